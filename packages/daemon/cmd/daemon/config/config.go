@@ -17,6 +17,20 @@ type Config struct {
 	EntrypointShutdownTimeoutSec int    `envconfig:"ENTRYPOINT_SHUTDOWN_TIMEOUT_SEC"`
 	SigtermShutdownTimeoutSec    int    `envconfig:"SIGTERM_SHUTDOWN_TIMEOUT_SEC"`
 	UserHomeAsWorkDir            bool   `envconfig:"DECK_USER_HOME_AS_WORKDIR"`
+	// Comma separated directories the file, git, process and SFTP APIs are
+	// confined to. Unset means no confinement.
+	WorkspaceRoots []string `envconfig:"DECK_WORKSPACE_ROOTS"`
+	// How long deleted files are kept in the trash and how many trash
+	// entries are kept at most
+	TrashRetentionHours int `envconfig:"DECK_TRASH_RETENTION_HOURS"`
+	TrashMaxItems       int `envconfig:"DECK_TRASH_MAX_ITEMS"`
+	// Process sessions without activity for this long, or that are older
+	// than the max lifetime, are terminated and their logs removed. Unset
+	// or 0 keeps sessions until they are deleted.
+	SessionIdleTimeoutMinutes int `envconfig:"DECK_SESSION_IDLE_TIMEOUT_MINUTES"`
+	SessionMaxLifetimeHours   int `envconfig:"DECK_SESSION_MAX_LIFETIME_HOURS"`
+	// How many jobs run at the same time. Unset or 0 means one per CPU.
+	JobsMaxConcurrency int `envconfig:"DECK_JOBS_MAX_CONCURRENCY"`
 }
 
 func defaultLogDir() string {
@@ -92,5 +106,14 @@ func GetConfig() (*Config, error) {
 		config.SigtermShutdownTimeoutSec = 5
 	}
 
+	if config.TrashRetentionHours <= 0 {
+		// Default to 7 days
+		config.TrashRetentionHours = 7 * 24
+	}
+
+	if config.TrashMaxItems <= 0 {
+		config.TrashMaxItems = 1000
+	}
+
 	return config, nil
 }
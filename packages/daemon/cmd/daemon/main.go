@@ -22,6 +22,7 @@ import (
 	"github.com/cofy-x/deck/apps/daemon/pkg/terminal"
 	"github.com/cofy-x/deck/apps/daemon/pkg/toolbox"
 	"github.com/cofy-x/deck/apps/daemon/pkg/toolbox/process"
+	"github.com/cofy-x/deck/apps/daemon/pkg/workspace"
 
 	common_consts "github.com/cofy-x/deck/packages/core-go/pkg/consts"
 	"github.com/cofy-x/deck/packages/core-go/pkg/log"
@@ -136,6 +137,15 @@ func main() {
 
 	log.Debugf("Starting Deck Daemon %s", internal.Version)
 
+	// Fail closed: serving unconfined APIs when confinement was requested
+	// would silently expose the whole filesystem.
+	if err := workspace.SetRoots(c.WorkspaceRoots); err != nil {
+		panic(err)
+	}
+	if workspace.Enabled() {
+		log.Infof("Confining API paths to workspace roots %v", workspace.Roots())
+	}
+
 	// Execute passed arguments as command
 	var entrypointCmd *exec.Cmd
 	var entrypointWg sync.WaitGroup
@@ -188,7 +198,12 @@ func main() {
 	}
 
 	toolBoxServer := &toolbox.Server{
-		WorkDir: workDir,
+		WorkDir:            workDir,
+		TrashRetention:     time.Duration(c.TrashRetentionHours) * time.Hour,
+		TrashMaxItems:      c.TrashMaxItems,
+		SessionIdleTimeout: time.Duration(c.SessionIdleTimeoutMinutes) * time.Minute,
+		SessionMaxLifetime: time.Duration(c.SessionMaxLifetimeHours) * time.Hour,
+		JobsMaxConcurrency: c.JobsMaxConcurrency,
 	}
 
 	// Start the toolbox server in a go routine
@@ -8,6 +8,7 @@ require (
 	github.com/cofy-x/deck/packages/computer-use v0.0.0
 	github.com/cofy-x/deck/packages/core-go v0.0.0
 	github.com/creack/pty v1.1.24
+	github.com/fsnotify/fsnotify v1.9.0
 	github.com/gin-gonic/gin v1.11.0
 	github.com/gliderlabs/ssh v0.3.8
 	github.com/go-git/go-git/v5 v5.16.4
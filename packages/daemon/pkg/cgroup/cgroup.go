@@ -0,0 +1,62 @@
+// Package cgroup runs processes started by the daemon in cgroup v2 groups
+// with memory, CPU and process count limits, so a runaway process can't
+// starve the rest of the sandbox, the daemon included.
+//
+// The groups are created in a subtree of the cgroup of the daemon. The daemon
+// usually is PID 1 of the sandbox, so its cgroup holds every process of the
+// sandbox. Those are moved to a leaf group first, as cgroup v2 only delegates
+// controllers from groups without processes.
+package cgroup
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrUnsupported is returned for limits on hosts without cgroup v2 or without
+// the memory, cpu and pids controllers delegated to the sandbox.
+var ErrUnsupported = errors.New("resource limits require cgroup v2 with the memory, cpu and pids controllers")
+
+const (
+	// smaller memory limits would kill most processes right away
+	minMemoryBytes = 4 << 20
+	// the smallest quota the kernel accepts is 1ms per 100ms period
+	minCpus = 0.01
+)
+
+type Limits struct {
+	// Maximum memory in bytes, page cache included. Processes exceeding it
+	// are killed by the OOM killer
+	MemoryBytes *int64 `json:"memoryBytes,omitempty" validate:"optional"`
+	// CPU time in cores, 0.5 allows half of one core
+	Cpus *float64 `json:"cpus,omitempty" validate:"optional"`
+	// Maximum number of processes and threads
+	Pids *int64 `json:"pids,omitempty" validate:"optional"`
+} //	@name	ResourceLimits
+
+// Empty reports whether no limit is set.
+func (l *Limits) Empty() bool {
+	return l == nil || (l.MemoryBytes == nil && l.Cpus == nil && l.Pids == nil)
+}
+
+// Validate checks that the limits are in range and can be applied on this
+// host.
+func (l *Limits) Validate() error {
+	if l.Empty() {
+		return nil
+	}
+	if l.MemoryBytes != nil && *l.MemoryBytes < minMemoryBytes {
+		return fmt.Errorf("memoryBytes must be at least %d", minMemoryBytes)
+	}
+	if l.Cpus != nil && (math.IsNaN(*l.Cpus) || math.IsInf(*l.Cpus, 0) || *l.Cpus < minCpus) {
+		return fmt.Errorf("cpus must be at least %v", minCpus)
+	}
+	if l.Pids != nil && *l.Pids < 1 {
+		return errors.New("pids must be at least 1")
+	}
+	if !Supported() {
+		return ErrUnsupported
+	}
+	return nil
+}
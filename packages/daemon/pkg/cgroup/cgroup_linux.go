@@ -0,0 +1,290 @@
+//go:build linux
+
+package cgroup
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cofy-x/deck/packages/core-go/pkg/log"
+	"github.com/google/uuid"
+)
+
+const (
+	mountPoint = "/sys/fs/cgroup"
+	// CPU quotas are enforced per period of 100ms
+	cpuPeriod = 100000
+)
+
+var controllers = []string{"memory", "cpu", "pids"}
+
+var (
+	setupOnce sync.Once
+	setupErr  error
+	// directory the groups are created in
+	parent string
+)
+
+// Group is a cgroup with limits. A nil Group stands for no limits, all its
+// methods are no-ops.
+type Group struct {
+	path string
+	dir  *os.File
+}
+
+// Supported reports whether limits can be applied. The subtree is set up on
+// the first call.
+func Supported() bool {
+	return setup() == nil
+}
+
+func setup() error {
+	setupOnce.Do(func() {
+		parent, setupErr = initSubtree()
+		if setupErr != nil {
+			log.Warnf("Resource limits are unavailable: %v", setupErr)
+		} else {
+			log.Debugf("Creating resource limited cgroups in %s", parent)
+		}
+	})
+	return setupErr
+}
+
+// initSubtree enables the controllers for the groups and returns the
+// directory to create them in.
+func initSubtree() (string, error) {
+	self, err := ownCgroup()
+	if err != nil {
+		return "", err
+	}
+	base := filepath.Join(mountPoint, self)
+
+	available, err := os.ReadFile(filepath.Join(base, "cgroup.controllers"))
+	if err != nil {
+		return "", fmt.Errorf("cgroup v2 is not mounted at %s: %w", mountPoint, err)
+	}
+	for _, controller := range controllers {
+		if !slices.Contains(strings.Fields(string(available)), controller) {
+			return "", fmt.Errorf("controller %s is not available", controller)
+		}
+	}
+
+	if err := enableControllers(base); err != nil {
+		// EBUSY means the group still holds processes
+		if !errors.Is(err, syscall.EBUSY) {
+			return "", err
+		}
+		if err := moveProcesses(base, filepath.Join(base, "daemon")); err != nil {
+			return "", err
+		}
+		if err := enableControllers(base); err != nil {
+			return "", err
+		}
+	}
+
+	dir := filepath.Join(base, "deck")
+	if err := os.Mkdir(dir, 0755); err != nil && !os.IsExist(err) {
+		return "", err
+	}
+	if err := enableControllers(dir); err != nil {
+		return "", err
+	}
+
+	// groups left behind by an earlier run, those still in use stay
+	if entries, err := os.ReadDir(dir); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() {
+				_ = os.Remove(filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	return dir, nil
+}
+
+// ownCgroup returns the cgroup v2 path of the daemon relative to the mount
+// point.
+func ownCgroup() (string, error) {
+	file, err := os.Open("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if path, ok := strings.CutPrefix(scanner.Text(), "0::"); ok {
+			return path, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.New("the daemon is not in a cgroup v2 hierarchy")
+}
+
+func enableControllers(dir string) error {
+	value := "+" + strings.Join(controllers, " +")
+	return os.WriteFile(filepath.Join(dir, "cgroup.subtree_control"), []byte(value), 0)
+}
+
+// moveProcesses moves all processes of the group at from to a new group at to.
+func moveProcesses(from, to string) error {
+	if err := os.Mkdir(to, 0755); err != nil && !os.IsExist(err) {
+		return err
+	}
+
+	// processes forked while moving may be missed, so try a few times
+	for range 10 {
+		pids, err := groupPids(from)
+		if err != nil {
+			return err
+		}
+		if len(pids) == 0 {
+			return nil
+		}
+		for _, pid := range pids {
+			// the process may have exited meanwhile
+			_ = os.WriteFile(filepath.Join(to, "cgroup.procs"), []byte(strconv.Itoa(pid)), 0)
+		}
+	}
+	return fmt.Errorf("failed to move the processes out of %s", from)
+}
+
+func groupPids(dir string) ([]int, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "cgroup.procs"))
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, field := range strings.Fields(string(data)) {
+		if pid, err := strconv.Atoi(field); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
+
+// New creates a group with the given limits. It returns a nil group when no
+// limit is set.
+func New(limits *Limits) (*Group, error) {
+	if limits.Empty() {
+		return nil, nil
+	}
+	if err := limits.Validate(); err != nil {
+		return nil, err
+	}
+
+	g := &Group{path: filepath.Join(parent, uuid.NewString())}
+	if err := os.Mkdir(g.path, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cgroup: %w", err)
+	}
+
+	type setting struct {
+		file, value string
+		optional    bool
+	}
+	var settings []setting
+	if limits.MemoryBytes != nil {
+		settings = append(settings,
+			setting{file: "memory.max", value: strconv.FormatInt(*limits.MemoryBytes, 10)},
+			// without swap accounting there is no swap to limit
+			setting{file: "memory.swap.max", value: "0", optional: true},
+		)
+	}
+	if limits.Cpus != nil {
+		quota := int64(*limits.Cpus * cpuPeriod)
+		settings = append(settings, setting{file: "cpu.max", value: fmt.Sprintf("%d %d", quota, cpuPeriod)})
+	}
+	if limits.Pids != nil {
+		settings = append(settings, setting{file: "pids.max", value: strconv.FormatInt(*limits.Pids, 10)})
+	}
+
+	for _, s := range settings {
+		err := os.WriteFile(filepath.Join(g.path, s.file), []byte(s.value), 0)
+		if err != nil && !(s.optional && os.IsNotExist(err)) {
+			g.Remove()
+			return nil, fmt.Errorf("failed to set %s: %w", s.file, err)
+		}
+	}
+	return g, nil
+}
+
+// Attach makes cmd start in the group, so the limits apply from its first
+// instruction on. It must be called before cmd is started.
+func (g *Group) Attach(cmd *exec.Cmd) error {
+	if g == nil {
+		return nil
+	}
+	dir, err := os.Open(g.path)
+	if err != nil {
+		return err
+	}
+	if g.dir != nil {
+		g.dir.Close()
+	}
+	g.dir = dir
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(dir.Fd())
+	return nil
+}
+
+// OOMKills returns how many processes of the group the OOM killer killed.
+func (g *Group) OOMKills() int {
+	if g == nil {
+		return 0
+	}
+	data, err := os.ReadFile(filepath.Join(g.path, "memory.events"))
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(line, "oom_kill "); ok {
+			count, _ := strconv.Atoi(strings.TrimSpace(value))
+			return count
+		}
+	}
+	return 0
+}
+
+// Remove kills the processes left in the group and deletes it.
+func (g *Group) Remove() {
+	if g == nil {
+		return
+	}
+	if g.dir != nil {
+		g.dir.Close()
+		g.dir = nil
+	}
+
+	// cgroup.kill needs Linux 5.14
+	if err := os.WriteFile(filepath.Join(g.path, "cgroup.kill"), []byte("1"), 0); err != nil {
+		pids, _ := groupPids(g.path)
+		for _, pid := range pids {
+			_ = syscall.Kill(pid, syscall.SIGKILL)
+		}
+	}
+
+	// the group can only be removed once the killed processes are gone
+	var err error
+	for range 50 {
+		if err = os.Remove(g.path); err == nil || os.IsNotExist(err) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	log.Warnf("Failed to remove cgroup %s: %v", g.path, err)
+}
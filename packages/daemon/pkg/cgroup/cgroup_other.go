@@ -0,0 +1,35 @@
+//go:build !linux
+
+package cgroup
+
+import "os/exec"
+
+// Group is a cgroup with limits. Without cgroups no group is ever created,
+// all methods of the nil Group are no-ops.
+type Group struct{}
+
+// Supported reports whether limits can be applied, which needs Linux.
+func Supported() bool {
+	return false
+}
+
+// New returns a nil group when no limit is set and ErrUnsupported otherwise.
+func New(limits *Limits) (*Group, error) {
+	if limits.Empty() {
+		return nil, nil
+	}
+	return nil, ErrUnsupported
+}
+
+// Attach makes cmd start in the group.
+func (g *Group) Attach(cmd *exec.Cmd) error {
+	return nil
+}
+
+// OOMKills returns how many processes of the group the OOM killer killed.
+func (g *Group) OOMKills() int {
+	return 0
+}
+
+// Remove kills the processes left in the group and deletes it.
+func (g *Group) Remove() {}
@@ -0,0 +1,32 @@
+package cgroup
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLimitsValidate(t *testing.T) {
+	bytes := func(v int64) *int64 { return &v }
+	cpus := func(v float64) *float64 { return &v }
+
+	var none *Limits
+	if !none.Empty() || none.Validate() != nil {
+		t.Fatal("expected nil limits to be empty and valid")
+	}
+	if err := (&Limits{}).Validate(); err != nil {
+		t.Fatalf("expected empty limits to be valid, got %v", err)
+	}
+
+	invalid := []Limits{
+		{MemoryBytes: bytes(1024)},
+		{Cpus: cpus(0)},
+		{Cpus: cpus(math.NaN())},
+		{Cpus: cpus(math.Inf(1))},
+		{Pids: bytes(0)},
+	}
+	for _, limits := range invalid {
+		if err := limits.Validate(); err == nil || err == ErrUnsupported {
+			t.Errorf("expected a range error for %+v, got %v", limits, err)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package common
+
+import "unicode/utf8"
+
+// SplitIncompleteRune splits data before the incomplete UTF-8 sequence it
+// ends with, if any. Writers receiving text in chunks carry partial over to
+// the next chunk, so a character cut in two is not mangled.
+func SplitIncompleteRune(data []byte) (complete, partial []byte) {
+	start := len(data)
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			start = i
+			break
+		}
+	}
+	if utf8.FullRune(data[start:]) {
+		return data, nil
+	}
+	return data[:start], data[start:]
+}
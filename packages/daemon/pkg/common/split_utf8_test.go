@@ -0,0 +1,29 @@
+package common
+
+import "testing"
+
+func TestSplitIncompleteRune(t *testing.T) {
+	euro := "€"
+	tests := []struct {
+		data     string
+		complete string
+		partial  string
+	}{
+		{"", "", ""},
+		{"abc", "abc", ""},
+		{"a" + euro, "a" + euro, ""},
+		{"a" + euro[:1], "a", euro[:1]},
+		{"a" + euro[:2], "a", euro[:2]},
+		{euro[:2], "", euro[:2]},
+		// invalid bytes are not held back
+		{"a\xff", "a\xff", ""},
+		{"a\x80\x80\x80\x80", "a\x80\x80\x80\x80", ""},
+	}
+
+	for _, tt := range tests {
+		complete, partial := SplitIncompleteRune([]byte(tt.data))
+		if string(complete) != tt.complete || string(partial) != tt.partial {
+			t.Errorf("%q: expected %q, %q, got %q, %q", tt.data, tt.complete, tt.partial, complete, partial)
+		}
+	}
+}
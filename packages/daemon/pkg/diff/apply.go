@@ -0,0 +1,154 @@
+package diff
+
+import (
+	"errors"
+	"strings"
+)
+
+// DefaultFuzz is the number of context lines that may be ignored at either
+// end of a hunk when it does not match exactly.
+const DefaultFuzz = 2
+
+// ErrConflict is returned by Apply when at least one hunk does not match.
+var ErrConflict = errors.New("patch does not apply")
+
+// HunkResult describes how a hunk was applied, or why it was not.
+type HunkResult struct {
+	Applied bool
+	// 1-based line of the original content where the hunk matched, or where
+	// it was expected when it did not match
+	Line int
+	// distance in lines from the position given in the hunk header
+	Offset int
+	// number of context lines ignored at each end of the hunk
+	Fuzz int
+
+	// set for hunks that were not applied
+	Reason   string
+	Expected []string
+	Actual   []string
+}
+
+// Apply applies the hunks of a file patch to content. Hunks are located
+// at the position from their header, shifted by the offset of the previous
+// hunk, or at the nearest position where they match. When no exact match
+// exists, up to maxFuzz context lines are ignored at either end of the hunk.
+//
+// All hunks are tried so that every conflict is reported. ErrConflict is
+// returned when any of them failed, in which case the returned content must
+// not be used.
+func Apply(content string, hunks []Hunk, maxFuzz int) (string, []HunkResult, error) {
+	lines := SplitLines(content)
+	results := make([]HunkResult, len(hunks))
+
+	var out []string
+	last := 0
+	offset := 0
+	failed := false
+
+	for i := range hunks {
+		h := &hunks[i]
+		oldLines, newLines := h.Old(), h.New()
+
+		// header lines are 1-based, except for hunks without old lines which
+		// insert after the given line
+		headerPos := h.OldStart - 1
+		if len(oldLines) == 0 {
+			headerPos = h.OldStart
+		}
+
+		pos, fuzz, ok := locate(lines, last, headerPos+offset, h, oldLines, maxFuzz)
+		if !ok {
+			failed = true
+			expected := min(max(headerPos+offset, last), len(lines))
+			results[i] = HunkResult{
+				Line:     expected + 1,
+				Reason:   "hunk context does not match the file",
+				Expected: oldLines,
+				Actual:   lines[expected:min(expected+len(oldLines), len(lines))],
+			}
+			continue
+		}
+
+		lead, trail := fuzzTrim(h, fuzz)
+		out = append(out, lines[last:pos]...)
+		out = append(out, newLines[lead:len(newLines)-trail]...)
+		last = pos + len(oldLines) - lead - trail
+
+		offset = pos - lead - headerPos
+		results[i] = HunkResult{
+			Applied: true,
+			Line:    pos - lead + 1,
+			Offset:  offset,
+			Fuzz:    fuzz,
+		}
+	}
+
+	if failed {
+		return "", results, ErrConflict
+	}
+
+	out = append(out, lines[last:]...)
+	return strings.Join(out, ""), results, nil
+}
+
+// locate finds the position in lines, at or after from, where the old side
+// of the hunk matches, preferring the least fuzz and then the position
+// closest to expected.
+func locate(lines []string, from, expected int, h *Hunk, oldLines []string, maxFuzz int) (int, int, bool) {
+	if len(oldLines) == 0 {
+		if expected < from || expected > len(lines) {
+			return 0, 0, false
+		}
+		return expected, 0, true
+	}
+
+	for fuzz := 0; fuzz <= maxFuzz; fuzz++ {
+		lead, trail := fuzzTrim(h, fuzz)
+		if fuzz > 0 && lead == 0 && trail == 0 {
+			// no context left to ignore
+			break
+		}
+		pattern := oldLines[lead : len(oldLines)-trail]
+		if len(pattern) == 0 {
+			break
+		}
+
+		target := expected + lead
+		for d := 0; ; d++ {
+			before, after := target-d, target+d
+			if before < from && after > len(lines)-len(pattern) {
+				break
+			}
+			if before >= from && before <= len(lines)-len(pattern) && matchAt(lines, before, pattern) {
+				return before, fuzz, true
+			}
+			if d > 0 && after >= from && after <= len(lines)-len(pattern) && matchAt(lines, after, pattern) {
+				return after, fuzz, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// fuzzTrim returns how many context lines to ignore at the start and end of
+// the hunk for the given fuzz factor.
+func fuzzTrim(h *Hunk, fuzz int) (int, int) {
+	lead, trail := 0, 0
+	for lead < len(h.Lines) && lead < fuzz && h.Lines[lead][0] == ' ' {
+		lead++
+	}
+	for trail < len(h.Lines)-lead && trail < fuzz && h.Lines[len(h.Lines)-1-trail][0] == ' ' {
+		trail++
+	}
+	return lead, trail
+}
+
+func matchAt(lines []string, pos int, pattern []string) bool {
+	for i, line := range pattern {
+		if lines[pos+i] != line {
+			return false
+		}
+	}
+	return true
+}
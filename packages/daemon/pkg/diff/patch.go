@@ -0,0 +1,204 @@
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DevNull is the file name used by unified diffs for a missing side of a
+// created or deleted file.
+const DevNull = "/dev/null"
+
+// FilePatch holds the hunks of a unified diff that apply to one file.
+type FilePatch struct {
+	OldName string
+	NewName string
+	Hunks   []Hunk
+}
+
+// IsCreate reports whether the patch creates a new file.
+func (p *FilePatch) IsCreate() bool {
+	return p.OldName == DevNull
+}
+
+// IsDelete reports whether the patch deletes the file.
+func (p *FilePatch) IsDelete() bool {
+	return p.NewName == DevNull
+}
+
+// Hunk is a single @@ section of a unified diff. Lines keep their leading
+// ' ', '-' or '+' marker and their trailing "\n", which is missing when the
+// line is the last one of a file without a final newline.
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []string
+}
+
+// Old returns the lines the hunk expects to find, without markers.
+func (h *Hunk) Old() []string {
+	return h.side('+')
+}
+
+// New returns the lines the hunk leaves in place, without markers.
+func (h *Hunk) New() []string {
+	return h.side('-')
+}
+
+func (h *Hunk) side(skip byte) []string {
+	lines := make([]string, 0, len(h.Lines))
+	for _, line := range h.Lines {
+		if line[0] != skip {
+			lines = append(lines, line[1:])
+		}
+	}
+	return lines
+}
+
+// Parse reads a unified diff that may contain several files. Text outside
+// of file headers and hunks, such as git extended headers, is ignored.
+func Parse(patch string) ([]*FilePatch, error) {
+	lines := SplitLines(patch)
+
+	var files []*FilePatch
+	for i := 0; i < len(lines); i++ {
+		if !strings.HasPrefix(lines[i], "--- ") || i+1 == len(lines) || !strings.HasPrefix(lines[i+1], "+++ ") {
+			continue
+		}
+
+		file := &FilePatch{
+			OldName: headerName(lines[i][4:]),
+			NewName: headerName(lines[i+1][4:]),
+		}
+		i += 2
+
+		for i < len(lines) && strings.HasPrefix(lines[i], "@@ ") {
+			hunk, next, err := parseHunk(lines, i)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file.NewName, err)
+			}
+			file.Hunks = append(file.Hunks, hunk)
+			i = next
+		}
+		i--
+
+		if len(file.Hunks) == 0 {
+			return nil, fmt.Errorf("%s: no hunks found", file.NewName)
+		}
+		files = append(files, file)
+	}
+
+	if len(files) == 0 {
+		return nil, errors.New("no file patches found")
+	}
+	return files, nil
+}
+
+// headerName extracts the file name from a ---/+++ line, dropping the
+// optional timestamp that follows a tab.
+func headerName(s string) string {
+	s = strings.TrimRight(s, "\r\n")
+	if i := strings.IndexByte(s, '\t'); i >= 0 {
+		s = s[:i]
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	return s
+}
+
+// parseHunk parses the hunk starting at lines[i] and returns it together
+// with the index of the first line after it.
+func parseHunk(lines []string, i int) (Hunk, int, error) {
+	var h Hunk
+	header := strings.TrimRight(lines[i], "\r\n")
+	if err := parseHunkHeader(header, &h); err != nil {
+		return h, 0, err
+	}
+	i++
+
+	oldSeen, newSeen := 0, 0
+	for i < len(lines) && (oldSeen < h.OldLines || newSeen < h.NewLines) {
+		line := lines[i]
+		switch line[0] {
+		case ' ', '-', '+':
+		case '\n':
+			// some tools strip the space of empty context lines
+			line = " \n"
+		case '\\':
+			// "\ No newline at end of file" applies to the previous line
+			if len(h.Lines) > 0 {
+				last := len(h.Lines) - 1
+				h.Lines[last] = strings.TrimSuffix(h.Lines[last], "\n")
+			}
+			i++
+			continue
+		default:
+			return h, 0, fmt.Errorf("unexpected line in hunk %q: %q", header, strings.TrimRight(line, "\n"))
+		}
+
+		if line[0] != '+' {
+			oldSeen++
+		}
+		if line[0] != '-' {
+			newSeen++
+		}
+		h.Lines = append(h.Lines, line)
+		i++
+	}
+
+	if oldSeen != h.OldLines || newSeen != h.NewLines {
+		return h, 0, fmt.Errorf("hunk %q is truncated", header)
+	}
+
+	if i < len(lines) && strings.HasPrefix(lines[i], "\\") && len(h.Lines) > 0 {
+		last := len(h.Lines) - 1
+		h.Lines[last] = strings.TrimSuffix(h.Lines[last], "\n")
+		i++
+	}
+
+	return h, i, nil
+}
+
+func parseHunkHeader(header string, h *Hunk) error {
+	rest, ok := strings.CutPrefix(header, "@@ -")
+	if !ok {
+		return fmt.Errorf("invalid hunk header %q", header)
+	}
+	end := strings.Index(rest, " @@")
+	if end < 0 {
+		return fmt.Errorf("invalid hunk header %q", header)
+	}
+	oldRange, newRange, ok := strings.Cut(rest[:end], " +")
+	if !ok {
+		return fmt.Errorf("invalid hunk header %q", header)
+	}
+
+	var err error
+	if h.OldStart, h.OldLines, err = parseRange(oldRange); err != nil {
+		return fmt.Errorf("invalid hunk header %q: %w", header, err)
+	}
+	if h.NewStart, h.NewLines, err = parseRange(newRange); err != nil {
+		return fmt.Errorf("invalid hunk header %q: %w", header, err)
+	}
+	return nil
+}
+
+func parseRange(s string) (int, int, error) {
+	startStr, countStr, hasCount := strings.Cut(s, ",")
+	start, err := strconv.Atoi(startStr)
+	if err != nil || start < 0 {
+		return 0, 0, fmt.Errorf("invalid range %q", s)
+	}
+	count := 1
+	if hasCount {
+		if count, err = strconv.Atoi(countStr); err != nil || count < 0 {
+			return 0, 0, fmt.Errorf("invalid range %q", s)
+		}
+	}
+	return start, count, nil
+}
@@ -0,0 +1,164 @@
+package diff
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func numberedLines(n int) string {
+	var sb strings.Builder
+	for i := 1; i <= n; i++ {
+		sb.WriteString(strings.Repeat("x", i%7))
+		sb.WriteString(string(rune('a' + i%26)))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func TestParseMultipleFiles(t *testing.T) {
+	patch := "diff --git a/one.txt b/one.txt\n" +
+		"index 1111111..2222222 100644\n" +
+		"--- a/one.txt\t2024-01-01 00:00:00\n" +
+		"+++ b/one.txt\n" +
+		"@@ -1,2 +1,2 @@\n" +
+		" keep\n" +
+		"-old\n" +
+		"+new\n" +
+		"--- /dev/null\n" +
+		"+++ b/two.txt\n" +
+		"@@ -0,0 +1 @@\n" +
+		"+created\n" +
+		"\\ No newline at end of file\n"
+
+	files, err := Parse(patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(files))
+	}
+	if files[0].OldName != "a/one.txt" || files[0].NewName != "b/one.txt" {
+		t.Fatalf("unexpected names: %q %q", files[0].OldName, files[0].NewName)
+	}
+	if !files[1].IsCreate() || files[1].IsDelete() {
+		t.Fatalf("expected a file creation")
+	}
+	if got := files[1].Hunks[0].New(); len(got) != 1 || got[0] != "created" {
+		t.Fatalf("unexpected new lines: %q", got)
+	}
+}
+
+func TestParseTruncatedHunk(t *testing.T) {
+	if _, err := Parse("--- a\n+++ b\n@@ -1,3 +1,3 @@\n a\n-b\n"); err == nil {
+		t.Fatal("expected an error for a truncated hunk")
+	}
+}
+
+func TestApplyRoundTrip(t *testing.T) {
+	oldContent := numberedLines(40)
+	lines := SplitLines(oldContent)
+	lines[3] = "changed\n"
+	lines = append(lines[:20], append([]string{"inserted\n"}, lines[22:]...)...)
+	newContent := strings.Join(lines, "") + "tail"
+
+	files, err := Parse(Unified("a", "b", oldContent, newContent))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, results, err := Apply(oldContent, files[0].Hunks, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != newContent {
+		t.Fatalf("unexpected result:\n%s", got)
+	}
+	for _, r := range results {
+		if !r.Applied || r.Offset != 0 || r.Fuzz != 0 {
+			t.Fatalf("unexpected hunk result: %+v", r)
+		}
+	}
+}
+
+func TestApplyWithOffset(t *testing.T) {
+	oldContent := "a\nb\nc\nd\ne\n"
+	files, err := Parse(Unified("a", "b", oldContent, "a\nb\nC\nd\ne\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, results, err := Apply("new 1\nnew 2\n"+oldContent, files[0].Hunks, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "new 1\nnew 2\na\nb\nC\nd\ne\n" {
+		t.Fatalf("unexpected result: %q", got)
+	}
+	if results[0].Offset != 2 || results[0].Line != 3 {
+		t.Fatalf("unexpected hunk result: %+v", results[0])
+	}
+}
+
+func TestApplyWithFuzz(t *testing.T) {
+	patch := "--- a\n+++ b\n@@ -1,5 +1,5 @@\n a\n b\n-c\n+C\n d\n e\n"
+	files, err := Parse(patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the outer context lines differ from the file
+	if _, _, err := Apply("A\nb\nc\nd\nE\n", files[0].Hunks, 0); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected a conflict without fuzz, got %v", err)
+	}
+
+	got, results, err := Apply("A\nb\nc\nd\nE\n", files[0].Hunks, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "A\nb\nC\nd\nE\n" {
+		t.Fatalf("unexpected result: %q", got)
+	}
+	if results[0].Fuzz != 1 {
+		t.Fatalf("unexpected hunk result: %+v", results[0])
+	}
+}
+
+func TestApplyConflict(t *testing.T) {
+	patch := "--- a\n+++ b\n" +
+		"@@ -1,2 +1,2 @@\n a\n-b\n+B\n" +
+		"@@ -4,2 +4,2 @@\n d\n-e\n+E\n"
+	files, err := Parse(patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, results, err := Apply("a\nb\nc\nd\nx\n", files[0].Hunks, DefaultFuzz)
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected a conflict, got %v", err)
+	}
+	if !results[0].Applied {
+		t.Fatalf("expected the first hunk to apply: %+v", results[0])
+	}
+	r := results[1]
+	if r.Applied || r.Line != 4 || len(r.Expected) != 2 || r.Actual[1] != "x\n" {
+		t.Fatalf("unexpected conflict: %+v", r)
+	}
+}
+
+func TestApplyMissingNewline(t *testing.T) {
+	oldContent := "x\ny"
+	newContent := "x\ny\nz\n"
+	files, err := Parse(Unified("a", "b", oldContent, newContent))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, _, err := Apply(oldContent, files[0].Hunks, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != newContent {
+		t.Fatalf("unexpected result: %q", got)
+	}
+}
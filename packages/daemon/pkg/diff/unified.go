@@ -0,0 +1,255 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines shown around each change.
+const DefaultContext = 3
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// op is a single line edit. a and b are the positions in the old and new
+// line slices at which the edit applies.
+type op struct {
+	kind opKind
+	a, b int
+	line string
+}
+
+// SplitLines splits s into lines, keeping the trailing "\n" on each line so
+// that a missing newline at end of file is preserved.
+func SplitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Unified returns a unified diff turning oldContent into newContent, using
+// DefaultContext lines of context. It returns an empty string when both
+// contents are equal.
+func Unified(oldName, newName, oldContent, newContent string) string {
+	return UnifiedContext(oldName, newName, oldContent, newContent, DefaultContext)
+}
+
+// UnifiedContext is like Unified with a custom number of context lines.
+func UnifiedContext(oldName, newName, oldContent, newContent string, context int) string {
+	if oldContent == newContent {
+		return ""
+	}
+
+	ops := diffLines(SplitLines(oldContent), SplitLines(newContent))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+
+	i := 0
+	for i < len(ops) {
+		for i < len(ops) && ops[i].kind == opEqual {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+
+		start := max(0, i-context)
+		end := i
+		for {
+			for end < len(ops) && ops[end].kind != opEqual {
+				end++
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == opEqual {
+				next++
+			}
+			if next == len(ops) || next-end > 2*context {
+				end = min(len(ops), end+context)
+				break
+			}
+			end = next
+		}
+
+		writeHunk(&sb, ops[start:end])
+		i = end
+	}
+
+	return sb.String()
+}
+
+func writeHunk(sb *strings.Builder, ops []op) {
+	oldStart, newStart := ops[0].a+1, ops[0].b+1
+	oldCount, newCount := 0, 0
+	for _, o := range ops {
+		if o.kind != opInsert {
+			oldCount++
+		}
+		if o.kind != opDelete {
+			newCount++
+		}
+	}
+	if oldCount == 0 {
+		oldStart--
+	}
+	if newCount == 0 {
+		newStart--
+	}
+
+	fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+	for _, o := range ops {
+		switch o.kind {
+		case opEqual:
+			sb.WriteByte(' ')
+		case opDelete:
+			sb.WriteByte('-')
+		case opInsert:
+			sb.WriteByte('+')
+		}
+		sb.WriteString(o.line)
+		if !strings.HasSuffix(o.line, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// diffLines computes a minimal line edit script using Myers' algorithm.
+func diffLines(a, b []string) []op {
+	// Trim the common prefix and suffix to keep the search space small.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]op, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, op{kind: opEqual, a: i, b: i, line: a[i]})
+	}
+
+	for _, o := range myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		o.a += prefix
+		o.b += prefix
+		ops = append(ops, o)
+	}
+
+	for i := suffix; i > 0; i-- {
+		ops = append(ops, op{kind: opEqual, a: len(a) - i, b: len(b) - i, line: a[len(a)-i]})
+	}
+
+	return ops
+}
+
+// maxEdits bounds the number of edit steps searched by myers. Its trace
+// grows with the square of the steps, so inputs that differ more are
+// replaced as a whole instead.
+const maxEdits = 1000
+
+func myers(a, b []string) []op {
+	n, m := len(a), len(b)
+	limit := n + m
+	if limit > maxEdits {
+		limit = maxEdits
+	}
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+
+	// trace[d] holds the furthest x reached on diagonals -d..d after step d.
+	var trace [][]int
+	done := false
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+		if done {
+			break
+		}
+	}
+	if !done {
+		return replaceLines(a, b)
+	}
+
+	var ops []op
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		at := func(k int) int { return prev[k+d-1] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, op{kind: opEqual, a: x, b: y, line: a[x]})
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, op{kind: opInsert, a: x, b: y, line: b[y]})
+		} else {
+			x--
+			ops = append(ops, op{kind: opDelete, a: x, b: y, line: a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		ops = append(ops, op{kind: opEqual, a: x, b: y, line: a[x]})
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// replaceLines deletes all of a and inserts all of b.
+func replaceLines(a, b []string) []op {
+	ops := make([]op, 0, len(a)+len(b))
+	for i, line := range a {
+		ops = append(ops, op{kind: opDelete, a: i, b: 0, line: line})
+	}
+	for j, line := range b {
+		ops = append(ops, op{kind: opInsert, a: len(a), b: j, line: line})
+	}
+	return ops
+}
@@ -0,0 +1,110 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedEqual(t *testing.T) {
+	if got := Unified("a", "b", "x\ny\n", "x\ny\n"); got != "" {
+		t.Fatalf("expected empty diff, got: %q", got)
+	}
+}
+
+func TestUnifiedSingleChange(t *testing.T) {
+	oldContent := "1\n2\n3\n4\n5\n6\n7\n8\n9\n"
+	newContent := "1\n2\n3\n4\nfive\n6\n7\n8\n9\n"
+
+	want := "--- a\n+++ b\n" +
+		"@@ -2,7 +2,7 @@\n" +
+		" 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n"
+
+	if got := Unified("a", "b", oldContent, newContent); got != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnifiedSeparateHunks(t *testing.T) {
+	lines := make([]string, 20)
+	for i := range lines {
+		lines[i] = string(rune('a'+i)) + "\n"
+	}
+	oldContent := strings.Join(lines, "")
+	changed := append([]string{}, lines...)
+	changed[1] = "B\n"
+	changed[18] = "S\n"
+	newContent := strings.Join(changed, "")
+
+	got := Unified("a", "b", oldContent, newContent)
+	if n := strings.Count(got, "@@ -"); n != 2 {
+		t.Fatalf("expected 2 hunks, got %d:\n%s", n, got)
+	}
+	if !strings.Contains(got, "@@ -1,5 +1,5 @@\n") || !strings.Contains(got, "@@ -16,5 +16,5 @@\n") {
+		t.Fatalf("unexpected hunk headers:\n%s", got)
+	}
+}
+
+func TestUnifiedNewFile(t *testing.T) {
+	want := "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+x\n+y\n"
+	if got := Unified("a", "b", "", "x\ny\n"); got != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnifiedMissingNewline(t *testing.T) {
+	want := "--- a\n+++ b\n@@ -1,2 +1,2 @@\n x\n-y\n\\ No newline at end of file\n+y\n"
+	if got := Unified("a", "b", "x\ny", "x\ny\n"); got != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDiffLinesRoundTrip(t *testing.T) {
+	a := SplitLines("a\nb\nc\nd\ne\nf\n")
+	b := SplitLines("x\nb\nd\ne\ny\nf\nz\n")
+
+	var gotA, gotB []string
+	for _, o := range diffLines(a, b) {
+		if o.kind != opInsert {
+			gotA = append(gotA, o.line)
+		}
+		if o.kind != opDelete {
+			gotB = append(gotB, o.line)
+		}
+	}
+
+	if strings.Join(gotA, "") != strings.Join(a, "") || strings.Join(gotB, "") != strings.Join(b, "") {
+		t.Fatalf("edit script does not reproduce inputs: %q / %q", gotA, gotB)
+	}
+}
+
+func TestUnifiedLargeDifferentInputs(t *testing.T) {
+	const n = 100000
+	var oldLines, newLines strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&oldLines, "old %d\n", i)
+		fmt.Fprintf(&newLines, "new %d\n", i)
+	}
+	oldContent := "first\n" + oldLines.String() + "last\n"
+	newContent := "first\n" + newLines.String() + "last\n"
+
+	got := Unified("a", "b", oldContent, newContent)
+	if n := strings.Count(got, "@@ -"); n != 1 {
+		t.Fatalf("expected 1 hunk, got %d", n)
+	}
+	if !strings.HasPrefix(got, "--- a\n+++ b\n@@ -1,100002 +1,100002 @@\n first\n-old 0\n") {
+		t.Fatalf("unexpected diff start:\n%s", got[:100])
+	}
+
+	files, err := Parse(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	patched, _, err := Apply(oldContent, files[0].Hunks, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patched != newContent {
+		t.Fatal("applying the diff does not reproduce the new content")
+	}
+}
@@ -0,0 +1,18 @@
+// Package fsutil holds the file system helpers shared by the daemon APIs
+// that work on directory trees.
+package fsutil
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Within reports whether path is root or lies below it. Both paths must be
+// clean.
+func Within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,58 @@
+package fsutil
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// MatchAnyGlob reports whether the base name or the root-relative path of
+// path matches one of the given globs. Globs may use ** to match any number
+// of directories.
+func MatchAnyGlob(globs []string, root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = path
+	}
+	rel = filepath.ToSlash(rel)
+	base := filepath.Base(path)
+
+	for _, glob := range globs {
+		if MatchGlob(glob, base) || MatchGlob(glob, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchGlob reports whether the slash separated name matches glob. Besides
+// the filepath.Match syntax, a ** path segment matches zero or more
+// directories.
+func MatchGlob(glob, name string) bool {
+	if !strings.Contains(glob, "**") {
+		matched, _ := filepath.Match(glob, name)
+		return matched
+	}
+	return matchSegments(strings.Split(glob, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(glob, name []string) bool {
+	for len(glob) > 0 {
+		if glob[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(glob[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(glob[0], name[0]); !matched {
+			return false
+		}
+		glob, name = glob[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,27 @@
+package fsutil
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		glob  string
+		name  string
+		match bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "cmd/daemon/main.go", true},
+		{"pkg/**", "pkg/a/b", true},
+		{"pkg/**/test/*.go", "pkg/test/a.go", true},
+		{"pkg/**/test/*.go", "pkg/a/b/test/a.go", true},
+		{"pkg/**/test/*.go", "pkg/a/b/test/c/a.go", false},
+		{"src/**", "other/a", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchGlob(tt.glob, tt.name); got != tt.match {
+			t.Errorf("MatchGlob(%q, %q) = %v, expected %v", tt.glob, tt.name, got, tt.match)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package fsutil
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+)
+
+// Hash returns the hex encoded SHA-256 of everything read from r. It is the
+// form in which content hashes are exchanged with clients.
+func Hash(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HashFile returns the hash of the content of the file at path.
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return Hash(f)
+}
+
+// HashBytes returns the hash of content.
+func HashBytes(content []byte) string {
+	// reading from memory cannot fail
+	hash, _ := Hash(bytes.NewReader(content))
+	return hash
+}
+
+// IsHash reports whether s is a hash in the form Hash returns it.
+func IsHash(s string) bool {
+	if len(s) != sha256.Size*2 {
+		return false
+	}
+	b, err := hex.DecodeString(s)
+	// hashes are compared as strings, so only the lowercase form is valid
+	return err == nil && hex.EncodeToString(b) == s
+}
@@ -0,0 +1,67 @@
+package fsutil
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// The Stage functions create a file at a temporary path next to target and
+// return that path. The caller renames it over target, or removes it.
+
+// StageCopy copies the content of source with the given mode.
+func StageCopy(target, source string, mode os.FileMode) (string, error) {
+	src, err := os.Open(source)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".tmp-*")
+	if err != nil {
+		return "", err
+	}
+
+	_, err = io.Copy(tmp, src)
+	if err == nil {
+		err = tmp.Chmod(mode)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// StageSymlink creates a symlink to linkTarget.
+func StageSymlink(target, linkTarget string) (string, error) {
+	return stageLink(target, func(tmp string) error {
+		return os.Symlink(linkTarget, tmp)
+	})
+}
+
+// StageHardlink creates a hard link to source.
+func StageHardlink(target, source string) (string, error) {
+	return stageLink(target, func(tmp string) error {
+		return os.Link(source, tmp)
+	})
+}
+
+func stageLink(target string, link func(tmp string) error) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	tmp.Close()
+	// the name was only reserved, the link takes its place
+	if err := os.Remove(tmp.Name()); err != nil {
+		return "", err
+	}
+	if err := link(tmp.Name()); err != nil {
+		return "", err
+	}
+	return tmp.Name(), nil
+}
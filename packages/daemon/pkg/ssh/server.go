@@ -12,6 +12,7 @@ import (
 
 	"github.com/cofy-x/deck/apps/daemon/pkg/common"
 	"github.com/cofy-x/deck/apps/daemon/pkg/ssh/config"
+	"github.com/cofy-x/deck/apps/daemon/pkg/workspace"
 	"github.com/gliderlabs/ssh"
 	"github.com/pkg/sftp"
 	"golang.org/x/sys/unix"
@@ -276,6 +277,11 @@ func (s *Server) osSignalFrom(sig ssh.Signal) os.Signal {
 }
 
 func (s *Server) sftpHandler(session ssh.Session) {
+	if workspace.Enabled() {
+		s.jailedSftpHandler(session)
+		return
+	}
+
 	server, err := sftp.NewServer(session, sftp.WithDebug(io.Discard))
 	if err != nil {
 		log.Errorf("SFTP init error: %v", err)
@@ -286,3 +292,13 @@ func (s *Server) sftpHandler(session ssh.Session) {
 	}
 	server.Close()
 }
+
+// jailedSftpHandler serves SFTP confined to the workspace roots, starting in
+// the first root.
+func (s *Server) jailedSftpHandler(session ssh.Session) {
+	server := sftp.NewRequestServer(session, newJailedHandlers(), sftp.WithStartDirectory(workspace.DefaultDir()))
+	if err := server.Serve(); err != nil && err != io.EOF {
+		log.Errorf("SFTP session error: %v", err)
+	}
+	server.Close()
+}
@@ -0,0 +1,238 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/workspace"
+	"github.com/pkg/sftp"
+)
+
+// jailedHandler serves SFTP requests from the local filesystem, confined to
+// the workspace roots. Paths are checked on every request, so a symlink
+// created later cannot be used to leave the roots either.
+type jailedHandler struct{}
+
+func newJailedHandlers() sftp.Handlers {
+	h := &jailedHandler{}
+	return sftp.Handlers{FileGet: h, FilePut: h, FileCmd: h, FileList: h}
+}
+
+// check confines path to the workspace roots, following symlinks unless
+// follow is false.
+func (h *jailedHandler) check(path string, follow bool) (string, error) {
+	check := workspace.Check
+	if !follow {
+		check = workspace.CheckNoFollow
+	}
+	checked, err := check(path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", sftp.ErrSSHFxPermissionDenied, err)
+	}
+	return checked, nil
+}
+
+func (h *jailedHandler) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	path, err := h.check(r.Filepath, true)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (h *jailedHandler) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	return h.OpenFile(r)
+}
+
+func (h *jailedHandler) OpenFile(r *sftp.Request) (sftp.WriterAtReaderAt, error) {
+	path, err := h.check(r.Filepath, true)
+	if err != nil {
+		return nil, err
+	}
+
+	pflags := r.Pflags()
+	flags := 0
+	switch {
+	case pflags.Read && pflags.Write:
+		flags = os.O_RDWR
+	case pflags.Write:
+		flags = os.O_WRONLY
+	default:
+		flags = os.O_RDONLY
+	}
+	// O_APPEND conflicts with WriteAt, the client sends the offsets itself
+	if pflags.Creat {
+		flags |= os.O_CREATE
+	}
+	if pflags.Trunc {
+		flags |= os.O_TRUNC
+	}
+	if pflags.Excl {
+		flags |= os.O_EXCL
+	}
+
+	return os.OpenFile(path, flags, 0o644)
+}
+
+func (h *jailedHandler) Filecmd(r *sftp.Request) error {
+	switch r.Method {
+	case "Setstat":
+		path, err := h.check(r.Filepath, true)
+		if err != nil {
+			return err
+		}
+		return setstat(path, r.AttrFlags(), r.Attributes())
+	case "Rename":
+		source, err := h.check(r.Filepath, false)
+		if err != nil {
+			return err
+		}
+		target, err := h.check(r.Target, false)
+		if err != nil {
+			return err
+		}
+		return os.Rename(source, target)
+	case "Rmdir", "Remove":
+		path, err := h.check(r.Filepath, false)
+		if err != nil {
+			return err
+		}
+		info, err := os.Lstat(path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() != (r.Method == "Rmdir") {
+			if info.IsDir() {
+				return fmt.Errorf("%s is a directory", r.Filepath)
+			}
+			return fmt.Errorf("%s is not a directory", r.Filepath)
+		}
+		return os.Remove(path)
+	case "Mkdir":
+		path, err := h.check(r.Filepath, false)
+		if err != nil {
+			return err
+		}
+		return os.Mkdir(path, 0o755)
+	case "Link":
+		// Filepath is the existing file, Target the new link
+		source, err := h.check(r.Filepath, true)
+		if err != nil {
+			return err
+		}
+		target, err := h.check(r.Target, false)
+		if err != nil {
+			return err
+		}
+		return os.Link(source, target)
+	case "Symlink":
+		// Filepath is the link target as sent by the client, Target the new link
+		target, err := h.check(r.Target, false)
+		if err != nil {
+			return err
+		}
+		linkTarget := r.Filepath
+		if !filepath.IsAbs(linkTarget) {
+			linkTarget = filepath.Join(filepath.Dir(target), linkTarget)
+		}
+		if _, err := h.check(linkTarget, true); err != nil {
+			return err
+		}
+		return os.Symlink(r.Filepath, target)
+	default:
+		return sftp.ErrSSHFxOpUnsupported
+	}
+}
+
+func (h *jailedHandler) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	path, err := h.check(r.Filepath, true)
+	if err != nil {
+		return nil, err
+	}
+
+	switch r.Method {
+	case "List":
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		infos := make([]os.FileInfo, 0, len(entries))
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			infos = append(infos, info)
+		}
+		return listerAt(infos), nil
+	case "Stat":
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		return listerAt{info}, nil
+	default:
+		return nil, sftp.ErrSSHFxOpUnsupported
+	}
+}
+
+func (h *jailedHandler) Lstat(r *sftp.Request) (sftp.ListerAt, error) {
+	path, err := h.check(r.Filepath, false)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+	return listerAt{info}, nil
+}
+
+func (h *jailedHandler) Readlink(path string) (string, error) {
+	path, err := h.check(path, false)
+	if err != nil {
+		return "", err
+	}
+	return os.Readlink(path)
+}
+
+// setstat applies the attributes of a Setstat request to path.
+func setstat(path string, flags sftp.FileAttrFlags, attrs *sftp.FileStat) error {
+	if flags.Size {
+		if err := os.Truncate(path, int64(attrs.Size)); err != nil {
+			return err
+		}
+	}
+	if flags.Permissions {
+		if err := os.Chmod(path, attrs.FileMode().Perm()); err != nil {
+			return err
+		}
+	}
+	if flags.UidGid {
+		if err := os.Chown(path, int(attrs.UID), int(attrs.GID)); err != nil {
+			return err
+		}
+	}
+	if flags.Acmodtime {
+		if err := os.Chtimes(path, attrs.AccessTime(), attrs.ModTime()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listerAt serves a fixed list of file infos.
+type listerAt []os.FileInfo
+
+func (l listerAt) ListAt(ls []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	n := copy(ls, l[offset:])
+	if n < len(ls) {
+		return n, io.EOF
+	}
+	return n, nil
+}
@@ -0,0 +1,332 @@
+package checkpoint
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/diff"
+	"github.com/cofy-x/deck/apps/daemon/pkg/workspace"
+	"github.com/gin-gonic/gin"
+)
+
+// maxDiffSize is the largest file a text diff is computed for.
+const maxDiffSize = 1 << 20
+
+type CheckpointController struct {
+	store   *Store
+	workDir string
+}
+
+// NewCheckpointController creates a controller that keeps its checkpoints
+// in configDir/checkpoints.
+func NewCheckpointController(configDir, workDir string) *CheckpointController {
+	return &CheckpointController{
+		store:   NewStore(filepath.Join(configDir, "checkpoints"), configDir),
+		workDir: workDir,
+	}
+}
+
+// CreateCheckpoint godoc
+//
+//	@Summary		Create a checkpoint
+//	@Description	Snapshot a directory, by default the work directory. File contents are stored once, unchanged files are shared with earlier checkpoints.
+//	@Tags			checkpoint
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		CreateCheckpointRequest	true	"Checkpoint request"
+//	@Success		201		{object}	Checkpoint
+//	@Router			/checkpoints [post]
+//
+//	@id				CreateCheckpoint
+func (cc *CheckpointController) CreateCheckpoint(c *gin.Context) {
+	var req CreateCheckpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	root := cc.workDir
+	if workspace.Enabled() {
+		root = workspace.DefaultDir()
+	}
+	if req.Path != nil && *req.Path != "" {
+		root = *req.Path
+	}
+	if root == "" {
+		root = "."
+	}
+
+	for _, glob := range req.Exclude {
+		if _, err := path.Match(glob, ""); err != nil {
+			c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid exclude glob %q: %w", glob, err))
+			return
+		}
+	}
+
+	root, err := workspace.Check(root)
+	if err != nil {
+		c.AbortWithError(http.StatusForbidden, err)
+		return
+	}
+
+	root, err = filepath.Abs(root)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid path: %w", err))
+		return
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	if !info.IsDir() {
+		c.AbortWithError(http.StatusBadRequest, errors.New("path must be a directory"))
+		return
+	}
+
+	cp, err := cc.store.Create(c.Request.Context(), root, req.Message, req.Exclude)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	cp.Entries = nil
+	c.JSON(http.StatusCreated, cp)
+}
+
+// ListCheckpoints godoc
+//
+//	@Summary		List checkpoints
+//	@Description	List all checkpoints, oldest first. Entries are not included.
+//	@Tags			checkpoint
+//	@Produce		json
+//	@Success		200	{array}	Checkpoint
+//	@Router			/checkpoints [get]
+//
+//	@id				ListCheckpoints
+func (cc *CheckpointController) ListCheckpoints(c *gin.Context) {
+	checkpoints, err := cc.store.List()
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, checkpoints)
+}
+
+// GetCheckpoint godoc
+//
+//	@Summary		Get a checkpoint
+//	@Description	Get a checkpoint with all of its entries
+//	@Tags			checkpoint
+//	@Produce		json
+//	@Param			checkpointId	path		string	true	"Checkpoint ID"
+//	@Success		200				{object}	Checkpoint
+//	@Router			/checkpoints/{checkpointId} [get]
+//
+//	@id				GetCheckpoint
+func (cc *CheckpointController) GetCheckpoint(c *gin.Context) {
+	cp, ok := cc.load(c, c.Param("checkpointId"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, cp)
+}
+
+// DeleteCheckpoint godoc
+//
+//	@Summary		Delete a checkpoint
+//	@Description	Delete a checkpoint and the stored contents no other checkpoint uses
+//	@Tags			checkpoint
+//	@Param			checkpointId	path	string	true	"Checkpoint ID"
+//	@Success		204
+//	@Router			/checkpoints/{checkpointId} [delete]
+//
+//	@id				DeleteCheckpoint
+func (cc *CheckpointController) DeleteCheckpoint(c *gin.Context) {
+	if err := cc.store.Delete(c.Param("checkpointId")); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DiffCheckpoint godoc
+//
+//	@Summary		Diff a checkpoint
+//	@Description	List the files that differ between a checkpoint and another checkpoint, or the current files of the checkpoint directory when no other checkpoint is given. Changes are described from the checkpoint towards the other side.
+//	@Tags			checkpoint
+//	@Produce		json
+//	@Param			checkpointId	path		string	true	"Checkpoint ID"
+//	@Param			to				query		string	false	"Checkpoint ID to compare with (default: current files)"
+//	@Param			patch			query		boolean	false	"Include unified diffs of changed text files"
+//	@Success		200				{object}	CheckpointDiff
+//	@Router			/checkpoints/{checkpointId}/diff [get]
+//
+//	@id				DiffCheckpoint
+func (cc *CheckpointController) DiffCheckpoint(c *gin.Context) {
+	from, ok := cc.load(c, c.Param("checkpointId"))
+	if !ok {
+		return
+	}
+
+	var toEntries []CheckpointEntry
+	var to *Checkpoint
+	if toId := c.Query("to"); toId != "" {
+		if to, ok = cc.load(c, toId); !ok {
+			return
+		}
+		toEntries = to.Entries
+	} else {
+		if _, err := workspace.Check(from.Path); err != nil {
+			c.AbortWithError(http.StatusForbidden, err)
+			return
+		}
+
+		var err error
+		toEntries, err = cc.store.Scan(c.Request.Context(), from)
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	result := CheckpointDiff{From: from.Id, Changes: Diff(from.Entries, toEntries)}
+	if to != nil {
+		result.To = to.Id
+	}
+
+	if c.Query("patch") == "true" {
+		// the current files are read from disk
+		dir := ""
+		if to == nil {
+			dir = from.Path
+		}
+
+		before := entriesByPath(from.Entries)
+		after := entriesByPath(toEntries)
+		for i := range result.Changes {
+			change := &result.Changes[i]
+			oldContent, oldOk := cc.text(before[change.Path], "")
+			newContent, newOk := cc.text(after[change.Path], dir)
+			if oldOk && newOk {
+				patch := diff.Unified("a/"+change.Path, "b/"+change.Path, oldContent, newContent)
+				change.Diff = &patch
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// RestoreCheckpoint godoc
+//
+//	@Summary		Restore a checkpoint
+//	@Description	Bring the checkpoint directory back to the state of the checkpoint, either completely or only the given paths. Files created after the checkpoint are removed unless keepExtra is set. Files that already match are left alone.
+//	@Tags			checkpoint
+//	@Accept			json
+//	@Produce		json
+//	@Param			checkpointId	path		string						true	"Checkpoint ID"
+//	@Param			request			body		RestoreCheckpointRequest	false	"Restore request"
+//	@Success		200				{object}	RestoreCheckpointResponse
+//	@Router			/checkpoints/{checkpointId}/restore [post]
+//
+//	@id				RestoreCheckpoint
+func (cc *CheckpointController) RestoreCheckpoint(c *gin.Context) {
+	var req RestoreCheckpointRequest
+	// the body is optional, an empty one restores everything
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	cp, ok := cc.load(c, c.Param("checkpointId"))
+	if !ok {
+		return
+	}
+
+	if _, err := workspace.Check(cp.Path); err != nil {
+		c.AbortWithError(http.StatusForbidden, err)
+		return
+	}
+
+	result, err := cc.store.Restore(cp, req.Files, req.KeepExtra)
+	if err != nil {
+		if errors.Is(err, errUnsafePath) {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (cc *CheckpointController) load(c *gin.Context, id string) (*Checkpoint, bool) {
+	cp, err := cc.store.Get(id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			c.AbortWithError(http.StatusNotFound, err)
+			return nil, false
+		}
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return nil, false
+	}
+	return cp, true
+}
+
+// text returns the content of a file entry when it is text and not too
+// large to diff. A missing entry has empty content. The content is read
+// from below dir when set, and from the store otherwise.
+func (cc *CheckpointController) text(entry *CheckpointEntry, dir string) (string, bool) {
+	if entry == nil {
+		return "", true
+	}
+	if entry.LinkTarget != nil || entry.Size > maxDiffSize {
+		return "", false
+	}
+
+	var r io.ReadCloser
+	var err error
+	if dir != "" {
+		r, err = os.Open(filepath.Join(dir, filepath.FromSlash(entry.Path)))
+	} else {
+		r, err = cc.store.Open(*entry)
+	}
+	if err != nil {
+		return "", false
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(io.LimitReader(r, maxDiffSize+1))
+	if err != nil || len(data) > maxDiffSize || bytes.IndexByte(data, 0) >= 0 {
+		return "", false
+	}
+	return string(data), true
+}
+
+func entriesByPath(entries []CheckpointEntry) map[string]*CheckpointEntry {
+	byPath := make(map[string]*CheckpointEntry, len(entries))
+	for i := range entries {
+		byPath[entries[i].Path] = &entries[i]
+	}
+	return byPath
+}
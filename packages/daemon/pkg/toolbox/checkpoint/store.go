@@ -0,0 +1,686 @@
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/fsutil"
+)
+
+var (
+	ErrNotFound   = errors.New("checkpoint not found")
+	errUnsafePath = errors.New("path escapes the checkpoint directory")
+)
+
+// Store keeps checkpoints below dir. File contents are stored once per hash
+// in dir/objects, so checkpoints share the files they have in common. Each
+// checkpoint is a JSON file listing its entries.
+type Store struct {
+	dir string
+	// skip is never snapshotted or restored, so that the store does not end
+	// up in its own checkpoints when they are taken of a parent directory
+	skip string
+	mu   sync.Mutex
+}
+
+func NewStore(dir, skip string) *Store {
+	return &Store{dir: dir, skip: filepath.Clean(skip)}
+}
+
+// Create snapshots the directory root.
+func (s *Store) Create(ctx context.Context, root, message string, exclude []string) (*Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	root, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+
+	// unchanged files are not hashed again
+	previous := map[string]CheckpointEntry{}
+	if latest, err := s.latest(root); err == nil && latest != nil {
+		for _, entry := range latest.Entries {
+			previous[entry.Path] = entry
+		}
+	}
+
+	cp := &Checkpoint{
+		Id:        uuid.NewString(),
+		Message:   message,
+		Path:      root,
+		CreatedAt: time.Now().UTC(),
+		Exclude:   exclude,
+		Entries:   []CheckpointEntry{},
+	}
+
+	err = s.walk(ctx, root, exclude, func(path string, entry *CheckpointEntry) error {
+		if !entry.IsDir && entry.LinkTarget == nil {
+			if prev, ok := previous[entry.Path]; ok && prev.Hash != "" && prev.Size == entry.Size && prev.ModTime.Equal(entry.ModTime) && s.hasObject(prev.Hash) {
+				entry.Hash = prev.Hash
+			} else {
+				hash, err := s.storeObject(path)
+				if err != nil {
+					return err
+				}
+				entry.Hash = hash
+			}
+			cp.Size += entry.Size
+		}
+		if !entry.IsDir {
+			cp.Files++
+		}
+		cp.Entries = append(cp.Entries, *entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.save(cp); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+// Scan lists the current entries of root like Create does, without storing
+// anything. Hashes of files that did not change since cp are reused.
+func (s *Store) Scan(ctx context.Context, cp *Checkpoint) ([]CheckpointEntry, error) {
+	previous := map[string]CheckpointEntry{}
+	for _, entry := range cp.Entries {
+		previous[entry.Path] = entry
+	}
+
+	entries := []CheckpointEntry{}
+	err := s.walk(ctx, cp.Path, cp.Exclude, func(path string, entry *CheckpointEntry) error {
+		if !entry.IsDir && entry.LinkTarget == nil {
+			if prev, ok := previous[entry.Path]; ok && prev.Hash != "" && prev.Size == entry.Size && prev.ModTime.Equal(entry.ModTime) {
+				entry.Hash = prev.Hash
+			} else {
+				hash, err := fsutil.HashFile(path)
+				if err != nil {
+					return err
+				}
+				entry.Hash = hash
+			}
+		}
+		entries = append(entries, *entry)
+		return nil
+	})
+	if os.IsNotExist(err) {
+		// the directory is gone, everything was deleted
+		return []CheckpointEntry{}, nil
+	}
+	return entries, err
+}
+
+// List returns all checkpoints without their entries, oldest first.
+func (s *Store) List() ([]Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all, err := s.loadAll()
+	if err != nil {
+		return nil, err
+	}
+	for i := range all {
+		all[i].Entries = nil
+	}
+	return all, nil
+}
+
+// Get returns the checkpoint with the given id.
+func (s *Store) Get(id string) (*Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load(id)
+}
+
+// Delete removes a checkpoint and the contents no other checkpoint uses.
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.load(id); err != nil {
+		return err
+	}
+	if err := os.Remove(s.metaPath(id)); err != nil {
+		return err
+	}
+	return s.collectGarbage()
+}
+
+// Open returns the stored content of a file entry.
+func (s *Store) Open(entry CheckpointEntry) (*os.File, error) {
+	return os.Open(s.objectPath(entry.Hash))
+}
+
+// Restore makes the checkpoint directory match cp again. With files set,
+// only those paths are restored. Files that are not in the checkpoint are
+// removed unless keepExtra is set.
+func (s *Store) Restore(cp *Checkpoint, files []string, keepExtra bool) (RestoreCheckpointResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result RestoreCheckpointResponse
+
+	scopes := make([]string, 0, len(files))
+	for _, file := range files {
+		scope := path.Clean(filepath.ToSlash(file))
+		if scope == "." || path.IsAbs(scope) || scope == ".." || strings.HasPrefix(scope, "../") {
+			return result, fmt.Errorf("%w: %s", errUnsafePath, file)
+		}
+		scopes = append(scopes, scope)
+	}
+
+	if err := os.MkdirAll(cp.Path, 0755); err != nil {
+		return result, err
+	}
+	root, err := filepath.EvalSymlinks(cp.Path)
+	if err != nil {
+		return result, err
+	}
+
+	// directory modes are applied last, a read-only directory could not
+	// be filled otherwise
+	dirModes := map[string]os.FileMode{}
+	var dirs []string
+	defer func() {
+		for i := len(dirs) - 1; i >= 0; i-- {
+			_ = os.Chmod(dirs[i], dirModes[dirs[i]])
+		}
+	}()
+
+	known := map[string]bool{}
+	for _, entry := range cp.Entries {
+		known[entry.Path] = true
+		if !inScope(scopes, entry.Path) {
+			continue
+		}
+
+		target, err := resolveTarget(root, entry.Path)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", entry.Path, err)
+		}
+		restored, err := s.restoreEntry(target, entry)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", entry.Path, err)
+		}
+		if entry.IsDir {
+			dirs = append(dirs, target)
+			dirModes[target] = parseMode(entry.Mode)
+		}
+		if restored {
+			result.Restored++
+		} else {
+			result.Unchanged++
+		}
+	}
+
+	if keepExtra {
+		return result, nil
+	}
+
+	if len(scopes) == 0 {
+		scopes = []string{""}
+	}
+	for _, scope := range scopes {
+		deleted, err := s.deleteExtra(root, scope, cp.Exclude, known)
+		result.Deleted += deleted
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// restoreEntry writes entry to target, reporting whether anything had to
+// be changed.
+func (s *Store) restoreEntry(target string, entry CheckpointEntry) (bool, error) {
+	perm := parseMode(entry.Mode)
+	info, statErr := os.Lstat(target)
+
+	// the mode of directories is set by the caller
+	if entry.IsDir {
+		if statErr == nil && info.IsDir() {
+			return info.Mode().Perm() != perm, nil
+		}
+		if statErr == nil {
+			if err := os.Remove(target); err != nil {
+				return false, err
+			}
+		}
+		return true, os.MkdirAll(target, 0755)
+	}
+
+	if entry.LinkTarget != nil {
+		if statErr == nil && info.Mode()&os.ModeSymlink != 0 {
+			if current, err := os.Readlink(target); err == nil && current == *entry.LinkTarget {
+				return false, nil
+			}
+		}
+	} else if statErr == nil && info.Mode().IsRegular() && info.Size() == entry.Size {
+		if hash, err := fsutil.HashFile(target); err == nil && hash == entry.Hash {
+			if info.Mode().Perm() == perm {
+				return false, nil
+			}
+			return true, os.Chmod(target, perm)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return false, err
+	}
+
+	var tmp string
+	var err error
+	if entry.LinkTarget != nil {
+		tmp, err = fsutil.StageSymlink(target, *entry.LinkTarget)
+	} else {
+		tmp, err = fsutil.StageCopy(target, s.objectPath(entry.Hash), perm)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if statErr == nil && info.IsDir() {
+		if err := os.RemoveAll(target); err != nil {
+			os.Remove(tmp)
+			return false, err
+		}
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return false, err
+	}
+
+	if entry.LinkTarget == nil {
+		// keeps the next checkpoint from hashing the file again
+		_ = os.Chtimes(target, entry.ModTime, entry.ModTime)
+	}
+	return true, nil
+}
+
+// deleteExtra removes everything below root/scope that is not known to the
+// checkpoint, leaving excluded paths alone.
+func (s *Store) deleteExtra(root, scope string, exclude []string, known map[string]bool) (int, error) {
+	start := root
+	if scope != "" {
+		target, err := resolveTarget(root, scope)
+		if err != nil {
+			return 0, err
+		}
+		start = target
+	}
+
+	deleted := 0
+	err := filepath.WalkDir(start, func(path string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if path == root {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if known[rel] {
+			return nil
+		}
+		if path == s.skip || fsutil.MatchAnyGlob(exclude, root, path) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+		deleted++
+		if d.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+// walk calls fn for every directory, file and symlink below root.
+func (s *Store) walk(ctx context.Context, root string, exclude []string, fn func(path string, entry *CheckpointEntry) error) error {
+	return filepath.WalkDir(root, func(path string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if path == s.skip || fsutil.MatchAnyGlob(exclude, root, path) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		entry := &CheckpointEntry{
+			Path:    rel,
+			Mode:    fmt.Sprintf("%04o", info.Mode().Perm()),
+			ModTime: info.ModTime().UTC(),
+		}
+		switch {
+		case d.IsDir():
+			entry.IsDir = true
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			entry.LinkTarget = &target
+		case info.Mode().IsRegular():
+			entry.Size = info.Size()
+		default:
+			// devices, sockets and pipes are not snapshotted
+			return nil
+		}
+		return fn(path, entry)
+	})
+}
+
+// storeObject copies the file at path into the object store and returns
+// its hash.
+func (s *Store) storeObject(path string) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	objects := filepath.Join(s.dir, "objects")
+	if err := os.MkdirAll(objects, 0700); err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(objects, ".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	hash, err := fsutil.Hash(io.TeeReader(src, tmp))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if s.hasObject(hash) {
+		return hash, nil
+	}
+
+	dst := s.objectPath(hash)
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return "", err
+	}
+	return hash, os.Rename(tmp.Name(), dst)
+}
+
+func (s *Store) hasObject(hash string) bool {
+	_, err := os.Stat(s.objectPath(hash))
+	return err == nil
+}
+
+func (s *Store) objectPath(hash string) string {
+	return filepath.Join(s.dir, "objects", hash[:2], hash[2:])
+}
+
+func (s *Store) metaPath(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+func (s *Store) save(cp *Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+	tmp := s.metaPath(cp.Id) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.metaPath(cp.Id))
+}
+
+func (s *Store) load(id string) (*Checkpoint, error) {
+	// the id becomes part of a path
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrNotFound
+	}
+
+	data, err := os.ReadFile(s.metaPath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("checkpoint %s: %w", id, err)
+	}
+	return &cp, nil
+}
+
+// loadAll returns all checkpoints, oldest first.
+func (s *Store) loadAll() ([]Checkpoint, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	all := []Checkpoint{}
+	for _, file := range files {
+		id, ok := strings.CutSuffix(file.Name(), ".json")
+		if !ok {
+			continue
+		}
+		cp, err := s.load(id)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, *cp)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].CreatedAt.Before(all[j].CreatedAt)
+	})
+	return all, nil
+}
+
+// latest returns the most recent checkpoint of root, or nil.
+func (s *Store) latest(root string) (*Checkpoint, error) {
+	all, err := s.loadAll()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(all) - 1; i >= 0; i-- {
+		if all[i].Path == root {
+			return &all[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// collectGarbage removes the objects no checkpoint refers to.
+func (s *Store) collectGarbage() error {
+	all, err := s.loadAll()
+	if err != nil {
+		return err
+	}
+
+	used := map[string]bool{}
+	for _, cp := range all {
+		for _, entry := range cp.Entries {
+			if entry.Hash != "" {
+				used[entry.Hash] = true
+			}
+		}
+	}
+
+	objects := filepath.Join(s.dir, "objects")
+	err = filepath.WalkDir(objects, func(path string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		hash := filepath.Base(filepath.Dir(path)) + d.Name()
+		if !used[hash] {
+			return os.Remove(path)
+		}
+		return nil
+	})
+	return err
+}
+
+// Diff lists the files and symlinks that differ between two sets of
+// entries, sorted by path.
+func Diff(from, to []CheckpointEntry) []CheckpointChange {
+	before := map[string]CheckpointEntry{}
+	for _, entry := range from {
+		if !entry.IsDir {
+			before[entry.Path] = entry
+		}
+	}
+
+	changes := []CheckpointChange{}
+	for _, entry := range to {
+		if entry.IsDir {
+			continue
+		}
+		prev, ok := before[entry.Path]
+		delete(before, entry.Path)
+		switch {
+		case !ok:
+			changes = append(changes, CheckpointChange{Path: entry.Path, Status: ChangeAdded})
+		case !sameContent(prev, entry):
+			changes = append(changes, CheckpointChange{Path: entry.Path, Status: ChangeModified})
+		}
+	}
+	for p := range before {
+		changes = append(changes, CheckpointChange{Path: p, Status: ChangeDeleted})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+func sameContent(a, b CheckpointEntry) bool {
+	if a.Mode != b.Mode || a.Hash != b.Hash || (a.LinkTarget == nil) != (b.LinkTarget == nil) {
+		return false
+	}
+	return a.LinkTarget == nil || *a.LinkTarget == *b.LinkTarget
+}
+
+// resolveTarget maps a relative entry path to a location below root, which
+// must be free of symlinks. The closest existing ancestor of the target has
+// to resolve below root, so that the write cannot be redirected.
+func resolveTarget(root, rel string) (string, error) {
+	name := filepath.FromSlash(rel)
+	if name == "" || filepath.IsAbs(name) {
+		return "", errUnsafePath
+	}
+	target := filepath.Join(root, name)
+	if target == root || !fsutil.Within(root, target) {
+		return "", errUnsafePath
+	}
+
+	dir := filepath.Dir(target)
+	for {
+		if _, err := os.Lstat(dir); err == nil {
+			break
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+		dir = filepath.Dir(dir)
+	}
+
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", err
+	}
+	if !fsutil.Within(root, resolved) {
+		return "", errUnsafePath
+	}
+	return target, nil
+}
+
+// inScope reports whether rel is one of scopes or lies below one. An empty
+// list covers everything.
+func inScope(scopes []string, rel string) bool {
+	if len(scopes) == 0 {
+		return true
+	}
+	for _, scope := range scopes {
+		if rel == scope || strings.HasPrefix(rel, scope+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMode parses an octal permission mode, falling back to 0644 for
+// files written by hand.
+func parseMode(mode string) os.FileMode {
+	m, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return 0644
+	}
+	return os.FileMode(m).Perm()
+}
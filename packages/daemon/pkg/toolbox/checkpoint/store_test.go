@@ -0,0 +1,202 @@
+package checkpoint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func countObjects(t *testing.T, dir string) int {
+	t.Helper()
+
+	n := 0
+	_ = filepath.WalkDir(filepath.Join(dir, "objects"), func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			n++
+		}
+		return nil
+	})
+	return n
+}
+
+func TestCheckpointRestore(t *testing.T) {
+	configDir := t.TempDir()
+	storeDir := filepath.Join(configDir, "checkpoints")
+	store := NewStore(storeDir, configDir)
+
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(root, "a.txt"), "one\n")
+	writeFile(t, filepath.Join(root, "copy.txt"), "one\n")
+	writeFile(t, filepath.Join(root, "src", "main.go"), "package main\n")
+	if err := os.Symlink("a.txt", filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := store.Create(context.Background(), root, "first", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Files != 4 {
+		t.Fatalf("expected 4 files, got %d", first.Files)
+	}
+	// identical files share one object
+	if n := countObjects(t, storeDir); n != 2 {
+		t.Fatalf("expected 2 objects, got %d", n)
+	}
+
+	writeFile(t, filepath.Join(root, "a.txt"), "two\n")
+	writeFile(t, filepath.Join(root, "new.txt"), "new\n")
+	if err := os.RemoveAll(filepath.Join(root, "src")); err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := store.Create(context.Background(), root, "second", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := Diff(first.Entries, second.Entries)
+	expected := []CheckpointChange{
+		{Path: "a.txt", Status: ChangeModified},
+		{Path: "new.txt", Status: ChangeAdded},
+		{Path: "src/main.go", Status: ChangeDeleted},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Errorf("change %d: expected %+v, got %+v", i, expected[i], changes[i])
+		}
+	}
+
+	// restoring a single path leaves everything else alone
+	result, err := store.Restore(first, []string{"src"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Restored != 2 {
+		t.Fatalf("expected src and src/main.go to be restored, got %+v", result)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "a.txt")); string(data) != "two\n" {
+		t.Fatalf("a.txt should not be restored, got %q", data)
+	}
+
+	result, err = store.Restore(first, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Restored != 1 || result.Deleted != 1 {
+		t.Fatalf("unexpected restore result: %+v", result)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "link")); string(data) != "one\n" {
+		t.Fatalf("unexpected content through link: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(root, "new.txt")); !os.IsNotExist(err) {
+		t.Fatal("new.txt should have been removed")
+	}
+
+	current, err := store.Scan(context.Background(), first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes := Diff(first.Entries, current); len(changes) != 0 {
+		t.Fatalf("expected no changes after restore, got %+v", changes)
+	}
+
+	// deleting the first checkpoint keeps the objects the second one needs
+	if err := store.Delete(first.Id); err != nil {
+		t.Fatal(err)
+	}
+	if n := countObjects(t, storeDir); n != 3 {
+		t.Fatalf("expected 3 objects after delete, got %d", n)
+	}
+	if _, err := store.Get(first.Id); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestCheckpointSkipsStore(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	configDir := filepath.Join(root, ".deck")
+	store := NewStore(filepath.Join(configDir, "checkpoints"), configDir)
+	writeFile(t, filepath.Join(root, "a.txt"), "a")
+
+	for range 2 {
+		cp, err := store.Create(context.Background(), root, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cp.Files != 1 {
+			t.Fatalf("expected only a.txt, got %+v", cp.Entries)
+		}
+	}
+}
+
+func TestCheckpointExcludeGlobs(t *testing.T) {
+	configDir := t.TempDir()
+	store := NewStore(filepath.Join(configDir, "checkpoints"), configDir)
+
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(root, "main.go"), "package main\n")
+	writeFile(t, filepath.Join(root, "build", "out.o"), "o")
+	writeFile(t, filepath.Join(root, "src", "gen", "api.pb.go"), "package gen\n")
+	writeFile(t, filepath.Join(root, "src", "api.go"), "package src\n")
+
+	cp, err := store.Create(context.Background(), root, "", []string{"build", "src/**/*.pb.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, entry := range cp.Entries {
+		if !entry.IsDir {
+			paths = append(paths, entry.Path)
+		}
+	}
+	if len(paths) != 2 || paths[0] != "main.go" || paths[1] != "src/api.go" {
+		t.Fatalf("expected main.go and src/api.go, got %v", paths)
+	}
+}
+
+func TestRestoreRejectsUnsafePaths(t *testing.T) {
+	configDir := t.TempDir()
+	store := NewStore(filepath.Join(configDir, "checkpoints"), configDir)
+	root := t.TempDir()
+
+	cp := &Checkpoint{Path: root}
+	for _, files := range [][]string{{"../x"}, {"/etc"}, {"."}} {
+		if _, err := store.Restore(cp, files, false); err == nil {
+			t.Errorf("expected %v to be rejected", files)
+		}
+	}
+
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	cp.Entries = []CheckpointEntry{{Path: "escape/x", Mode: "0644", Hash: "00"}}
+	if _, err := store.Restore(cp, nil, true); err == nil {
+		t.Error("expected a write through a symlink to be rejected")
+	}
+}
@@ -0,0 +1,78 @@
+package checkpoint
+
+import "time"
+
+// Change statuses
+const (
+	ChangeAdded    = "added"
+	ChangeDeleted  = "deleted"
+	ChangeModified = "modified"
+)
+
+type CreateCheckpointRequest struct {
+	// Directory to snapshot (defaults to the work directory)
+	Path *string `json:"path,omitempty" validate:"optional"`
+	// Description of the checkpoint
+	Message string `json:"message,omitempty" validate:"optional"`
+	// Globs of files and directories to leave out, matched against the relative path and the base name; ** matches any number of directories
+	Exclude []string `json:"exclude,omitempty" validate:"optional"`
+} //	@name	CreateCheckpointRequest
+
+type Checkpoint struct {
+	Id        string    `json:"id" validate:"required"`
+	Message   string    `json:"message,omitempty" validate:"optional"`
+	Path      string    `json:"path" validate:"required"`
+	CreatedAt time.Time `json:"createdAt" validate:"required"`
+	Exclude   []string  `json:"exclude,omitempty" validate:"optional"`
+	// Number of files and symlinks in the checkpoint
+	Files int `json:"files" validate:"required"`
+	// Total size of the files in bytes
+	Size int64 `json:"size" validate:"required"`
+	// Snapshotted entries, only returned for a single checkpoint
+	Entries []CheckpointEntry `json:"entries,omitempty" validate:"optional"`
+} //	@name	Checkpoint
+
+type CheckpointEntry struct {
+	// Slash separated path relative to the checkpoint path
+	Path    string    `json:"path" validate:"required"`
+	IsDir   bool      `json:"isDir,omitempty" validate:"optional"`
+	Mode    string    `json:"mode" validate:"required"`
+	Size    int64     `json:"size" validate:"required"`
+	ModTime time.Time `json:"modTime" validate:"required"`
+	// Hex encoded SHA-256 of the content, only set for files
+	Hash string `json:"hash,omitempty" validate:"optional"`
+	// Target of a symlink
+	LinkTarget *string `json:"linkTarget,omitempty" validate:"optional"`
+} //	@name	CheckpointEntry
+
+type CheckpointDiff struct {
+	From string `json:"from" validate:"required"`
+	// Id of the other checkpoint, empty when compared with the current files
+	To      string             `json:"to,omitempty" validate:"optional"`
+	Changes []CheckpointChange `json:"changes" validate:"required"`
+} //	@name	CheckpointDiff
+
+type CheckpointChange struct {
+	Path string `json:"path" validate:"required"`
+	// added, deleted or modified
+	Status string `json:"status" validate:"required"`
+	// Unified diff of text files, only set when requested
+	Diff *string `json:"diff,omitempty" validate:"optional"`
+} //	@name	CheckpointChange
+
+type RestoreCheckpointRequest struct {
+	// Paths to restore, relative to the checkpoint path. Directories include
+	// everything below them. Restores the whole checkpoint when empty.
+	Files []string `json:"files,omitempty" validate:"optional"`
+	// Keep files that were created after the checkpoint instead of removing them
+	KeepExtra bool `json:"keepExtra,omitempty" validate:"optional"`
+} //	@name	RestoreCheckpointRequest
+
+type RestoreCheckpointResponse struct {
+	// Number of files, symlinks and directories that were written
+	Restored int `json:"restored" validate:"required"`
+	// Number of entries that already matched the checkpoint
+	Unchanged int `json:"unchanged" validate:"required"`
+	// Number of files and directories that were removed
+	Deleted int `json:"deleted" validate:"required"`
+} //	@name	RestoreCheckpointResponse
@@ -15,6 +15,198 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/checkpoints": {
+            "get": {
+                "description": "List all checkpoints, oldest first. Entries are not included.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "checkpoint"
+                ],
+                "summary": "List checkpoints",
+                "operationId": "ListCheckpoints",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/Checkpoint"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Snapshot a directory, by default the work directory. File contents are stored once, unchanged files are shared with earlier checkpoints.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "checkpoint"
+                ],
+                "summary": "Create a checkpoint",
+                "operationId": "CreateCheckpoint",
+                "parameters": [
+                    {
+                        "description": "Checkpoint request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/CreateCheckpointRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/Checkpoint"
+                        }
+                    }
+                }
+            }
+        },
+        "/checkpoints/{checkpointId}": {
+            "get": {
+                "description": "Get a checkpoint with all of its entries",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "checkpoint"
+                ],
+                "summary": "Get a checkpoint",
+                "operationId": "GetCheckpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Checkpoint ID",
+                        "name": "checkpointId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Checkpoint"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a checkpoint and the stored contents no other checkpoint uses",
+                "tags": [
+                    "checkpoint"
+                ],
+                "summary": "Delete a checkpoint",
+                "operationId": "DeleteCheckpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Checkpoint ID",
+                        "name": "checkpointId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/checkpoints/{checkpointId}/diff": {
+            "get": {
+                "description": "List the files that differ between a checkpoint and another checkpoint, or the current files of the checkpoint directory when no other checkpoint is given. Changes are described from the checkpoint towards the other side.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "checkpoint"
+                ],
+                "summary": "Diff a checkpoint",
+                "operationId": "DiffCheckpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Checkpoint ID",
+                        "name": "checkpointId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Checkpoint ID to compare with (default: current files)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include unified diffs of changed text files",
+                        "name": "patch",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/CheckpointDiff"
+                        }
+                    }
+                }
+            }
+        },
+        "/checkpoints/{checkpointId}/restore": {
+            "post": {
+                "description": "Bring the checkpoint directory back to the state of the checkpoint, either completely or only the given paths. Files created after the checkpoint are removed unless keepExtra is set. Files that already match are left alone.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "checkpoint"
+                ],
+                "summary": "Restore a checkpoint",
+                "operationId": "RestoreCheckpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Checkpoint ID",
+                        "name": "checkpointId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Restore request",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/RestoreCheckpointRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/RestoreCheckpointResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/computeruse/browser/close": {
             "post": {
                 "description": "Force close the browser process and cleanup",
@@ -795,7 +987,7 @@ const docTemplate = `{
         },
         "/files": {
             "get": {
-                "description": "List files and directories in the specified path",
+                "description": "List files and directories in the specified path, sorted and optionally paginated. The total number of entries is returned in the X-Total-Count header.",
                 "produces": [
                     "application/json"
                 ],
@@ -810,6 +1002,30 @@ const docTemplate = `{
                         "description": "Directory path to list (defaults to working directory)",
                         "name": "path",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort key: name (default), size or modTime",
+                        "name": "sortBy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort order: asc (default) or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of entries to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of entries to return (default: all)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -820,12 +1036,18 @@ const docTemplate = `{
                             "items": {
                                 "$ref": "#/definitions/FileInfo"
                             }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Total number of entries in the directory"
+                            }
                         }
                     }
                 }
             },
             "delete": {
-                "description": "Delete a file or directory at the specified path",
+                "description": "Delete a file or directory at the specified path. With trash, the path is moved to the trash instead, from where it can be restored until it expires.",
                 "tags": [
                     "file-system"
                 ],
@@ -844,6 +1066,12 @@ const docTemplate = `{
                         "description": "Enable recursive deletion for directories",
                         "name": "recursive",
                         "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Move to the trash instead of deleting permanently",
+                        "name": "trash",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -853,6 +1081,68 @@ const docTemplate = `{
                 }
             }
         },
+        "/files/archive": {
+            "get": {
+                "description": "Stream a tar.gz or zip archive of a file or directory. Entry names are relative to the archived directory. Symlinks are stored as links and are not followed. Files ignored by .gitignore and the .git directory are skipped unless noIgnore is set.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "file-system"
+                ],
+                "summary": "Download a directory as an archive",
+                "operationId": "ArchiveFiles",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File or directory to archive",
+                        "name": "path",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Archive format, tar.gz (default) or zip",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only archive files matching these globs",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Skip files and directories matching these globs",
+                        "name": "exclude",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Do not respect .gitignore files",
+                        "name": "noIgnore",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
+                }
+            }
+        },
         "/files/bulk-download": {
             "post": {
                 "description": "Download multiple files by providing their paths",
@@ -906,179 +1196,278 @@ const docTemplate = `{
                 }
             }
         },
-        "/files/download": {
-            "get": {
-                "description": "Download a file by providing its path",
-                "produces": [
-                    "application/octet-stream"
-                ],
+        "/files/copy": {
+            "post": {
+                "description": "Copy a file, or a directory with everything below it, to destination. Symlinks below a copied directory are copied as links. Files and directories keep the permission bits of the source, like cp does. With overwrite, existing files and symlinks are replaced and directories are merged.",
                 "tags": [
                     "file-system"
                 ],
-                "summary": "Download a file",
-                "operationId": "DownloadFile",
+                "summary": "Copy a file or directory",
+                "operationId": "CopyFile",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "File path to download",
-                        "name": "path",
+                        "description": "Source file or directory path",
+                        "name": "source",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Destination file or directory path",
+                        "name": "destination",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Enable copying directories",
+                        "name": "recursive",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Replace existing files at the destination",
+                        "name": "overwrite",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also keep the modification times and the setuid, setgid and sticky bits of the source",
+                        "name": "preserveMode",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "file"
-                        }
+                        "description": "OK"
                     }
                 }
             }
         },
-        "/files/find": {
+        "/files/download": {
             "get": {
-                "description": "Search for text pattern within files in a directory",
+                "description": "Download a file by providing its path. Supports Range requests; If-Range and If-None-Match are matched against the returned ETag.",
                 "produces": [
-                    "application/json"
+                    "application/octet-stream"
                 ],
                 "tags": [
                     "file-system"
                 ],
-                "summary": "Find text in files",
-                "operationId": "FindInFiles",
+                "summary": "Download a file",
+                "operationId": "DownloadFile",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Directory path to search in",
+                        "description": "File path to download",
                         "name": "path",
                         "in": "query",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Text pattern to search for",
-                        "name": "pattern",
-                        "in": "query",
-                        "required": true
+                        "description": "Byte range to download, e.g. bytes=0-1023",
+                        "name": "Range",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only honor Range if the file still has this ETag",
+                        "name": "If-Range",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/Match"
-                            }
+                            "type": "file"
+                        }
+                    },
+                    "206": {
+                        "description": "Partial Content",
+                        "schema": {
+                            "type": "file"
                         }
                     }
                 }
             }
         },
-        "/files/folder": {
+        "/files/extract": {
             "post": {
-                "description": "Create a folder with the specified path and optional permissions",
+                "description": "Unpack an uploaded tar, tar.gz or zip archive into the target directory. The format is detected from the content. Entries that would be written outside the target directory, including through symlinks, are rejected. Existing files are overwritten.",
                 "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "file-system"
                 ],
-                "summary": "Create a folder",
-                "operationId": "CreateFolder",
+                "summary": "Extract an archive",
+                "operationId": "ExtractArchive",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Folder path to create",
+                        "description": "Directory to extract into, created if missing",
                         "name": "path",
                         "in": "query",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Octal permission mode (default: 0755)",
-                        "name": "mode",
-                        "in": "query",
+                        "type": "file",
+                        "description": "Archive to extract",
+                        "name": "file",
+                        "in": "formData",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ExtractResult"
+                        }
                     }
                 }
             }
         },
-        "/files/info": {
+        "/files/find": {
             "get": {
-                "description": "Get detailed information about a file or directory",
+                "description": "Search for a text or regex pattern within files in a directory. Files ignored by .gitignore and the .git directory are skipped unless noIgnore is set. Matches are returned in the order the directory is walked, so maxResults always keeps the same matches. Lines longer than 1MiB are skipped.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "file-system"
                 ],
-                "summary": "Get file information",
-                "operationId": "GetFileInfo",
+                "summary": "Find text in files",
+                "operationId": "FindInFiles",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "File or directory path",
+                        "description": "Directory path to search in",
                         "name": "path",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Text pattern to search for",
+                        "name": "pattern",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Treat pattern as a regular expression",
+                        "name": "regex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Match case-insensitively",
+                        "name": "ignoreCase",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only search files matching these globs",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Skip files and directories matching these globs",
+                        "name": "exclude",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Do not respect .gitignore files",
+                        "name": "noIgnore",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of context lines to return before and after each match",
+                        "name": "context",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of matches to return (0 means no limit)",
+                        "name": "maxResults",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/FileInfo"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/Match"
+                            }
                         }
                     }
                 }
             }
         },
-        "/files/move": {
+        "/files/folder": {
             "post": {
-                "description": "Move or rename a file or directory from source to destination",
+                "description": "Create a folder with the specified path and optional permissions",
+                "consumes": [
+                    "application/json"
+                ],
                 "tags": [
                     "file-system"
                 ],
-                "summary": "Move or rename file/directory",
-                "operationId": "MoveFile",
+                "summary": "Create a folder",
+                "operationId": "CreateFolder",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Source file or directory path",
-                        "name": "source",
+                        "description": "Folder path to create",
+                        "name": "path",
                         "in": "query",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Destination file or directory path",
-                        "name": "destination",
+                        "description": "Octal permission mode (default: 0755)",
+                        "name": "mode",
                         "in": "query",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK"
+                    "201": {
+                        "description": "Created"
                     }
                 }
             }
         },
-        "/files/permissions": {
-            "post": {
-                "description": "Set file permissions, ownership, and group for a file or directory",
+        "/files/info": {
+            "get": {
+                "description": "Get detailed information about a file or directory. With detail, the content of a regular file is read to report its MIME type, whether it is binary, the text encoding, line endings, line count and SHA-256.",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
                     "file-system"
                 ],
-                "summary": "Set file permissions",
-                "operationId": "SetFilePermissions",
+                "summary": "Get file information",
+                "operationId": "GetFileInfo",
                 "parameters": [
                     {
                         "type": "string",
@@ -1088,34 +1477,65 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Owner (username or UID)",
-                        "name": "owner",
+                        "type": "boolean",
+                        "description": "Include content details of a regular file",
+                        "name": "detail",
                         "in": "query"
-                    },
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/FileInfo"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/lines": {
+            "get": {
+                "description": "Read the lines start to end (1-based, inclusive) of a text file, without line endings. The response contains the total number of lines and the hash of the file to use as expectedHash in EditLines.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "file-system"
+                ],
+                "summary": "Read a range of lines",
+                "operationId": "GetFileLines",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Group (group name or GID)",
-                        "name": "group",
+                        "description": "File path",
+                        "name": "path",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "First line to read (default: 1)",
+                        "name": "start",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "File mode in octal format (e.g., 0755)",
-                        "name": "mode",
+                        "type": "integer",
+                        "description": "Last line to read (default: last line of the file)",
+                        "name": "end",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/FileLinesResponse"
+                        }
                     }
                 }
-            }
-        },
-        "/files/replace": {
+            },
             "post": {
-                "description": "Replace text pattern with new value in multiple files",
+                "description": "Replace, insert or delete lines of a text file. When expectedHash is set and the file no longer has that hash, nothing is written and 409 is returned. Right before the file is replaced it is checked again, and 409 is returned as well when another writer changed it during the edit. A write landing between that last check and the replacement, or through a file handle opened earlier, is not detected. New lines use the line ending of the file.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1125,16 +1545,16 @@ const docTemplate = `{
                 "tags": [
                     "file-system"
                 ],
-                "summary": "Replace text in files",
-                "operationId": "ReplaceInFiles",
+                "summary": "Edit a range of lines",
+                "operationId": "EditLines",
                 "parameters": [
                     {
-                        "description": "Replace request",
+                        "description": "Edit request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/ReplaceRequest"
+                            "$ref": "#/definitions/EditLinesRequest"
                         }
                     }
                 ],
@@ -1142,152 +1562,145 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/ReplaceResult"
-                            }
+                            "$ref": "#/definitions/EditLinesResponse"
                         }
                     }
                 }
             }
         },
-        "/files/search": {
-            "get": {
-                "description": "Search for files matching a specific pattern in a directory",
-                "produces": [
-                    "application/json"
-                ],
+        "/files/link": {
+            "post": {
+                "description": "Create a link at path pointing to target. A symlink target is stored as given and may be relative to the directory of the link; it does not have to exist. A hard link target must be an existing file.",
                 "tags": [
                     "file-system"
                 ],
-                "summary": "Search files by pattern",
-                "operationId": "SearchFiles",
+                "summary": "Create a symlink or hard link",
+                "operationId": "CreateLink",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Directory path to search in",
+                        "description": "Path of the new link",
                         "name": "path",
                         "in": "query",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "File pattern to match (e.g., *.txt, *.go)",
-                        "name": "pattern",
+                        "description": "File or directory the link points to",
+                        "name": "target",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Link type, symlink (default) or hard",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Replace an existing file or link at path",
+                        "name": "overwrite",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/SearchFilesResponse"
-                        }
+                    "201": {
+                        "description": "Created"
                     }
                 }
             }
         },
-        "/files/upload": {
-            "post": {
-                "description": "Upload a file to the specified path",
-                "consumes": [
-                    "multipart/form-data"
+        "/files/manifest": {
+            "get": {
+                "description": "List the files and symlinks below a directory with their size, mode and SHA-256 hash. Paths are slash separated and relative to the directory. Directories are implied by the paths of their entries. Files ignored by .gitignore and the .git directory are skipped unless noIgnore is set.",
+                "produces": [
+                    "application/json"
                 ],
                 "tags": [
                     "file-system"
                 ],
-                "summary": "Upload a file",
-                "operationId": "UploadFile",
+                "summary": "Get the manifest of a directory",
+                "operationId": "GetManifest",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Destination path for the uploaded file",
+                        "description": "Directory path",
                         "name": "path",
                         "in": "query",
                         "required": true
                     },
                     {
-                        "type": "file",
-                        "description": "File to upload",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only list files matching these globs",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Skip files and directories matching these globs",
+                        "name": "exclude",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Do not respect .gitignore files",
+                        "name": "noIgnore",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/H"
+                            "$ref": "#/definitions/Manifest"
                         }
                     }
                 }
             }
         },
-        "/git/add": {
+        "/files/move": {
             "post": {
-                "description": "Add files to the Git staging area",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Move or rename a file or directory from source to destination",
                 "tags": [
-                    "git"
+                    "file-system"
                 ],
-                "summary": "Add files to Git staging",
-                "operationId": "AddFiles",
+                "summary": "Move or rename file/directory",
+                "operationId": "MoveFile",
                 "parameters": [
                     {
-                        "description": "Add files request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/GitAddRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK"
-                    }
-                }
-            }
-        },
-        "/git/branches": {
-            "get": {
-                "description": "Get a list of all branches in the Git repository",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "git"
-                ],
-                "summary": "List branches",
-                "operationId": "ListBranches",
-                "parameters": [
+                        "type": "string",
+                        "description": "Source file or directory path",
+                        "name": "source",
+                        "in": "query",
+                        "required": true
+                    },
                     {
                         "type": "string",
-                        "description": "Repository path",
-                        "name": "path",
+                        "description": "Destination file or directory path",
+                        "name": "destination",
                         "in": "query",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/ListBranchResponse"
-                        }
+                        "description": "OK"
                     }
                 }
-            },
+            }
+        },
+        "/files/patch": {
             "post": {
-                "description": "Create a new branch in the Git repository",
+                "description": "Apply a unified diff touching one or more files. Hunks are matched at an offset or with fuzz when the file has drifted. Either every file is patched or none is; on conflicts 409 is returned with the failing hunks. With dryRun set, nothing is written and the resulting diff of each file is returned.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1295,81 +1708,70 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "git"
+                    "file-system"
                 ],
-                "summary": "Create a new branch",
-                "operationId": "CreateBranch",
+                "summary": "Apply a unified diff",
+                "operationId": "ApplyPatch",
                 "parameters": [
                     {
-                        "description": "Create branch request",
+                        "description": "Patch request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/GitBranchRequest"
+                            "$ref": "#/definitions/PatchRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created"
-                    }
-                }
-            },
-            "delete": {
-                "description": "Delete a branch from the Git repository",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "git"
-                ],
-                "summary": "Delete a branch",
-                "operationId": "DeleteBranch",
-                "parameters": [
-                    {
-                        "description": "Delete branch request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/GitDeleteBranchRequest"
+                            "$ref": "#/definitions/PatchResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/PatchResponse"
                         }
-                    }
-                ],
-                "responses": {
-                    "204": {
-                        "description": "No Content"
                     }
                 }
             }
         },
-        "/git/checkout": {
+        "/files/permissions": {
             "post": {
-                "description": "Switch to a different branch or commit in the Git repository",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Set file permissions, ownership, and group for a file or directory",
                 "tags": [
-                    "git"
+                    "file-system"
                 ],
-                "summary": "Checkout branch or commit",
-                "operationId": "CheckoutBranch",
+                "summary": "Set file permissions",
+                "operationId": "SetFilePermissions",
                 "parameters": [
                     {
-                        "description": "Checkout request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/GitCheckoutRequest"
-                        }
+                        "type": "string",
+                        "description": "File or directory path",
+                        "name": "path",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Owner (username or UID)",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Group (group name or GID)",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "File mode in octal format (e.g., 0755)",
+                        "name": "mode",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -1379,9 +1781,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/git/clone": {
+        "/files/replace": {
             "post": {
-                "description": "Clone a Git repository to the specified path",
+                "description": "Replace text or regex pattern with new value in multiple files. With dryRun set, no file is written and a unified diff is returned for each file.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1389,131 +1791,187 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "git"
+                    "file-system"
                 ],
-                "summary": "Clone a Git repository",
-                "operationId": "CloneRepository",
+                "summary": "Replace text in files",
+                "operationId": "ReplaceInFiles",
                 "parameters": [
                     {
-                        "description": "Clone repository request",
+                        "description": "Replace request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/GitCloneRequest"
+                            "$ref": "#/definitions/ReplaceRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/ReplaceResult"
+                            }
+                        }
                     }
                 }
             }
         },
-        "/git/commit": {
-            "post": {
-                "description": "Commit staged changes to the Git repository",
-                "consumes": [
-                    "application/json"
-                ],
+        "/files/search": {
+            "get": {
+                "description": "Search for files matching a specific pattern in a directory",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "git"
+                    "file-system"
                 ],
-                "summary": "Commit changes",
-                "operationId": "CommitChanges",
+                "summary": "Search files by pattern",
+                "operationId": "SearchFiles",
                 "parameters": [
                     {
-                        "description": "Commit request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/GitCommitRequest"
-                        }
+                        "type": "string",
+                        "description": "Directory path to search in",
+                        "name": "path",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "File pattern to match (e.g., *.txt, *.go)",
+                        "name": "pattern",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of results",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/GitCommitResponse"
+                            "$ref": "#/definitions/SearchFilesResponse"
                         }
                     }
                 }
             }
         },
-        "/git/history": {
+        "/files/search/fuzzy": {
             "get": {
-                "description": "Get the commit history of the Git repository",
+                "description": "Find files whose path relative to the search directory contains the characters of the query in order, like fzf. Results are ranked, matches at the start of path segments and words, consecutive matches and matches in the file name score higher. The query is case-insensitive unless it contains an upper case letter. Files ignored by .gitignore are skipped unless noIgnore is set.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "git"
+                    "file-system"
                 ],
-                "summary": "Get commit history",
-                "operationId": "GetCommitHistory",
+                "summary": "Fuzzy search files by path",
+                "operationId": "FuzzySearchFiles",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Repository path",
+                        "description": "Directory to search in",
                         "name": "path",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Fuzzy query, an empty query lists the shortest paths first",
+                        "name": "query",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only consider files matching these globs, ** matches any number of directories",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Skip files and directories matching these globs",
+                        "name": "exclude",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Do not skip files ignored by .gitignore",
+                        "name": "noIgnore",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of results (default 50)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/GitCommitInfo"
-                            }
+                            "$ref": "#/definitions/FuzzySearchResponse"
                         }
                     }
                 }
             }
         },
-        "/git/pull": {
+        "/files/sync": {
             "post": {
-                "description": "Pull changes from the remote Git repository",
+                "description": "Make a directory match a manifest. The first part of the multipart body must be the manifest, followed by the blobs that GetMissingBlobs reported, each with its hash as file name. Contents already present below the directory are reused. When blobs are still missing nothing is written and 409 is returned with the missing hashes. Files are replaced atomically, one at a time.",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "git"
+                    "file-system"
                 ],
-                "summary": "Pull changes from remote",
-                "operationId": "PullChanges",
+                "summary": "Materialize a manifest",
+                "operationId": "SyncFiles",
                 "parameters": [
                     {
-                        "description": "Pull request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/GitRepoRequest"
-                        }
+                        "type": "string",
+                        "description": "SyncRequest as JSON",
+                        "name": "manifest",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Blob content, named by its hash (repeatable)",
+                        "name": "blob",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SyncResponse"
+                        }
                     }
                 }
             }
         },
-        "/git/push": {
+        "/files/sync/missing": {
             "post": {
-                "description": "Push local changes to the remote Git repository",
+                "description": "Compare a manifest with the directory it would be materialized into and return the hashes of the file contents that are not available there yet. Only these blobs have to be uploaded to SyncFiles. Contents are found at the path of the entry or at any other path below the directory.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1521,128 +1979,223 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "git"
+                    "file-system"
                 ],
-                "summary": "Push changes to remote",
-                "operationId": "PushChanges",
+                "summary": "Find the blobs missing for a sync",
+                "operationId": "GetMissingBlobs",
                 "parameters": [
                     {
-                        "description": "Push request",
+                        "description": "Manifest to compare",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/GitRepoRequest"
+                            "$ref": "#/definitions/MissingBlobsRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
-                    }
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/MissingBlobsResponse"
+                        }
+                    }
                 }
             }
         },
-        "/git/status": {
+        "/files/trash": {
             "get": {
-                "description": "Get the Git status of the repository at the specified path",
+                "description": "List the files and directories in the trash, most recently deleted first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "git"
+                    "file-system"
                 ],
-                "summary": "Get Git status",
-                "operationId": "GetStatus",
+                "summary": "List the trash",
+                "operationId": "ListTrash",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/TrashItem"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Permanently delete everything in the trash",
+                "tags": [
+                    "file-system"
+                ],
+                "summary": "Empty the trash",
+                "operationId": "PurgeTrash",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/files/trash/{itemId}": {
+            "delete": {
+                "description": "Permanently delete a file or directory from the trash",
+                "tags": [
+                    "file-system"
+                ],
+                "summary": "Purge a trash item",
+                "operationId": "PurgeTrashItem",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Repository path",
-                        "name": "path",
-                        "in": "query",
+                        "description": "Trash item ID",
+                        "name": "itemId",
+                        "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/GitStatus"
-                        }
+                    "204": {
+                        "description": "No Content"
                     }
                 }
             }
         },
-        "/lsp/completions": {
+        "/files/trash/{itemId}/restore": {
             "post": {
-                "description": "Get code completion suggestions from the LSP server",
-                "consumes": [
-                    "application/json"
+                "description": "Move a file or directory out of the trash, back to its original path or to the given path. Missing parent directories are created.",
+                "tags": [
+                    "file-system"
+                ],
+                "summary": "Restore a trash item",
+                "operationId": "RestoreTrashItem",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Trash item ID",
+                        "name": "itemId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Path to restore to (default: the original path)",
+                        "name": "path",
+                        "in": "query"
+                    }
                 ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/files/tree": {
+            "get": {
+                "description": "Get the nested tree of a directory down to the given depth. Directory sizes and counts include everything below them, also beyond the depth limit. Symlinks are reported with their target and not followed. Entries ignored by .gitignore are left out unless noIgnore is set.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "lsp"
+                    "file-system"
                 ],
-                "summary": "Get code completions",
-                "operationId": "Completions",
+                "summary": "Get a directory tree",
+                "operationId": "GetFileTree",
                 "parameters": [
                     {
-                        "description": "Completion request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/LspCompletionParams"
-                        }
+                        "type": "string",
+                        "description": "Directory path (defaults to working directory)",
+                        "name": "path",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of levels to list below the directory (default: 3)",
+                        "name": "depth",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only list files matching these globs",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Skip files and directories matching these globs",
+                        "name": "exclude",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Do not respect .gitignore files",
+                        "name": "noIgnore",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/CompletionList"
+                            "$ref": "#/definitions/FileTreeNode"
                         }
                     }
                 }
             }
         },
-        "/lsp/did-close": {
+        "/files/upload": {
             "post": {
-                "description": "Notify the LSP server that a document has been closed",
+                "description": "Upload a file to the specified path",
                 "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "tags": [
-                    "lsp"
+                    "file-system"
                 ],
-                "summary": "Notify document closed",
-                "operationId": "DidClose",
+                "summary": "Upload a file",
+                "operationId": "UploadFile",
                 "parameters": [
                     {
-                        "description": "Document request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/LspDocumentRequest"
-                        }
+                        "type": "string",
+                        "description": "Destination path for the uploaded file",
+                        "name": "path",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "File to upload",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/H"
+                        }
                     }
                 }
             }
         },
-        "/lsp/did-open": {
+        "/files/uploads": {
             "post": {
-                "description": "Notify the LSP server that a document has been opened",
+                "description": "Start a resumable upload to the specified path. Upload the content in chunks with UploadChunk, then call CompleteUploadSession. Sessions survive daemon restarts, resume from the offset reported by GetUploadSession.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1650,59 +2203,48 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "lsp"
+                    "file-system"
                 ],
-                "summary": "Notify document opened",
-                "operationId": "DidOpen",
+                "summary": "Create an upload session",
+                "operationId": "CreateUploadSession",
                 "parameters": [
                     {
-                        "description": "Document request",
+                        "description": "Upload session request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/LspDocumentRequest"
+                            "$ref": "#/definitions/CreateUploadSessionRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK"
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/UploadSession"
+                        }
                     }
                 }
             }
         },
-        "/lsp/document-symbols": {
+        "/files/uploads/{uploadId}": {
             "get": {
-                "description": "Get symbols (functions, classes, etc.) from a document",
+                "description": "Get the state of an upload session, including the offset to resume from",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "lsp"
+                    "file-system"
                 ],
-                "summary": "Get document symbols",
-                "operationId": "DocumentSymbols",
+                "summary": "Get upload session",
+                "operationId": "GetUploadSession",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Language ID (e.g., python, typescript)",
-                        "name": "languageId",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Path to project",
-                        "name": "pathToProject",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Document URI",
-                        "name": "uri",
-                        "in": "query",
+                        "description": "Upload session ID",
+                        "name": "uploadId",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -1710,50 +2252,90 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/LspSymbol"
-                            }
+                            "$ref": "#/definitions/UploadSession"
                         }
                     }
                 }
-            }
-        },
-        "/lsp/start": {
-            "post": {
-                "description": "Start a Language Server Protocol server for the specified language",
+            },
+            "put": {
+                "description": "Append the raw request body to an upload session. The offset must match the current session offset, otherwise 409 is returned with the current session state.",
                 "consumes": [
-                    "application/json"
+                    "application/octet-stream"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "lsp"
+                    "file-system"
                 ],
-                "summary": "Start LSP server",
-                "operationId": "Start",
+                "summary": "Upload a chunk",
+                "operationId": "UploadChunk",
                 "parameters": [
                     {
-                        "description": "LSP server request",
-                        "name": "request",
+                        "type": "string",
+                        "description": "Upload session ID",
+                        "name": "uploadId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Byte offset of the chunk",
+                        "name": "offset",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Chunk content",
+                        "name": "chunk",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/LspServerRequest"
+                            "type": "string"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UploadSession"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/UploadSession"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Abort an upload session and discard the data uploaded so far",
+                "tags": [
+                    "file-system"
+                ],
+                "summary": "Abort an upload session",
+                "operationId": "DeleteUploadSession",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload session ID",
+                        "name": "uploadId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
                     }
                 }
             }
         },
-        "/lsp/stop": {
+        "/files/uploads/{uploadId}/complete": {
             "post": {
-                "description": "Stop a Language Server Protocol server",
+                "description": "Verify the SHA-256 checksum of the uploaded content and move it to the destination path",
                 "consumes": [
                     "application/json"
                 ],
@@ -1761,112 +2343,1317 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "lsp"
+                    "file-system"
                 ],
-                "summary": "Stop LSP server",
-                "operationId": "Stop",
+                "summary": "Complete an upload session",
+                "operationId": "CompleteUploadSession",
                 "parameters": [
                     {
-                        "description": "LSP server request",
+                        "type": "string",
+                        "description": "Upload session ID",
+                        "name": "uploadId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Completion request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/LspServerRequest"
+                            "$ref": "#/definitions/CompleteUploadRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UploadSession"
+                        }
                     }
                 }
             }
         },
-        "/lsp/workspacesymbols": {
+        "/files/watch": {
+            "get": {
+                "description": "Stream create, modify, delete and rename events for a path over WebSocket. Events are coalesced per path within the debounce window, and sent at the latest four windows after the first one of a batch. Directories ignored by .gitignore are not watched unless noIgnore is set.",
+                "tags": [
+                    "file-system"
+                ],
+                "summary": "Watch files for changes",
+                "operationId": "WatchFiles",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File or directory path to watch",
+                        "name": "path",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Watch subdirectories recursively",
+                        "name": "recursive",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only report files matching these globs",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Skip files and directories matching these globs",
+                        "name": "exclude",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Do not respect .gitignore files",
+                        "name": "noIgnore",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Debounce window in milliseconds (default: 100, 0 disables debouncing)",
+                        "name": "debounce",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols - events are sent as JSON text messages",
+                        "schema": {
+                            "$ref": "#/definitions/FileWatchEvent"
+                        }
+                    }
+                }
+            }
+        },
+        "/git/add": {
+            "post": {
+                "description": "Add files to the Git staging area",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "git"
+                ],
+                "summary": "Add files to Git staging",
+                "operationId": "AddFiles",
+                "parameters": [
+                    {
+                        "description": "Add files request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/GitAddRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/git/branches": {
+            "get": {
+                "description": "Get a list of all branches in the Git repository",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "git"
+                ],
+                "summary": "List branches",
+                "operationId": "ListBranches",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Repository path",
+                        "name": "path",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ListBranchResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new branch in the Git repository",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "git"
+                ],
+                "summary": "Create a new branch",
+                "operationId": "CreateBranch",
+                "parameters": [
+                    {
+                        "description": "Create branch request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/GitBranchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a branch from the Git repository",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "git"
+                ],
+                "summary": "Delete a branch",
+                "operationId": "DeleteBranch",
+                "parameters": [
+                    {
+                        "description": "Delete branch request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/GitDeleteBranchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/git/checkout": {
+            "post": {
+                "description": "Switch to a different branch or commit in the Git repository",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "git"
+                ],
+                "summary": "Checkout branch or commit",
+                "operationId": "CheckoutBranch",
+                "parameters": [
+                    {
+                        "description": "Checkout request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/GitCheckoutRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/git/clone": {
+            "post": {
+                "description": "Clone a Git repository to the specified path",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "git"
+                ],
+                "summary": "Clone a Git repository",
+                "operationId": "CloneRepository",
+                "parameters": [
+                    {
+                        "description": "Clone repository request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/GitCloneRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/git/commit": {
+            "post": {
+                "description": "Commit staged changes to the Git repository",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "git"
+                ],
+                "summary": "Commit changes",
+                "operationId": "CommitChanges",
+                "parameters": [
+                    {
+                        "description": "Commit request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/GitCommitRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/GitCommitResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/git/history": {
+            "get": {
+                "description": "Get the commit history of the Git repository",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "git"
+                ],
+                "summary": "Get commit history",
+                "operationId": "GetCommitHistory",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Repository path",
+                        "name": "path",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/GitCommitInfo"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/git/pull": {
+            "post": {
+                "description": "Pull changes from the remote Git repository",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "git"
+                ],
+                "summary": "Pull changes from remote",
+                "operationId": "PullChanges",
+                "parameters": [
+                    {
+                        "description": "Pull request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/GitRepoRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/git/push": {
+            "post": {
+                "description": "Push local changes to the remote Git repository",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "git"
+                ],
+                "summary": "Push changes to remote",
+                "operationId": "PushChanges",
+                "parameters": [
+                    {
+                        "description": "Push request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/GitRepoRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/git/status": {
+            "get": {
+                "description": "Get the Git status of the repository at the specified path",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "git"
+                ],
+                "summary": "Get Git status",
+                "operationId": "GetStatus",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Repository path",
+                        "name": "path",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/GitStatus"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs": {
+            "get": {
+                "description": "Get the jobs in the order they were submitted. Only the latest 1000 finished jobs are kept.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "job"
+                ],
+                "summary": "List jobs",
+                "operationId": "ListJobs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only jobs with this status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/Job"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Queue a command to run detached from the request. Jobs run concurrently up to the configured limit, the highest priority first. The ID of the job is returned right away.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "job"
+                ],
+                "summary": "Submit a job",
+                "operationId": "SubmitJob",
+                "parameters": [
+                    {
+                        "description": "Job",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/SubmitJobRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/Job"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{jobId}": {
+            "get": {
+                "description": "Get the status of a job, and its exit code once it has finished",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "job"
+                ],
+                "summary": "Get a job",
+                "operationId": "GetJob",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "jobId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Job"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a finished job with its output",
+                "tags": [
+                    "job"
+                ],
+                "summary": "Delete a job",
+                "operationId": "DeleteJob",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "jobId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/jobs/{jobId}/cancel": {
+            "post": {
+                "description": "Remove a queued job from the queue, or kill the process group of a running job and wait until it has exited",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "job"
+                ],
+                "summary": "Cancel a job",
+                "operationId": "CancelJob",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "jobId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Job"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{jobId}/output": {
+            "get": {
+                "description": "Get the combined stdout and stderr of a job. With follow the response stays open and streams new output until the job has finished.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "job"
+                ],
+                "summary": "Get the output of a job",
+                "operationId": "GetJobOutput",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "jobId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Stream output as it is written",
+                        "name": "follow",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Output",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/lsp/completions": {
+            "post": {
+                "description": "Get code completion suggestions from the LSP server",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lsp"
+                ],
+                "summary": "Get code completions",
+                "operationId": "Completions",
+                "parameters": [
+                    {
+                        "description": "Completion request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/LspCompletionParams"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/CompletionList"
+                        }
+                    }
+                }
+            }
+        },
+        "/lsp/did-close": {
+            "post": {
+                "description": "Notify the LSP server that a document has been closed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lsp"
+                ],
+                "summary": "Notify document closed",
+                "operationId": "DidClose",
+                "parameters": [
+                    {
+                        "description": "Document request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/LspDocumentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/lsp/did-open": {
+            "post": {
+                "description": "Notify the LSP server that a document has been opened",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lsp"
+                ],
+                "summary": "Notify document opened",
+                "operationId": "DidOpen",
+                "parameters": [
+                    {
+                        "description": "Document request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/LspDocumentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/lsp/document-symbols": {
+            "get": {
+                "description": "Get symbols (functions, classes, etc.) from a document",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lsp"
+                ],
+                "summary": "Get document symbols",
+                "operationId": "DocumentSymbols",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Language ID (e.g., python, typescript)",
+                        "name": "languageId",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Path to project",
+                        "name": "pathToProject",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Document URI",
+                        "name": "uri",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/LspSymbol"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/lsp/start": {
+            "post": {
+                "description": "Start a Language Server Protocol server for the specified language",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lsp"
+                ],
+                "summary": "Start LSP server",
+                "operationId": "Start",
+                "parameters": [
+                    {
+                        "description": "LSP server request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/LspServerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/lsp/stop": {
+            "post": {
+                "description": "Stop a Language Server Protocol server",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lsp"
+                ],
+                "summary": "Stop LSP server",
+                "operationId": "Stop",
+                "parameters": [
+                    {
+                        "description": "LSP server request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/LspServerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/lsp/workspacesymbols": {
             "get": {
                 "description": "Search for symbols across the entire workspace",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "lsp"
+                    "lsp"
+                ],
+                "summary": "Get workspace symbols",
+                "operationId": "WorkspaceSymbols",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search query",
+                        "name": "query",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language ID (e.g., python, typescript)",
+                        "name": "languageId",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Path to project",
+                        "name": "pathToProject",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/LspSymbol"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/port": {
+            "get": {
+                "description": "Get a list of all currently active ports",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "port"
+                ],
+                "summary": "Get active ports",
+                "operationId": "GetPorts",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/PortList"
+                        }
+                    }
+                }
+            }
+        },
+        "/port/{port}/in-use": {
+            "get": {
+                "description": "Check if a specific port is currently in use",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "port"
+                ],
+                "summary": "Check if port is in use",
+                "operationId": "IsPortInUse",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Port number (3000-9999)",
+                        "name": "port",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/IsPortInUseResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/process/execute": {
+            "post": {
+                "description": "Execute a command and return the output and exit code. The command line is split into arguments on whitespace, unless shell is set to run it through the user's shell. Alternatively argv runs a program with the given arguments as they are. With limits the command runs in its own cgroup, processes it leaves behind are killed once it exits.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "process"
+                ],
+                "summary": "Execute a command",
+                "operationId": "ExecuteCommand",
+                "parameters": [
+                    {
+                        "description": "Command execution request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ExecuteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ExecuteResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/process/execute/stream": {
+            "post": {
+                "description": "Execute a shell command and stream its stdout and stderr as server-sent events while it runs, in the order the output arrives. The event name is the event type, the data an ExecuteStreamEvent. The last event is an exit event. The command is killed when the client disconnects.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "process"
+                ],
+                "summary": "Execute a command and stream its output",
+                "operationId": "ExecuteCommandStream",
+                "parameters": [
+                    {
+                        "description": "Command execution request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ExecuteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stream of events",
+                        "schema": {
+                            "$ref": "#/definitions/ExecuteStreamEvent"
+                        }
+                    }
+                }
+            }
+        },
+        "/process/execute/ws": {
+            "get": {
+                "description": "Execute a shell command and stream its stdout and stderr over WebSocket while it runs, in the order the output arrives. The client sends an ExecuteRequest as the first message, every following message from the daemon is an ExecuteStreamEvent. The last event is an exit event, or an error event when the command could not be started. The command is killed when the client disconnects.",
+                "tags": [
+                    "process"
+                ],
+                "summary": "Execute a command over WebSocket",
+                "operationId": "ExecuteCommandWebSocket",
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols - events are sent as JSON text messages",
+                        "schema": {
+                            "$ref": "#/definitions/ExecuteStreamEvent"
+                        }
+                    }
+                }
+            }
+        },
+        "/process/interpreter/context": {
+            "get": {
+                "description": "Returns information about all user-created interpreter contexts (excludes default context)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "interpreter"
+                ],
+                "summary": "List all user-created interpreter contexts",
+                "operationId": "ListInterpreterContexts",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ListContextsResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a new isolated interpreter context with optional working directory and language",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "interpreter"
+                ],
+                "summary": "Create a new interpreter context",
+                "operationId": "CreateInterpreterContext",
+                "parameters": [
+                    {
+                        "description": "Context configuration",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/CreateContextRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/InterpreterContext"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/process/interpreter/context/{id}": {
+            "delete": {
+                "description": "Deletes an interpreter context and shuts down its worker process",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "interpreter"
+                ],
+                "summary": "Delete an interpreter context",
+                "operationId": "DeleteInterpreterContext",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Context ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/process/interpreter/execute": {
+            "get": {
+                "description": "Executes code in a specified context (or default context if not specified) via WebSocket streaming",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "interpreter"
+                ],
+                "summary": "Execute code in an interpreter context",
+                "operationId": "ExecuteInterpreterCode",
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "type": "string"
+                        },
+                        "headers": {
+                            "Connection": {
+                                "type": "string",
+                                "description": "Upgrade"
+                            },
+                            "Upgrade": {
+                                "type": "string",
+                                "description": "websocket"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/process/list": {
+            "get": {
+                "description": "List the processes of the sandbox ordered by PID, or as a tree of root processes with their children nested when tree is set",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "process"
+                ],
+                "summary": "List processes",
+                "operationId": "ListProcesses",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Nest child processes under their parents",
+                        "name": "tree",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/ProcessInfo"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/process/pty": {
+            "get": {
+                "description": "Get a list of all active pseudo-terminal sessions",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "process"
+                ],
+                "summary": "List all PTY sessions",
+                "operationId": "ListPtySessions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/PtyListResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new pseudo-terminal session with specified configuration. With limits the shell and its children run in a cgroup of their own.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "process"
                 ],
-                "summary": "Get workspace symbols",
-                "operationId": "WorkspaceSymbols",
+                "summary": "Create a new PTY session",
+                "operationId": "CreatePtySession",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Search query",
-                        "name": "query",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Language ID (e.g., python, typescript)",
-                        "name": "languageId",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Path to project",
-                        "name": "pathToProject",
-                        "in": "query",
-                        "required": true
+                        "description": "PTY session creation request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/PtyCreateRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/LspSymbol"
-                            }
+                            "$ref": "#/definitions/PtyCreateResponse"
                         }
                     }
                 }
             }
         },
-        "/port": {
+        "/process/pty/{sessionId}": {
             "get": {
-                "description": "Get a list of all currently active ports",
+                "description": "Get detailed information about a specific pseudo-terminal session",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "port"
+                    "process"
+                ],
+                "summary": "Get PTY session information",
+                "operationId": "GetPtySession",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "PTY session ID",
+                        "name": "sessionId",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get active ports",
-                "operationId": "GetPorts",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/PortList"
+                            "$ref": "#/definitions/PtySessionInfo"
                         }
                     }
                 }
-            }
-        },
-        "/port/{port}/in-use": {
-            "get": {
-                "description": "Check if a specific port is currently in use",
+            },
+            "delete": {
+                "description": "Delete a pseudo-terminal session and terminate its process",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "port"
+                    "process"
                 ],
-                "summary": "Check if port is in use",
-                "operationId": "IsPortInUse",
+                "summary": "Delete a PTY session",
+                "operationId": "DeletePtySession",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Port number (3000-9999)",
-                        "name": "port",
+                        "type": "string",
+                        "description": "PTY session ID",
+                        "name": "sessionId",
                         "in": "path",
                         "required": true
                     }
@@ -1875,15 +3662,39 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/IsPortInUseResponse"
+                            "$ref": "#/definitions/H"
                         }
                     }
                 }
             }
         },
-        "/process/execute": {
+        "/process/pty/{sessionId}/connect": {
+            "get": {
+                "description": "Establish a WebSocket connection to interact with a pseudo-terminal session",
+                "tags": [
+                    "process"
+                ],
+                "summary": "Connect to PTY session via WebSocket",
+                "operationId": "ConnectPtySession",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "PTY session ID",
+                        "name": "sessionId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols - WebSocket connection established"
+                    }
+                }
+            }
+        },
+        "/process/pty/{sessionId}/resize": {
             "post": {
-                "description": "Execute a shell command and return the output and exit code",
+                "description": "Resize the terminal dimensions of a pseudo-terminal session",
                 "consumes": [
                     "application/json"
                 ],
@@ -1893,16 +3704,23 @@ const docTemplate = `{
                 "tags": [
                     "process"
                 ],
-                "summary": "Execute a command",
-                "operationId": "ExecuteCommand",
+                "summary": "Resize a PTY session",
+                "operationId": "ResizePtySession",
                 "parameters": [
                     {
-                        "description": "Command execution request",
+                        "type": "string",
+                        "description": "PTY session ID",
+                        "name": "sessionId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Resize request with new dimensions",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/ExecuteRequest"
+                            "$ref": "#/definitions/PtyResizeRequest"
                         }
                     }
                 ],
@@ -1910,34 +3728,37 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/ExecuteResponse"
+                            "$ref": "#/definitions/PtySessionInfo"
                         }
                     }
                 }
             }
         },
-        "/process/interpreter/context": {
+        "/process/session": {
             "get": {
-                "description": "Returns information about all user-created interpreter contexts (excludes default context)",
+                "description": "Get a list of all shell sessions, including the sessions restored after a restart of the daemon whose shells are gone",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "interpreter"
+                    "process"
                 ],
-                "summary": "List all user-created interpreter contexts",
-                "operationId": "ListInterpreterContexts",
+                "summary": "List all sessions",
+                "operationId": "ListSessions",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/ListContextsResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/Session"
+                            }
                         }
                     }
                 }
             },
             "post": {
-                "description": "Creates a new isolated interpreter context with optional working directory and language",
+                "description": "Create a new shell session for command execution. An existing session with the same ID whose shell is gone, e.g. one restored after a restart of the daemon, is replaced along with its commands and logs. With limits the shell and its commands run in a cgroup of their own. The shell must be POSIX compatible.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1945,65 +3766,44 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "interpreter"
+                    "process"
                 ],
-                "summary": "Create a new interpreter context",
-                "operationId": "CreateInterpreterContext",
+                "summary": "Create a new session",
+                "operationId": "CreateSession",
                 "parameters": [
                     {
-                        "description": "Context configuration",
+                        "description": "Session creation request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/CreateContextRequest"
+                            "$ref": "#/definitions/CreateSessionRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/InterpreterContext"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
+                    "201": {
+                        "description": "Created"
                     }
                 }
             }
         },
-        "/process/interpreter/context/{id}": {
-            "delete": {
-                "description": "Deletes an interpreter context and shuts down its worker process",
+        "/process/session/{sessionId}": {
+            "get": {
+                "description": "Get details of a specific session including its commands, and the working directory and exported environment variables its last command left the shell in",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "interpreter"
+                    "process"
                 ],
-                "summary": "Delete an interpreter context",
-                "operationId": "DeleteInterpreterContext",
+                "summary": "Get session details",
+                "operationId": "GetSession",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Context ID",
-                        "name": "id",
+                        "description": "Session ID",
+                        "name": "sessionId",
                         "in": "path",
                         "required": true
                     }
@@ -2012,270 +3812,309 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/Session"
                         }
                     }
                 }
-            }
-        },
-        "/process/interpreter/execute": {
-            "get": {
-                "description": "Executes code in a specified context (or default context if not specified) via WebSocket streaming",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+            },
+            "delete": {
+                "description": "Delete an existing shell session",
                 "tags": [
-                    "interpreter"
-                ],
-                "summary": "Execute code in an interpreter context",
-                "operationId": "ExecuteInterpreterCode",
-                "responses": {
-                    "101": {
-                        "description": "Switching Protocols",
-                        "schema": {
-                            "type": "string"
-                        },
-                        "headers": {
-                            "Connection": {
-                                "type": "string",
-                                "description": "Upgrade"
-                            },
-                            "Upgrade": {
-                                "type": "string",
-                                "description": "websocket"
-                            }
-                        }
+                    "process"
+                ],
+                "summary": "Delete a session",
+                "operationId": "DeleteSession",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "sessionId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
                     }
                 }
             }
         },
-        "/process/pty": {
+        "/process/session/{sessionId}/command/{commandId}": {
             "get": {
-                "description": "Get a list of all active pseudo-terminal sessions",
+                "description": "Get details of a specific command within a session",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "process"
                 ],
-                "summary": "List all PTY sessions",
-                "operationId": "ListPtySessions",
+                "summary": "Get session command details",
+                "operationId": "GetSessionCommand",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "sessionId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Command ID",
+                        "name": "commandId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/PtyListResponse"
+                            "$ref": "#/definitions/Command"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/process/session/{sessionId}/command/{commandId}/input": {
             "post": {
-                "description": "Create a new pseudo-terminal session with specified configuration",
+                "description": "Write data to the standard input of a running command of a session, optionally closing it afterwards. Only commands run with runAsync take input. Data can be written before the command has started, its stdin can only be closed once it has.",
                 "consumes": [
                     "application/json"
                 ],
-                "produces": [
-                    "application/json"
-                ],
                 "tags": [
                     "process"
                 ],
-                "summary": "Create a new PTY session",
-                "operationId": "CreatePtySession",
+                "summary": "Write to the stdin of a session command",
+                "operationId": "SendSessionCommandInput",
                 "parameters": [
                     {
-                        "description": "PTY session creation request",
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "sessionId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Command ID",
+                        "name": "commandId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Input request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/PtyCreateRequest"
+                            "$ref": "#/definitions/SessionCommandInputRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
-                        "schema": {
-                            "$ref": "#/definitions/PtyCreateResponse"
-                        }
+                    "200": {
+                        "description": "OK"
                     }
                 }
             }
         },
-        "/process/pty/{sessionId}": {
+        "/process/session/{sessionId}/command/{commandId}/logs": {
             "get": {
-                "description": "Get detailed information about a specific pseudo-terminal session",
+                "description": "Get logs for a specific command within a session. Supports both HTTP and WebSocket streaming.",
                 "produces": [
-                    "application/json"
+                    "text/plain"
                 ],
                 "tags": [
                     "process"
                 ],
-                "summary": "Get PTY session information",
-                "operationId": "GetPtySession",
+                "summary": "Get session command logs",
+                "operationId": "GetSessionCommandLogs",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "PTY session ID",
+                        "description": "Session ID",
                         "name": "sessionId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Command ID",
+                        "name": "commandId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Follow logs in real-time (WebSocket only)",
+                        "name": "follow",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Log content",
                         "schema": {
-                            "$ref": "#/definitions/PtySessionInfo"
+                            "type": "string"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete a pseudo-terminal session and terminate its process",
-                "produces": [
+            }
+        },
+        "/process/session/{sessionId}/command/{commandId}/signal": {
+            "post": {
+                "description": "Send SIGINT, SIGTERM or SIGKILL to the processes of a running command of a session. Background jobs of the session shell started by earlier commands are not signaled, and neither is the shell itself, so a command made of shell builtins only can't be interrupted.",
+                "consumes": [
                     "application/json"
                 ],
                 "tags": [
                     "process"
                 ],
-                "summary": "Delete a PTY session",
-                "operationId": "DeletePtySession",
+                "summary": "Signal a session command",
+                "operationId": "SignalSessionCommand",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "PTY session ID",
+                        "description": "Session ID",
                         "name": "sessionId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Command ID",
+                        "name": "commandId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Signal request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/SessionCommandSignalRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/H"
-                        }
+                        "description": "OK"
                     }
                 }
             }
         },
-        "/process/pty/{sessionId}/connect": {
-            "get": {
-                "description": "Establish a WebSocket connection to interact with a pseudo-terminal session",
+        "/process/session/{sessionId}/exec": {
+            "post": {
+                "description": "Execute a command within an existing shell session",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
                     "process"
                 ],
-                "summary": "Connect to PTY session via WebSocket",
-                "operationId": "ConnectPtySession",
+                "summary": "Execute command in session",
+                "operationId": "SessionExecuteCommand",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "PTY session ID",
+                        "description": "Session ID",
                         "name": "sessionId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Command execution request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/SessionExecuteRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "101": {
-                        "description": "Switching Protocols - WebSocket connection established"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SessionExecuteResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/SessionExecuteResponse"
+                        }
                     }
                 }
             }
         },
-        "/process/pty/{sessionId}/resize": {
+        "/process/{pid}/signal": {
             "post": {
-                "description": "Resize the terminal dimensions of a pseudo-terminal session",
+                "description": "Send SIGINT, SIGTERM or SIGKILL to a process, or to its whole process group. The daemon and its own process group can't be signaled.",
                 "consumes": [
                     "application/json"
                 ],
-                "produces": [
-                    "application/json"
-                ],
                 "tags": [
                     "process"
                 ],
-                "summary": "Resize a PTY session",
-                "operationId": "ResizePtySession",
+                "summary": "Signal a process",
+                "operationId": "SignalProcess",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "PTY session ID",
-                        "name": "sessionId",
+                        "type": "integer",
+                        "description": "Process ID",
+                        "name": "pid",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Resize request with new dimensions",
+                        "description": "Signal request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/PtyResizeRequest"
+                            "$ref": "#/definitions/SignalRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/PtySessionInfo"
-                        }
+                        "description": "OK"
                     }
                 }
             }
         },
-        "/process/session": {
+        "/services": {
             "get": {
-                "description": "Get a list of all active shell sessions",
+                "description": "Get all services, sorted by name",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "process"
+                    "service"
                 ],
-                "summary": "List all sessions",
-                "operationId": "ListSessions",
+                "summary": "List services",
+                "operationId": "ListServices",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/Session"
+                                "$ref": "#/definitions/Service"
                             }
                         }
                     }
                 }
             },
             "post": {
-                "description": "Create a new shell session for command execution",
+                "description": "Start a long-running process, like a dev server or database, supervised by the daemon. Depending on its restart policy it is restarted when it exits, with a delay doubling from one second up to a minute while it keeps exiting. Its stdout and stderr go to a log that can be tailed, rotated at 10 MiB keeping one previous log.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2283,44 +4122,47 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "process"
+                    "service"
                 ],
-                "summary": "Create a new session",
-                "operationId": "CreateSession",
+                "summary": "Create a service",
+                "operationId": "CreateService",
                 "parameters": [
                     {
-                        "description": "Session creation request",
+                        "description": "Service",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/CreateSessionRequest"
+                            "$ref": "#/definitions/CreateServiceRequest"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Created"
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/Service"
+                        }
                     }
                 }
             }
         },
-        "/process/session/{sessionId}": {
+        "/services/{name}": {
             "get": {
-                "description": "Get details of a specific session including its commands",
+                "description": "Get the configuration and status of a service",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "process"
+                    "service"
                 ],
-                "summary": "Get session details",
-                "operationId": "GetSession",
+                "summary": "Get a service",
+                "operationId": "GetService",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Session ID",
-                        "name": "sessionId",
+                        "description": "Service name",
+                        "name": "name",
                         "in": "path",
                         "required": true
                     }
@@ -2329,23 +4171,23 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/Session"
+                            "$ref": "#/definitions/Service"
                         }
                     }
                 }
             },
             "delete": {
-                "description": "Delete an existing shell session",
+                "description": "Stop a service and delete it with its logs",
                 "tags": [
-                    "process"
+                    "service"
                 ],
-                "summary": "Delete a session",
-                "operationId": "DeleteSession",
+                "summary": "Delete a service",
+                "operationId": "DeleteService",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Session ID",
-                        "name": "sessionId",
+                        "description": "Service name",
+                        "name": "name",
                         "in": "path",
                         "required": true
                     }
@@ -2357,29 +4199,64 @@ const docTemplate = `{
                 }
             }
         },
-        "/process/session/{sessionId}/command/{commandId}": {
+        "/services/{name}/logs": {
             "get": {
-                "description": "Get details of a specific command within a session",
+                "description": "Get the last lines of the combined stdout and stderr of a service. With follow the response stays open and streams new output until the client disconnects or the service is deleted.",
                 "produces": [
-                    "application/json"
+                    "text/plain"
                 ],
                 "tags": [
-                    "process"
+                    "service"
                 ],
-                "summary": "Get session command details",
-                "operationId": "GetSessionCommand",
+                "summary": "Tail the logs of a service",
+                "operationId": "GetServiceLogs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Service name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of lines from the end, 0 for the whole log, defaults to 100",
+                        "name": "lines",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Stream new output as it is written",
+                        "name": "follow",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Log content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/services/{name}/restart": {
+            "post": {
+                "description": "Stop a service if it is running and start it again",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "service"
+                ],
+                "summary": "Restart a service",
+                "operationId": "RestartService",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Session ID",
-                        "name": "sessionId",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Command ID",
-                        "name": "commandId",
+                        "description": "Service name",
+                        "name": "name",
                         "in": "path",
                         "required": true
                     }
@@ -2388,98 +4265,67 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/Command"
+                            "$ref": "#/definitions/Service"
                         }
                     }
                 }
             }
         },
-        "/process/session/{sessionId}/command/{commandId}/logs": {
-            "get": {
-                "description": "Get logs for a specific command within a session. Supports both HTTP and WebSocket streaming.",
+        "/services/{name}/start": {
+            "post": {
+                "description": "Start a service that was stopped or is no longer restarted",
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "process"
+                    "service"
                 ],
-                "summary": "Get session command logs",
-                "operationId": "GetSessionCommandLogs",
+                "summary": "Start a service",
+                "operationId": "StartService",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Session ID",
-                        "name": "sessionId",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Command ID",
-                        "name": "commandId",
+                        "description": "Service name",
+                        "name": "name",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "boolean",
-                        "description": "Follow logs in real-time (WebSocket only)",
-                        "name": "follow",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Log content",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/Service"
                         }
                     }
                 }
             }
         },
-        "/process/session/{sessionId}/exec": {
+        "/services/{name}/stop": {
             "post": {
-                "description": "Execute a command within an existing shell session",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Stop a service with SIGTERM, and SIGKILL when it is still running after 10 seconds. It is not restarted until started again.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "process"
+                    "service"
                 ],
-                "summary": "Execute command in session",
-                "operationId": "SessionExecuteCommand",
+                "summary": "Stop a service",
+                "operationId": "StopService",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Session ID",
-                        "name": "sessionId",
+                        "description": "Service name",
+                        "name": "name",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Command execution request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/SessionExecuteRequest"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/SessionExecuteResponse"
-                        }
-                    },
-                    "202": {
-                        "description": "Accepted",
-                        "schema": {
-                            "$ref": "#/definitions/SessionExecuteResponse"
+                            "$ref": "#/definitions/Service"
                         }
                     }
                 }
@@ -2569,21 +4415,183 @@ const docTemplate = `{
                 }
             }
         },
+        "Checkpoint": {
+            "type": "object",
+            "required": [
+                "createdAt",
+                "files",
+                "id",
+                "path",
+                "size"
+            ],
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "entries": {
+                    "description": "Snapshotted entries, only returned for a single checkpoint",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/CheckpointEntry"
+                    }
+                },
+                "exclude": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "files": {
+                    "description": "Number of files and symlinks in the checkpoint",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "size": {
+                    "description": "Total size of the files in bytes",
+                    "type": "integer"
+                }
+            }
+        },
+        "CheckpointChange": {
+            "type": "object",
+            "required": [
+                "path",
+                "status"
+            ],
+            "properties": {
+                "diff": {
+                    "description": "Unified diff of text files, only set when requested",
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "added, deleted or modified",
+                    "type": "string"
+                }
+            }
+        },
+        "CheckpointDiff": {
+            "type": "object",
+            "required": [
+                "changes",
+                "from"
+            ],
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/CheckpointChange"
+                    }
+                },
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "description": "Id of the other checkpoint, empty when compared with the current files",
+                    "type": "string"
+                }
+            }
+        },
+        "CheckpointEntry": {
+            "type": "object",
+            "required": [
+                "modTime",
+                "mode",
+                "path",
+                "size"
+            ],
+            "properties": {
+                "hash": {
+                    "description": "Hex encoded SHA-256 of the content, only set for files",
+                    "type": "string"
+                },
+                "isDir": {
+                    "type": "boolean"
+                },
+                "linkTarget": {
+                    "description": "Target of a symlink",
+                    "type": "string"
+                },
+                "modTime": {
+                    "type": "string"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "path": {
+                    "description": "Slash separated path relative to the checkpoint path",
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
         "Command": {
             "type": "object",
             "required": [
                 "command",
-                "id"
+                "id",
+                "status"
             ],
             "properties": {
                 "command": {
                     "type": "string"
                 },
+                "endedAt": {
+                    "type": "string"
+                },
                 "exitCode": {
                     "type": "integer"
                 },
                 "id": {
                     "type": "string"
+                },
+                "oomKilled": {
+                    "description": "Set when the OOM killer killed a process of the session while the\ncommand ran",
+                    "type": "boolean"
+                },
+                "startedAt": {
+                    "description": "When the shell started the command, unset while earlier commands of\nthe session are still running",
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/CommandStatus"
+                }
+            }
+        },
+        "CommandStatus": {
+            "type": "string",
+            "enum": [
+                "running",
+                "exited",
+                "killed"
+            ],
+            "x-enum-varnames": [
+                "CommandStatusRunning",
+                "CommandStatusExited",
+                "CommandStatusKilled"
+            ]
+        },
+        "CompleteUploadRequest": {
+            "type": "object",
+            "required": [
+                "checksum"
+            ],
+            "properties": {
+                "checksum": {
+                    "description": "Hex encoded SHA-256 of the whole file, optionally prefixed with \"sha256:\"",
+                    "type": "string"
                 }
             }
         },
@@ -2682,6 +4690,26 @@ const docTemplate = `{
                 }
             }
         },
+        "CreateCheckpointRequest": {
+            "type": "object",
+            "properties": {
+                "exclude": {
+                    "description": "Globs of files and directories to leave out, matched against the relative path and the base name; ** matches any number of directories",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "message": {
+                    "description": "Description of the checkpoint",
+                    "type": "string"
+                },
+                "path": {
+                    "description": "Directory to snapshot (defaults to the work directory)",
+                    "type": "string"
+                }
+            }
+        },
         "CreateContextRequest": {
             "type": "object",
             "properties": {
@@ -2693,14 +4721,113 @@ const docTemplate = `{
                 }
             }
         },
+        "CreateServiceRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "argv": {
+                    "description": "Program and arguments, passed on without any parsing",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "command": {
+                    "description": "Command line run through the user's shell. Either command or argv is\nrequired",
+                    "type": "string"
+                },
+                "cwd": {
+                    "description": "Current working directory",
+                    "type": "string"
+                },
+                "envs": {
+                    "description": "Environment variables set in addition to the daemon's environment",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "limits": {
+                    "description": "Resource limits of each run of the service, needs cgroup v2",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/ResourceLimits"
+                        }
+                    ]
+                },
+                "maxRestarts": {
+                    "description": "Restarts in a row before giving up, unlimited when unset. The count\nresets once the service stays up for a minute",
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Letters, digits, dots, dashes and underscores",
+                    "type": "string"
+                },
+                "readiness": {
+                    "$ref": "#/definitions/ReadinessProbe"
+                },
+                "restart": {
+                    "description": "always, on-failure or never, defaults to on-failure",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/RestartPolicy"
+                        }
+                    ]
+                }
+            }
+        },
         "CreateSessionRequest": {
             "type": "object",
             "required": [
                 "sessionId"
             ],
             "properties": {
-                "sessionId": {
+                "cwd": {
+                    "description": "Initial working directory of the shell",
+                    "type": "string"
+                },
+                "envs": {
+                    "description": "Environment variables set in addition to the daemon's environment",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "limits": {
+                    "description": "Resource limits shared by the shell of the session and all its\ncommands, needs cgroup v2",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/ResourceLimits"
+                        }
+                    ]
+                },
+                "sessionId": {
+                    "type": "string"
+                },
+                "shell": {
+                    "description": "POSIX compatible shell to run, defaults to the user's shell",
+                    "type": "string"
+                }
+            }
+        },
+        "CreateUploadSessionRequest": {
+            "type": "object",
+            "required": [
+                "path"
+            ],
+            "properties": {
+                "mode": {
+                    "description": "Octal permission mode of the final file (default: 0644, or the mode of the file being replaced)",
+                    "type": "string"
+                },
+                "path": {
                     "type": "string"
+                },
+                "size": {
+                    "description": "Total size in bytes, when known in advance",
+                    "type": "integer"
                 }
             }
         },
@@ -2738,22 +4865,106 @@ const docTemplate = `{
                 }
             }
         },
+        "EditLinesRequest": {
+            "type": "object",
+            "required": [
+                "operation",
+                "path",
+                "start"
+            ],
+            "properties": {
+                "end": {
+                    "description": "1-based last line of the range for replace and delete, defaults to start",
+                    "type": "integer"
+                },
+                "expectedHash": {
+                    "description": "SHA-256 the file must still have, otherwise the edit fails with 409",
+                    "type": "string"
+                },
+                "lines": {
+                    "description": "Lines to insert or to replace the range with, without line endings",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "operation": {
+                    "description": "One of replace, insert or delete",
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "start": {
+                    "description": "1-based first line of the range. For insert, the line the new lines are inserted before; totalLines + 1 appends",
+                    "type": "integer"
+                }
+            }
+        },
+        "EditLinesResponse": {
+            "type": "object",
+            "required": [
+                "hash",
+                "path",
+                "totalLines"
+            ],
+            "properties": {
+                "hash": {
+                    "description": "SHA-256 of the file after the edit",
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "totalLines": {
+                    "type": "integer"
+                }
+            }
+        },
         "Empty": {
             "type": "object"
         },
         "ExecuteRequest": {
             "type": "object",
-            "required": [
-                "command"
-            ],
             "properties": {
+                "argv": {
+                    "description": "Program and arguments, passed on without any parsing",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "command": {
+                    "description": "Command line, split into arguments on whitespace unless shell is set.\nEither command or argv is required.",
                     "type": "string"
                 },
                 "cwd": {
                     "description": "Current working directory",
                     "type": "string"
                 },
+                "envs": {
+                    "description": "Environment variables set in addition to the daemon's environment",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "limits": {
+                    "description": "Resource limits of the command and its children, needs cgroup v2",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/ResourceLimits"
+                        }
+                    ]
+                },
+                "shell": {
+                    "description": "Run command through the user's shell, so pipes, redirects, globs and\nvariable expansion work",
+                    "type": "boolean"
+                },
+                "stdin": {
+                    "description": "Content written to the standard input of the command",
+                    "type": "string"
+                },
                 "timeout": {
                     "description": "Timeout in seconds, defaults to 10 seconds",
                     "type": "integer"
@@ -2769,7 +4980,100 @@ const docTemplate = `{
                 "exitCode": {
                     "type": "integer"
                 },
+                "oomKilled": {
+                    "description": "Set when a process of the command was killed for exceeding the memory limit",
+                    "type": "boolean"
+                },
                 "result": {
+                    "description": "Standard output followed by standard error",
+                    "type": "string"
+                },
+                "stderr": {
+                    "type": "string"
+                },
+                "stdout": {
+                    "type": "string"
+                }
+            }
+        },
+        "ExecuteStreamEvent": {
+            "type": "object",
+            "required": [
+                "type"
+            ],
+            "properties": {
+                "data": {
+                    "description": "Output chunk of stdout and stderr events, message of error events",
+                    "type": "string"
+                },
+                "exitCode": {
+                    "description": "Exit code of the exit event",
+                    "type": "integer"
+                },
+                "oomKilled": {
+                    "description": "Set on the exit event when a process of the command was killed for\nexceeding the memory limit",
+                    "type": "boolean"
+                },
+                "timedOut": {
+                    "description": "Set on the exit event when the command was killed after the timeout",
+                    "type": "boolean"
+                },
+                "type": {
+                    "description": "stdout, stderr, exit or error",
+                    "type": "string"
+                }
+            }
+        },
+        "ExtractResult": {
+            "type": "object",
+            "required": [
+                "directories",
+                "files",
+                "path",
+                "symlinks"
+            ],
+            "properties": {
+                "directories": {
+                    "type": "integer"
+                },
+                "files": {
+                    "type": "integer"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "symlinks": {
+                    "type": "integer"
+                }
+            }
+        },
+        "FileDetails": {
+            "type": "object",
+            "required": [
+                "isBinary",
+                "mimeType",
+                "sha256"
+            ],
+            "properties": {
+                "encoding": {
+                    "description": "ascii, utf-8, utf-8-bom, utf-16le, utf-16be or unknown. Not set for binary files.",
+                    "type": "string"
+                },
+                "isBinary": {
+                    "type": "boolean"
+                },
+                "lineCount": {
+                    "description": "Not set for binary files",
+                    "type": "integer"
+                },
+                "lineEnding": {
+                    "description": "lf, crlf, cr, mixed or none. Not set for binary files.",
+                    "type": "string"
+                },
+                "mimeType": {
+                    "type": "string"
+                },
+                "sha256": {
                     "type": "string"
                 }
             }
@@ -2779,6 +5083,7 @@ const docTemplate = `{
             "required": [
                 "group",
                 "isDir",
+                "isSymlink",
                 "modTime",
                 "mode",
                 "name",
@@ -2787,12 +5092,26 @@ const docTemplate = `{
                 "size"
             ],
             "properties": {
+                "details": {
+                    "description": "Content details of a regular file, only returned on request",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/FileDetails"
+                        }
+                    ]
+                },
                 "group": {
                     "type": "string"
                 },
                 "isDir": {
                     "type": "boolean"
                 },
+                "isSymlink": {
+                    "type": "boolean"
+                },
+                "linkTarget": {
+                    "type": "string"
+                },
                 "modTime": {
                     "type": "string"
                 },
@@ -2813,6 +5132,43 @@ const docTemplate = `{
                 }
             }
         },
+        "FileLinesResponse": {
+            "type": "object",
+            "required": [
+                "end",
+                "hash",
+                "lines",
+                "path",
+                "start",
+                "totalLines"
+            ],
+            "properties": {
+                "end": {
+                    "description": "1-based number of the last returned line, start - 1 when no line was returned",
+                    "type": "integer"
+                },
+                "hash": {
+                    "description": "SHA-256 of the whole file, to be passed as expectedHash when editing it",
+                    "type": "string"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "path": {
+                    "type": "string"
+                },
+                "start": {
+                    "description": "1-based number of the first returned line",
+                    "type": "integer"
+                },
+                "totalLines": {
+                    "type": "integer"
+                }
+            }
+        },
         "FileStatus": {
             "type": "object",
             "required": [
@@ -2836,17 +5192,142 @@ const docTemplate = `{
                 }
             }
         },
+        "FileTreeNode": {
+            "type": "object",
+            "required": [
+                "isDir",
+                "name",
+                "path",
+                "size"
+            ],
+            "properties": {
+                "children": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/FileTreeNode"
+                    }
+                },
+                "dirCount": {
+                    "description": "Number of directories below a directory, at any depth",
+                    "type": "integer"
+                },
+                "error": {
+                    "description": "Set when the directory could not be read",
+                    "type": "string"
+                },
+                "fileCount": {
+                    "description": "Number of files below a directory, at any depth",
+                    "type": "integer"
+                },
+                "isDir": {
+                    "type": "boolean"
+                },
+                "isSymlink": {
+                    "type": "boolean"
+                },
+                "linkTarget": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "size": {
+                    "description": "Size of a file, or the total size of the files below a directory",
+                    "type": "integer"
+                },
+                "truncated": {
+                    "description": "Whether the children of the directory were left out because of the depth limit",
+                    "type": "boolean"
+                }
+            }
+        },
+        "FileWatchEvent": {
+            "type": "object",
+            "required": [
+                "isDir",
+                "time",
+                "type"
+            ],
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "isDir": {
+                    "type": "boolean"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "type": {
+                    "description": "One of create, modify, delete, rename or error",
+                    "type": "string"
+                }
+            }
+        },
         "FilesDownloadRequest": {
             "type": "object",
             "required": [
                 "paths"
             ],
             "properties": {
-                "paths": {
+                "paths": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "FuzzyMatch": {
+            "type": "object",
+            "required": [
+                "path",
+                "positions",
+                "relativePath",
+                "score"
+            ],
+            "properties": {
+                "path": {
+                    "type": "string"
+                },
+                "positions": {
+                    "description": "Indexes of the matched characters in relativePath, counted in unicode code points",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "relativePath": {
+                    "description": "Path relative to the search directory, the text the query was matched against",
+                    "type": "string"
+                },
+                "score": {
+                    "type": "integer"
+                }
+            }
+        },
+        "FuzzySearchResponse": {
+            "type": "object",
+            "required": [
+                "matches",
+                "total"
+            ],
+            "properties": {
+                "matches": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/FuzzyMatch"
                     }
+                },
+                "total": {
+                    "description": "Number of matching files, including those cut off by the limit",
+                    "type": "integer"
                 }
             }
         },
@@ -3088,6 +5569,78 @@ const docTemplate = `{
                 }
             }
         },
+        "Job": {
+            "type": "object",
+            "required": [
+                "id",
+                "priority",
+                "status",
+                "submittedAt"
+            ],
+            "properties": {
+                "argv": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "command": {
+                    "type": "string"
+                },
+                "endedAt": {
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Why the job could not be started",
+                    "type": "string"
+                },
+                "exitCode": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "oomKilled": {
+                    "description": "Set when the OOM killer killed a process of the job",
+                    "type": "boolean"
+                },
+                "pid": {
+                    "type": "integer"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "startedAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/JobStatus"
+                },
+                "submittedAt": {
+                    "type": "string"
+                },
+                "timedOut": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "JobStatus": {
+            "type": "string",
+            "enum": [
+                "queued",
+                "running",
+                "exited",
+                "cancelled",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "JobStatusQueued",
+                "JobStatusRunning",
+                "JobStatusExited",
+                "JobStatusCancelled",
+                "JobStatusFailed"
+            ]
+        },
         "KeyboardHotkeyRequest": {
             "type": "object",
             "properties": {
@@ -3276,17 +5829,84 @@ const docTemplate = `{
                 }
             }
         },
+        "Manifest": {
+            "type": "object",
+            "required": [
+                "entries",
+                "path"
+            ],
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ManifestEntry"
+                    }
+                },
+                "path": {
+                    "type": "string"
+                }
+            }
+        },
+        "ManifestEntry": {
+            "type": "object",
+            "required": [
+                "path"
+            ],
+            "properties": {
+                "hash": {
+                    "description": "Hex encoded SHA-256 of the content, not set for symlinks",
+                    "type": "string"
+                },
+                "linkTarget": {
+                    "description": "Target of a symlink",
+                    "type": "string"
+                },
+                "mode": {
+                    "description": "Octal permission mode, e.g. 0644",
+                    "type": "string"
+                },
+                "path": {
+                    "description": "Slash separated path relative to the manifest root",
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
         "Match": {
             "type": "object",
             "required": [
+                "column",
                 "content",
+                "endColumn",
                 "file",
                 "line"
             ],
             "properties": {
+                "column": {
+                    "description": "1-based byte offset of the first match on the line",
+                    "type": "integer"
+                },
                 "content": {
                     "type": "string"
                 },
+                "contextAfter": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "contextBefore": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "endColumn": {
+                    "description": "1-based byte offset just past the end of the first match",
+                    "type": "integer"
+                },
                 "file": {
                     "type": "string"
                 },
@@ -3295,6 +5915,39 @@ const docTemplate = `{
                 }
             }
         },
+        "MissingBlobsRequest": {
+            "type": "object",
+            "required": [
+                "path"
+            ],
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ManifestEntry"
+                    }
+                },
+                "path": {
+                    "description": "Directory the manifest would be materialized into",
+                    "type": "string"
+                }
+            }
+        },
+        "MissingBlobsResponse": {
+            "type": "object",
+            "required": [
+                "missing"
+            ],
+            "properties": {
+                "missing": {
+                    "description": "Hashes of the blobs that have to be uploaded",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
         "MouseClickRequest": {
             "type": "object",
             "properties": {
@@ -3395,6 +6048,129 @@ const docTemplate = `{
                 }
             }
         },
+        "PatchHunkResult": {
+            "type": "object",
+            "required": [
+                "applied",
+                "fuzz",
+                "hunk",
+                "line",
+                "offset"
+            ],
+            "properties": {
+                "actual": {
+                    "description": "Lines found at the expected position, for hunks that did not apply",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "applied": {
+                    "type": "boolean"
+                },
+                "expected": {
+                    "description": "Lines the hunk expected to find, for hunks that did not apply",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "fuzz": {
+                    "description": "Number of context lines ignored at each end of the hunk",
+                    "type": "integer"
+                },
+                "hunk": {
+                    "description": "1-based index of the hunk within its file",
+                    "type": "integer"
+                },
+                "line": {
+                    "description": "1-based line where the hunk matched, or where it was expected",
+                    "type": "integer"
+                },
+                "offset": {
+                    "description": "Distance in lines from the position in the hunk header",
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "PatchRequest": {
+            "type": "object",
+            "required": [
+                "patch"
+            ],
+            "properties": {
+                "dryRun": {
+                    "description": "Check the patch and return the resulting diffs without writing any file",
+                    "type": "boolean"
+                },
+                "fuzz": {
+                    "description": "Maximum number of context lines ignored at each end of a hunk that does not match exactly (default: 2)",
+                    "type": "integer"
+                },
+                "patch": {
+                    "description": "Unified diff, may contain several files",
+                    "type": "string"
+                },
+                "path": {
+                    "description": "Directory relative file names in the patch are resolved against, defaults to the working directory",
+                    "type": "string"
+                },
+                "strip": {
+                    "description": "Number of leading path components to strip from file names, like patch -p. Defaults to 1 when all names carry git style a/ and b/ prefixes, 0 otherwise",
+                    "type": "integer"
+                }
+            }
+        },
+        "PatchResponse": {
+            "type": "object",
+            "required": [
+                "applied",
+                "files"
+            ],
+            "properties": {
+                "applied": {
+                    "description": "Whether the changes were written, false for dry runs and conflicts",
+                    "type": "boolean"
+                },
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/PatchResult"
+                    }
+                }
+            }
+        },
+        "PatchResult": {
+            "type": "object",
+            "properties": {
+                "diff": {
+                    "description": "Unified diff of the change, only set for dry runs",
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "file": {
+                    "type": "string"
+                },
+                "hunks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/PatchHunkResult"
+                    }
+                },
+                "operation": {
+                    "description": "One of create, modify, delete or rename",
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
         "PortList": {
             "type": "object",
             "properties": {
@@ -3428,6 +6204,59 @@ const docTemplate = `{
                 }
             }
         },
+        "ProcessInfo": {
+            "type": "object",
+            "required": [
+                "cmdline",
+                "cpuPercent",
+                "name",
+                "pgid",
+                "pid",
+                "ppid",
+                "rss",
+                "startTime",
+                "user"
+            ],
+            "properties": {
+                "children": {
+                    "description": "Child processes, only set when the tree is requested",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ProcessInfo"
+                    }
+                },
+                "cmdline": {
+                    "type": "string"
+                },
+                "cpuPercent": {
+                    "description": "CPU usage in percent of one core, averaged over the lifetime of the process",
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "pgid": {
+                    "description": "Process group ID",
+                    "type": "integer"
+                },
+                "pid": {
+                    "type": "integer"
+                },
+                "ppid": {
+                    "type": "integer"
+                },
+                "rss": {
+                    "description": "Resident set size in bytes",
+                    "type": "integer"
+                },
+                "startTime": {
+                    "type": "string"
+                },
+                "user": {
+                    "type": "string"
+                }
+            }
+        },
         "ProcessLogsResponse": {
             "type": "object",
             "properties": {
@@ -3500,6 +6329,14 @@ const docTemplate = `{
                     "description": "Don't start PTY until first client connects",
                     "type": "boolean"
                 },
+                "limits": {
+                    "description": "Resource limits of the shell and its children, needs cgroup v2",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/ResourceLimits"
+                        }
+                    ]
+                },
                 "rows": {
                     "type": "integer"
                 }
@@ -3567,11 +6404,30 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
-                "lazyStart": {
-                    "description": "Whether this session uses lazy start",
-                    "type": "boolean"
-                },
-                "rows": {
+                "lazyStart": {
+                    "description": "Whether this session uses lazy start",
+                    "type": "boolean"
+                },
+                "limits": {
+                    "$ref": "#/definitions/ResourceLimits"
+                },
+                "rows": {
+                    "type": "integer"
+                }
+            }
+        },
+        "ReadinessProbe": {
+            "type": "object",
+            "required": [
+                "port"
+            ],
+            "properties": {
+                "path": {
+                    "description": "HTTP path requested on the port, any status below 400 means ready.\nWithout a path accepting TCP connections is enough",
+                    "type": "string"
+                },
+                "port": {
+                    "description": "Port on localhost the service listens on once it is ready",
                     "type": "integer"
                 }
             }
@@ -3584,34 +6440,121 @@ const docTemplate = `{
                 "pattern"
             ],
             "properties": {
+                "dryRun": {
+                    "description": "Compute the changes and return a diff without writing any file",
+                    "type": "boolean"
+                },
                 "files": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
+                "maxReplacements": {
+                    "description": "Maximum number of replacements per file, unlimited when not set",
+                    "type": "integer"
+                },
                 "newValue": {
                     "type": "string"
                 },
                 "pattern": {
                     "type": "string"
+                },
+                "regex": {
+                    "description": "Treat pattern as a regular expression; newValue may reference groups as $1 or ${name}",
+                    "type": "boolean"
                 }
             }
         },
         "ReplaceResult": {
             "type": "object",
             "properties": {
+                "diff": {
+                    "description": "Unified diff of the change, only set for dry runs",
+                    "type": "string"
+                },
                 "error": {
                     "type": "string"
                 },
                 "file": {
                     "type": "string"
                 },
+                "replacements": {
+                    "type": "integer"
+                },
                 "success": {
                     "type": "boolean"
                 }
             }
         },
+        "ResourceLimits": {
+            "type": "object",
+            "properties": {
+                "cpus": {
+                    "description": "CPU time in cores, 0.5 allows half of one core",
+                    "type": "number"
+                },
+                "memoryBytes": {
+                    "description": "Maximum memory in bytes, page cache included. Processes exceeding it\nare killed by the OOM killer",
+                    "type": "integer"
+                },
+                "pids": {
+                    "description": "Maximum number of processes and threads",
+                    "type": "integer"
+                }
+            }
+        },
+        "RestartPolicy": {
+            "type": "string",
+            "enum": [
+                "always",
+                "on-failure",
+                "never"
+            ],
+            "x-enum-varnames": [
+                "RestartAlways",
+                "RestartOnFailure",
+                "RestartNever"
+            ]
+        },
+        "RestoreCheckpointRequest": {
+            "type": "object",
+            "properties": {
+                "files": {
+                    "description": "Paths to restore, relative to the checkpoint path. Directories include\neverything below them. Restores the whole checkpoint when empty.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "keepExtra": {
+                    "description": "Keep files that were created after the checkpoint instead of removing them",
+                    "type": "boolean"
+                }
+            }
+        },
+        "RestoreCheckpointResponse": {
+            "type": "object",
+            "required": [
+                "deleted",
+                "restored",
+                "unchanged"
+            ],
+            "properties": {
+                "deleted": {
+                    "description": "Number of files and directories that were removed",
+                    "type": "integer"
+                },
+                "restored": {
+                    "description": "Number of files, symlinks and directories that were written",
+                    "type": "integer"
+                },
+                "unchanged": {
+                    "description": "Number of entries that already matched the checkpoint",
+                    "type": "integer"
+                }
+            }
+        },
         "ScreenshotResponse": {
             "type": "object",
             "properties": {
@@ -3648,21 +6591,177 @@ const docTemplate = `{
                 }
             }
         },
+        "Service": {
+            "type": "object",
+            "required": [
+                "name",
+                "ready",
+                "restarts",
+                "status"
+            ],
+            "properties": {
+                "argv": {
+                    "description": "Program and arguments, passed on without any parsing",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "command": {
+                    "description": "Command line run through the user's shell. Either command or argv is\nrequired",
+                    "type": "string"
+                },
+                "cwd": {
+                    "description": "Current working directory",
+                    "type": "string"
+                },
+                "envs": {
+                    "description": "Environment variables set in addition to the daemon's environment",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "exitCode": {
+                    "description": "Exit code of the last run",
+                    "type": "integer"
+                },
+                "exitedAt": {
+                    "description": "When the last run exited",
+                    "type": "string"
+                },
+                "limits": {
+                    "description": "Resource limits of each run of the service, needs cgroup v2",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/ResourceLimits"
+                        }
+                    ]
+                },
+                "maxRestarts": {
+                    "description": "Restarts in a row before giving up, unlimited when unset. The count\nresets once the service stays up for a minute",
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Letters, digits, dots, dashes and underscores",
+                    "type": "string"
+                },
+                "pid": {
+                    "type": "integer"
+                },
+                "readiness": {
+                    "$ref": "#/definitions/ReadinessProbe"
+                },
+                "ready": {
+                    "description": "Whether the readiness probe succeeded, always set for a running\nservice without probe",
+                    "type": "boolean"
+                },
+                "restart": {
+                    "description": "always, on-failure or never, defaults to on-failure",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/RestartPolicy"
+                        }
+                    ]
+                },
+                "restarts": {
+                    "description": "Restarts since the service was created or last started",
+                    "type": "integer"
+                },
+                "startedAt": {
+                    "description": "When the current or last run started",
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/ServiceStatus"
+                }
+            }
+        },
+        "ServiceStatus": {
+            "type": "string",
+            "enum": [
+                "starting",
+                "running",
+                "backoff",
+                "stopped",
+                "exited",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "ServiceStatusStarting",
+                "ServiceStatusRunning",
+                "ServiceStatusBackoff",
+                "ServiceStatusStopped",
+                "ServiceStatusExited",
+                "ServiceStatusFailed"
+            ]
+        },
         "Session": {
             "type": "object",
             "required": [
+                "active",
                 "commands",
+                "createdAt",
                 "sessionId"
             ],
             "properties": {
+                "active": {
+                    "description": "Whether the shell of the session is running. Sessions restored after\na restart of the daemon are not, only their commands can be queried",
+                    "type": "boolean"
+                },
                 "commands": {
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/Command"
                     }
                 },
+                "createdAt": {
+                    "type": "string"
+                },
+                "cwd": {
+                    "description": "Working directory of the shell after the last command",
+                    "type": "string"
+                },
+                "envs": {
+                    "description": "Environment variables exported by the shell after the last command",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "limits": {
+                    "$ref": "#/definitions/ResourceLimits"
+                },
                 "sessionId": {
                     "type": "string"
+                },
+                "shell": {
+                    "type": "string"
+                }
+            }
+        },
+        "SessionCommandInputRequest": {
+            "type": "object",
+            "properties": {
+                "close": {
+                    "description": "Close the standard input after writing data, so the command reads EOF",
+                    "type": "boolean"
+                },
+                "data": {
+                    "description": "Written to the standard input of the command as is",
+                    "type": "string"
+                }
+            }
+        },
+        "SessionCommandSignalRequest": {
+            "type": "object",
+            "required": [
+                "signal"
+            ],
+            "properties": {
+                "signal": {
+                    "description": "SIGINT, SIGTERM or SIGKILL, the SIG prefix is optional",
+                    "type": "string"
                 }
             }
         },
@@ -3679,6 +6778,7 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "runAsync": {
+                    "description": "Return the command ID right away instead of waiting for the command to\nexit. Only async commands can be sent input, the stdin of a synchronous\ncommand is closed as soon as it starts.",
                     "type": "boolean"
                 }
             }
@@ -3692,6 +6792,10 @@ const docTemplate = `{
                 "exitCode": {
                     "type": "integer"
                 },
+                "oomKilled": {
+                    "description": "Set when the OOM killer killed a process of the session while the\ncommand ran",
+                    "type": "boolean"
+                },
                 "output": {
                     "type": "string"
                 },
@@ -3703,6 +6807,22 @@ const docTemplate = `{
                 }
             }
         },
+        "SignalRequest": {
+            "type": "object",
+            "required": [
+                "signal"
+            ],
+            "properties": {
+                "group": {
+                    "description": "Signal the whole process group of the process",
+                    "type": "boolean"
+                },
+                "signal": {
+                    "description": "SIGINT, SIGTERM or SIGKILL, the SIG prefix is optional",
+                    "type": "string"
+                }
+            }
+        },
         "Status": {
             "type": "string",
             "enum": [
@@ -3726,6 +6846,141 @@ const docTemplate = `{
                 "UpdatedButUnmerged"
             ]
         },
+        "SubmitJobRequest": {
+            "type": "object",
+            "properties": {
+                "argv": {
+                    "description": "Program and arguments, passed on without any parsing",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "command": {
+                    "description": "Command line run through the user's shell. Either command or argv is\nrequired",
+                    "type": "string"
+                },
+                "cwd": {
+                    "description": "Current working directory",
+                    "type": "string"
+                },
+                "envs": {
+                    "description": "Environment variables set in addition to the daemon's environment",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "limits": {
+                    "description": "Resource limits of the job and its children, needs cgroup v2",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/ResourceLimits"
+                        }
+                    ]
+                },
+                "priority": {
+                    "description": "Jobs with a higher priority are started first, jobs of the same\npriority in the order they were submitted. Defaults to 0",
+                    "type": "integer"
+                },
+                "timeout": {
+                    "description": "Timeout in seconds once the job is running, unlimited when unset",
+                    "type": "integer"
+                }
+            }
+        },
+        "SyncResponse": {
+            "type": "object",
+            "required": [
+                "deleted",
+                "path",
+                "unchanged",
+                "written"
+            ],
+            "properties": {
+                "deleted": {
+                    "description": "Number of files and directories that were removed",
+                    "type": "integer"
+                },
+                "missing": {
+                    "description": "Hashes of blobs that were neither uploaded nor found on disk",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "path": {
+                    "type": "string"
+                },
+                "unchanged": {
+                    "description": "Number of entries that were already up to date",
+                    "type": "integer"
+                },
+                "written": {
+                    "description": "Number of files and symlinks that were written",
+                    "type": "integer"
+                }
+            }
+        },
+        "TrashItem": {
+            "type": "object",
+            "required": [
+                "deletedAt",
+                "id",
+                "isDir",
+                "originalPath",
+                "size"
+            ],
+            "properties": {
+                "deletedAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "isDir": {
+                    "type": "boolean"
+                },
+                "originalPath": {
+                    "type": "string"
+                },
+                "size": {
+                    "description": "Size of the file, or the total size of the files below a directory",
+                    "type": "integer"
+                }
+            }
+        },
+        "UploadSession": {
+            "type": "object",
+            "required": [
+                "createdAt",
+                "offset",
+                "path",
+                "updatedAt",
+                "uploadId"
+            ],
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "offset": {
+                    "description": "Number of bytes received so far, the next chunk must start here",
+                    "type": "integer"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "uploadId": {
+                    "type": "string"
+                }
+            }
+        },
         "UserHomeDirResponse": {
             "type": "object",
             "properties": {
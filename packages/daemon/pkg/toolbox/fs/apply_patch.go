@@ -0,0 +1,344 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/diff"
+	"github.com/cofy-x/deck/apps/daemon/pkg/workspace"
+	"github.com/gin-gonic/gin"
+
+	"github.com/cofy-x/deck/packages/core-go/pkg/log"
+)
+
+// Patch operations
+const (
+	PatchOperationCreate = "create"
+	PatchOperationModify = "modify"
+	PatchOperationDelete = "delete"
+	PatchOperationRename = "rename"
+)
+
+// ApplyPatch godoc
+//
+//	@Summary		Apply a unified diff
+//	@Description	Apply a unified diff touching one or more files. Hunks are matched at an offset or with fuzz when the file has drifted. Either every file is patched or none is; on conflicts 409 is returned with the failing hunks. With dryRun set, nothing is written and the resulting diff of each file is returned.
+//	@Tags			file-system
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		PatchRequest	true	"Patch request"
+//	@Success		200		{object}	PatchResponse
+//	@Failure		409		{object}	PatchResponse
+//	@Router			/files/patch [post]
+//
+//	@id				ApplyPatch
+func ApplyPatch(c *gin.Context) {
+	var req PatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	filePatches, err := diff.Parse(req.Patch)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid patch: %w", err))
+		return
+	}
+
+	fuzz := diff.DefaultFuzz
+	if req.Fuzz != nil {
+		if *req.Fuzz < 0 {
+			c.AbortWithError(http.StatusBadRequest, errors.New("fuzz must not be negative"))
+			return
+		}
+		fuzz = *req.Fuzz
+	}
+
+	strip := defaultStrip(filePatches)
+	if req.Strip != nil {
+		if *req.Strip < 0 {
+			c.AbortWithError(http.StatusBadRequest, errors.New("strip must not be negative"))
+			return
+		}
+		strip = *req.Strip
+	}
+
+	base := ""
+	if req.Path != nil {
+		base = *req.Path
+	}
+	base, ok := checkPath(c, base)
+	if !ok {
+		return
+	}
+	base, err = filepath.Abs(base)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid path: %w", err))
+		return
+	}
+
+	changes := make([]*patchChange, 0, len(filePatches))
+	results := make([]PatchResult, len(filePatches))
+	targets := map[string]bool{}
+	failed := false
+
+	for i, fp := range filePatches {
+		change, err := preparePatch(fp, base, strip, fuzz, &results[i])
+		if errors.Is(err, workspace.ErrOutsideRoots) {
+			c.AbortWithError(http.StatusForbidden, err)
+			return
+		}
+		if err == nil {
+			for _, path := range []string{change.source, change.target} {
+				if path != "" && targets[path] {
+					err = fmt.Errorf("%s is patched more than once", path)
+				}
+			}
+		}
+		if err != nil {
+			results[i].Error = err.Error()
+			failed = true
+			continue
+		}
+
+		results[i].Success = true
+		for _, path := range []string{change.source, change.target} {
+			if path != "" {
+				targets[path] = true
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	if failed {
+		c.AbortWithStatusJSON(http.StatusConflict, PatchResponse{Applied: false, Files: results})
+		return
+	}
+
+	if req.DryRun {
+		for i, change := range changes {
+			patch := diff.Unified(change.name(change.source), change.name(change.target), change.oldContent, change.newContent)
+			results[i].Diff = &patch
+		}
+		c.JSON(http.StatusOK, PatchResponse{Applied: false, Files: results})
+		return
+	}
+
+	if err := commitPatch(changes); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to apply patch: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, PatchResponse{Applied: true, Files: results})
+}
+
+// patchChange is the outcome of applying a file patch in memory.
+type patchChange struct {
+	op string
+	// file the original content is read from, empty for creates
+	source string
+	// file the new content is written to, empty for deletes
+	target string
+	// stat of source, nil for creates
+	info       os.FileInfo
+	oldContent string
+	newContent string
+
+	// staged new content and whether the change has been committed
+	tmpPath   string
+	committed bool
+}
+
+// name returns path, or /dev/null for the missing side of a create or
+// delete.
+func (c *patchChange) name(path string) string {
+	if path == "" {
+		return diff.DevNull
+	}
+	return path
+}
+
+// preparePatch applies fp in memory and fills in result.
+func preparePatch(fp *diff.FilePatch, base string, strip, fuzz int, result *PatchResult) (*patchChange, error) {
+	change := &patchChange{}
+
+	var err error
+	if !fp.IsCreate() {
+		if change.source, err = patchPath(fp.OldName, base, strip); err != nil {
+			return nil, err
+		}
+	}
+	if !fp.IsDelete() {
+		if change.target, err = patchPath(fp.NewName, base, strip); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case fp.IsCreate():
+		change.op = PatchOperationCreate
+		result.File = change.target
+	case fp.IsDelete():
+		change.op = PatchOperationDelete
+		result.File = change.source
+	case change.source == change.target:
+		change.op = PatchOperationModify
+		result.File = change.target
+		// the staged content is renamed over the file a symlink points to
+		if change.target, err = writeTarget(change.target); err != nil {
+			return nil, err
+		}
+	default:
+		change.op = PatchOperationRename
+		result.File = change.target
+	}
+	result.Operation = change.op
+	result.Hunks = []PatchHunkResult{}
+
+	if change.source != "" {
+		info, err := os.Stat(change.source)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			return nil, fmt.Errorf("%s is a directory", change.source)
+		}
+		content, err := os.ReadFile(change.source)
+		if err != nil {
+			return nil, err
+		}
+		change.info = info
+		change.oldContent = string(content)
+	}
+
+	if change.op == PatchOperationCreate || change.op == PatchOperationRename {
+		if _, err := os.Lstat(change.target); err == nil {
+			return nil, fmt.Errorf("%s already exists", change.target)
+		}
+	}
+
+	newContent, hunks, applyErr := diff.Apply(change.oldContent, fp.Hunks, fuzz)
+
+	failedHunks := 0
+	for i, h := range hunks {
+		result.Hunks = append(result.Hunks, PatchHunkResult{
+			Hunk:     i + 1,
+			Applied:  h.Applied,
+			Line:     h.Line,
+			Offset:   h.Offset,
+			Fuzz:     h.Fuzz,
+			Reason:   h.Reason,
+			Expected: h.Expected,
+			Actual:   h.Actual,
+		})
+		if !h.Applied {
+			failedHunks++
+		}
+	}
+	if applyErr != nil {
+		return nil, fmt.Errorf("%d of %d hunks failed", failedHunks, len(hunks))
+	}
+
+	if change.op == PatchOperationDelete && newContent != "" {
+		return nil, errors.New("file is not empty after applying the patch")
+	}
+	change.newContent = newContent
+
+	return change, nil
+}
+
+// commitPatch writes all changes. Content is staged next to each target
+// first, so a failure while staging leaves every file untouched. A failure
+// while moving the staged files into place rolls back the changes made so
+// far.
+func commitPatch(changes []*patchChange) error {
+	defer func() {
+		for _, change := range changes {
+			if change.tmpPath != "" && !change.committed {
+				os.Remove(change.tmpPath)
+			}
+		}
+	}()
+
+	for _, change := range changes {
+		if change.target == "" {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(change.target), 0755); err != nil {
+			return err
+		}
+		tmpPath, err := stageFile(change.target, []byte(change.newContent), change.info)
+		if err != nil {
+			return err
+		}
+		change.tmpPath = tmpPath
+	}
+
+	for i, change := range changes {
+		var err error
+		if change.target != "" {
+			err = os.Rename(change.tmpPath, change.target)
+		}
+		if err == nil && change.op != PatchOperationCreate && change.op != PatchOperationModify {
+			err = os.Remove(change.source)
+		}
+		if err != nil {
+			rollbackPatch(changes[:i])
+			return err
+		}
+		change.committed = true
+	}
+	return nil
+}
+
+// rollbackPatch restores the original state of committed changes.
+func rollbackPatch(changes []*patchChange) {
+	for _, change := range changes {
+		var err error
+		switch change.op {
+		case PatchOperationCreate:
+			err = os.Remove(change.target)
+		case PatchOperationModify, PatchOperationDelete:
+			err = writeFileAtomic(change.source, []byte(change.oldContent), change.info)
+		case PatchOperationRename:
+			if err = writeFileAtomic(change.source, []byte(change.oldContent), change.info); err == nil {
+				err = os.Remove(change.target)
+			}
+		}
+		if err != nil {
+			log.Errorf("Failed to roll back %s of %s: %v", change.op, change.name(change.source), err)
+		}
+	}
+}
+
+// defaultStrip returns 1 when every file name carries a git style a/ or b/
+// prefix and 0 otherwise.
+func defaultStrip(filePatches []*diff.FilePatch) int {
+	for _, fp := range filePatches {
+		if !fp.IsCreate() && !strings.HasPrefix(fp.OldName, "a/") {
+			return 0
+		}
+		if !fp.IsDelete() && !strings.HasPrefix(fp.NewName, "b/") {
+			return 0
+		}
+	}
+	return 1
+}
+
+// patchPath strips the leading components of a patch file name and
+// resolves it against base.
+func patchPath(name, base string, strip int) (string, error) {
+	parts := strings.Split(name, "/")
+	if strip >= len(parts) {
+		return "", fmt.Errorf("cannot strip %d components from %s", strip, name)
+	}
+	path := filepath.FromSlash(strings.Join(parts[strip:], "/"))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+	return workspace.Check(filepath.Clean(path))
+}
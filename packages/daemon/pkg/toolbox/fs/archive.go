@@ -0,0 +1,233 @@
+package fs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cofy-x/deck/packages/core-go/pkg/log"
+)
+
+// Archive formats
+const (
+	ArchiveFormatTarGz = "tar.gz"
+	ArchiveFormatZip   = "zip"
+)
+
+// ArchiveFiles godoc
+//
+//	@Summary		Download a directory as an archive
+//	@Description	Stream a tar.gz or zip archive of a file or directory. Entry names are relative to the archived directory. Symlinks are stored as links and are not followed. Files ignored by .gitignore and the .git directory are skipped unless noIgnore is set.
+//	@Tags			file-system
+//	@Produce		octet-stream
+//	@Param			path		query	string		true	"File or directory to archive"
+//	@Param			format		query	string		false	"Archive format, tar.gz (default) or zip"
+//	@Param			include		query	[]string	false	"Only archive files matching these globs"			collectionFormat(multi)
+//	@Param			exclude		query	[]string	false	"Skip files and directories matching these globs"	collectionFormat(multi)
+//	@Param			noIgnore	query	boolean		false	"Do not respect .gitignore files"
+//	@Success		200			{file}	binary
+//	@Router			/files/archive [get]
+//
+//	@id				ArchiveFiles
+func ArchiveFiles(c *gin.Context) {
+	path := c.Query("path")
+	if path == "" {
+		c.AbortWithError(http.StatusBadRequest, errors.New("path is required"))
+		return
+	}
+
+	path, ok := checkPath(c, path)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", ArchiveFormatTarGz)
+	if format != ArchiveFormatTarGz && format != ArchiveFormatZip {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("unsupported archive format: %s", format))
+		return
+	}
+
+	root := filepath.Clean(path)
+	rootInfo, err := os.Stat(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		if os.IsPermission(err) {
+			c.AbortWithError(http.StatusForbidden, err)
+			return
+		}
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	filter := newWalkFilter(root, splitGlobs(c.QueryArray("include")), splitGlobs(c.QueryArray("exclude")), c.Query("noIgnore") == "true")
+
+	var aw archiveWriter
+	out := &ctxWriter{ctx: c.Request.Context(), w: c.Writer}
+	contentType := "application/gzip"
+	if format == ArchiveFormatZip {
+		aw = newZipArchiveWriter(out)
+		contentType = "application/zip"
+	} else {
+		aw = newTarGzArchiveWriter(out)
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, filepath.Base(root), format))
+	c.Status(http.StatusOK)
+
+	// The status line is already sent at this point, failures can only be
+	// reported by cutting the stream short.
+	if err := writeArchive(aw, root, rootInfo, filter); err != nil {
+		log.Errorf("Failed to archive %s: %v", root, err)
+		return
+	}
+	if err := aw.Close(); err != nil {
+		log.Errorf("Failed to finish archive of %s: %v", root, err)
+	}
+}
+
+// archiveWriter is the common interface of the tar.gz and zip writers.
+type archiveWriter interface {
+	// add writes a single entry. name is slash separated, linkTarget is only
+	// used for symlinks and r only for regular files.
+	add(name string, info os.FileInfo, linkTarget string, r io.Reader) error
+	Close() error
+}
+
+func writeArchive(aw archiveWriter, root string, rootInfo os.FileInfo, filter *walkFilter) error {
+	if !rootInfo.IsDir() {
+		return addArchiveEntry(aw, rootInfo.Name(), root, rootInfo)
+	}
+
+	return filepath.WalkDir(root, func(path string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if filter.skipDir(path) {
+				return filepath.SkipDir
+			}
+			if path == root {
+				return nil
+			}
+		} else if filter.skipFile(path) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		return addArchiveEntry(aw, filepath.ToSlash(rel), path, info)
+	})
+}
+
+func addArchiveEntry(aw archiveWriter, name, path string, info os.FileInfo) error {
+	switch {
+	case info.IsDir():
+		return aw.add(name+"/", info, "", nil)
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		return aw.add(name, info, target, nil)
+	case info.Mode().IsRegular():
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return aw.add(name, info, "", f)
+	default:
+		// devices, sockets and pipes have no portable representation
+		return nil
+	}
+}
+
+type tarGzArchiveWriter struct {
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+func newTarGzArchiveWriter(w io.Writer) *tarGzArchiveWriter {
+	gz := gzip.NewWriter(w)
+	return &tarGzArchiveWriter{gz: gz, tw: tar.NewWriter(gz)}
+}
+
+func (a *tarGzArchiveWriter) add(name string, info os.FileInfo, linkTarget string, r io.Reader) error {
+	hdr, err := tar.FileInfoHeader(info, linkTarget)
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if err := a.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if r != nil {
+		_, err = io.Copy(a.tw, r)
+	}
+	return err
+}
+
+func (a *tarGzArchiveWriter) Close() error {
+	if err := a.tw.Close(); err != nil {
+		return err
+	}
+	return a.gz.Close()
+}
+
+type zipArchiveWriter struct {
+	zw *zip.Writer
+}
+
+func newZipArchiveWriter(w io.Writer) *zipArchiveWriter {
+	return &zipArchiveWriter{zw: zip.NewWriter(w)}
+}
+
+func (a *zipArchiveWriter) add(name string, info os.FileInfo, linkTarget string, r io.Reader) error {
+	hdr, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if info.Mode().IsRegular() {
+		hdr.Method = zip.Deflate
+	}
+
+	w, err := a.zw.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case linkTarget != "":
+		// zip stores the symlink target as the entry content
+		_, err = io.WriteString(w, linkTarget)
+	case r != nil:
+		_, err = io.Copy(w, r)
+	}
+	return err
+}
+
+func (a *zipArchiveWriter) Close() error {
+	return a.zw.Close()
+}
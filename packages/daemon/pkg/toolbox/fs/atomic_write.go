@@ -0,0 +1,93 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/workspace"
+)
+
+// writeFileAtomic replaces the contents of path by writing to a temporary
+// file in the same directory and renaming it over the original. The mode and,
+// where permitted, the ownership of the original file are preserved. A nil
+// info creates the file with mode 0644. When path is a symlink, the file it
+// points to is replaced and the link kept.
+func writeFileAtomic(path string, data []byte, info os.FileInfo) error {
+	path, err := writeTarget(path)
+	if err != nil {
+		return err
+	}
+
+	tmpPath, err := stageFile(path, data, info)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// writeTarget returns the file that replacing path has to rename over. A
+// symlink is written through like os.WriteFile does, so its target, which
+// has to lie inside the workspace roots as well, is returned instead of the
+// link.
+func writeTarget(path string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		if _, lstatErr := os.Lstat(path); os.IsNotExist(lstatErr) {
+			// a new file
+			return path, nil
+		}
+		return "", err
+	}
+	return workspace.Check(resolved)
+}
+
+// stageFile writes data to a temporary file next to path, with the mode and
+// ownership writeFileAtomic would give it, and returns the temporary path.
+// The caller renames it into place or removes it.
+func stageFile(path string, data []byte, info os.FileInfo) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	tmpPath := tmp.Name()
+
+	cleanup := func(err error) error {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		return "", cleanup(err)
+	}
+
+	mode := os.FileMode(0644)
+	if info != nil {
+		mode = info.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		return "", cleanup(err)
+	}
+
+	if info != nil {
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			// best effort, only root may hand files to other users
+			_ = tmp.Chown(int(stat.Uid), int(stat.Gid))
+		}
+	}
+
+	if err := tmp.Sync(); err != nil {
+		return "", cleanup(err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	return tmpPath, nil
+}
@@ -0,0 +1,38 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomicThroughSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.txt")
+	link := filepath.Join(dir, "link.txt")
+	if err := os.WriteFile(target, []byte("old\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("target.txt", link); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(link, []byte("new\n"), info); err != nil {
+		t.Fatal(err)
+	}
+
+	if linkInfo, err := os.Lstat(link); err != nil || linkInfo.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("expected the link to be kept, got %v, %v", linkInfo, err)
+	}
+	content, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "new\n" {
+		t.Fatalf("expected the target to be written, got %q", content)
+	}
+}
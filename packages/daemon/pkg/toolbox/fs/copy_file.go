@@ -0,0 +1,290 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/fsutil"
+)
+
+var errDestinationExists = errors.New("destination already exists")
+
+// CopyFile godoc
+//
+//	@Summary		Copy a file or directory
+//	@Description	Copy a file, or a directory with everything below it, to destination. Symlinks below a copied directory are copied as links. Files and directories keep the permission bits of the source, like cp does. With overwrite, existing files and symlinks are replaced and directories are merged.
+//	@Tags			file-system
+//	@Param			source			query	string	true	"Source file or directory path"
+//	@Param			destination		query	string	true	"Destination file or directory path"
+//	@Param			recursive		query	boolean	false	"Enable copying directories"
+//	@Param			overwrite		query	boolean	false	"Replace existing files at the destination"
+//	@Param			preserveMode	query	boolean	false	"Also keep the modification times and the setuid, setgid and sticky bits of the source"
+//	@Success		200
+//	@Router			/files/copy [post]
+//
+//	@id				CopyFile
+func CopyFile(c *gin.Context) {
+	sourcePath := c.Query("source")
+	destPath := c.Query("destination")
+
+	if sourcePath == "" || destPath == "" {
+		c.AbortWithError(http.StatusBadRequest, errors.New("source and destination paths are required"))
+		return
+	}
+
+	sourcePath, ok := checkPath(c, sourcePath)
+	if !ok {
+		return
+	}
+
+	destPath, ok = checkPath(c, destPath)
+	if !ok {
+		return
+	}
+
+	absSourcePath, err := filepath.Abs(sourcePath)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, errors.New("invalid source path"))
+		return
+	}
+
+	absDestPath, err := filepath.Abs(destPath)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, errors.New("invalid destination path"))
+		return
+	}
+
+	sourceInfo, err := os.Stat(absSourcePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		if os.IsPermission(err) {
+			c.AbortWithError(http.StatusForbidden, err)
+			return
+		}
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	if sourceInfo.IsDir() {
+		if c.Query("recursive") != "true" {
+			c.AbortWithError(http.StatusBadRequest, errors.New("recursive is required to copy a directory"))
+			return
+		}
+
+		// compare the real locations, either side may be reached through a symlink
+		realSource, err := filepath.EvalSymlinks(absSourcePath)
+		if err == nil {
+			realDest := absDestPath
+			if resolved, err := filepath.EvalSymlinks(filepath.Dir(absDestPath)); err == nil {
+				realDest = filepath.Join(resolved, filepath.Base(absDestPath))
+			}
+			if fsutil.Within(realSource, realDest) {
+				c.AbortWithError(http.StatusBadRequest, errors.New("cannot copy a directory into itself"))
+				return
+			}
+		}
+	}
+
+	if _, err := os.Stat(filepath.Dir(absDestPath)); err != nil {
+		if os.IsNotExist(err) {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		if os.IsPermission(err) {
+			c.AbortWithError(http.StatusForbidden, err)
+			return
+		}
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	cp := &copier{
+		overwrite:    c.Query("overwrite") == "true",
+		preserveMode: c.Query("preserveMode") == "true",
+	}
+
+	if sourceInfo.IsDir() {
+		err = cp.copyDir(absSourcePath, absDestPath)
+	} else {
+		err = cp.copyFile(absSourcePath, absDestPath, sourceInfo)
+	}
+	if err != nil {
+		if errors.Is(err, errDestinationExists) {
+			c.AbortWithError(http.StatusConflict, err)
+			return
+		}
+		if os.IsPermission(err) {
+			c.AbortWithError(http.StatusForbidden, err)
+			return
+		}
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("failed to copy: %w", err))
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+type copier struct {
+	overwrite    bool
+	preserveMode bool
+}
+
+func (cp *copier) copyDir(src, dst string) error {
+	// directory modes are applied last, read-only directories could not be
+	// filled otherwise
+	type dirMode struct {
+		path string
+		info os.FileInfo
+	}
+	var dirs []dirMode
+
+	err := filepath.WalkDir(src, func(path string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			if err := cp.mkdir(target); err != nil {
+				return err
+			}
+			dirs = append(dirs, dirMode{target, info})
+			return nil
+		case info.Mode()&os.ModeSymlink != 0:
+			return cp.copySymlink(path, target)
+		case info.Mode().IsRegular():
+			return cp.copyFile(path, target, info)
+		default:
+			// devices, sockets and pipes are not copied
+			return nil
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Chmod(dirs[i].path, cp.mode(dirs[i].info)); err != nil {
+			return err
+		}
+		if cp.preserveMode {
+			_ = os.Chtimes(dirs[i].path, dirs[i].info.ModTime(), dirs[i].info.ModTime())
+		}
+	}
+	return nil
+}
+
+func (cp *copier) mkdir(target string) error {
+	info, err := os.Lstat(target)
+	if err == nil && info.Mode()&os.ModeSymlink != 0 {
+		// files are never copied through a symlink, which may point anywhere;
+		// with overwrite the link is replaced by a real directory
+		if !cp.overwrite {
+			return fmt.Errorf("%w: %s", errDestinationExists, target)
+		}
+		if err := os.Remove(target); err != nil {
+			return err
+		}
+		return os.Mkdir(target, 0755)
+	}
+	if err == nil {
+		if !info.IsDir() {
+			return fmt.Errorf("%w: %s is not a directory", errDestinationExists, target)
+		}
+		if !cp.overwrite {
+			return fmt.Errorf("%w: %s", errDestinationExists, target)
+		}
+		// keep the directory writable while it is filled
+		return os.Chmod(target, info.Mode().Perm()|0700)
+	}
+	if !os.IsNotExist(err) {
+		return err
+	}
+	return os.Mkdir(target, 0755)
+}
+
+// mode returns the mode a copy of a file or directory is given.
+func (cp *copier) mode(info os.FileInfo) os.FileMode {
+	if cp.preserveMode {
+		return info.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+	}
+	return info.Mode().Perm()
+}
+
+// checkTarget makes sure target may be replaced.
+func (cp *copier) checkTarget(target string) error {
+	info, err := os.Lstat(target)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !cp.overwrite {
+		return fmt.Errorf("%w: %s", errDestinationExists, target)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%w: %s is a directory", errDestinationExists, target)
+	}
+	return nil
+}
+
+func (cp *copier) copyFile(src, target string, info os.FileInfo) error {
+	if err := cp.checkTarget(target); err != nil {
+		return err
+	}
+
+	tmp, err := fsutil.StageCopy(target, src, cp.mode(info))
+	if err != nil {
+		return err
+	}
+	if cp.preserveMode {
+		_ = os.Chtimes(tmp, info.ModTime(), info.ModTime())
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func (cp *copier) copySymlink(src, target string) error {
+	if err := cp.checkTarget(target); err != nil {
+		return err
+	}
+
+	linkTarget, err := os.Readlink(src)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := fsutil.StageSymlink(target, linkTarget)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCopierCopyDir(t *testing.T) {
+	src := t.TempDir()
+	writeTestFiles(t, src, map[string]string{
+		"a.txt":     "a",
+		"sub/b.txt": "b",
+	})
+	if err := os.Chmod(filepath.Join(src, "sub", "b.txt"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("a.txt", filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "dst")
+	if err := (&copier{}).copyDir(src, dst); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(dst, "sub", "b.txt")); string(data) != "b" {
+		t.Fatalf("unexpected content %q", data)
+	}
+	// a private file stays private
+	if info, err := os.Stat(filepath.Join(dst, "sub", "b.txt")); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("expected mode 0600, got %v (%v)", info, err)
+	}
+	// symlinks are copied as links, not followed
+	if target, err := os.Readlink(filepath.Join(dst, "link")); err != nil || target != "a.txt" {
+		t.Fatalf("expected link to a.txt, got %q (%v)", target, err)
+	}
+
+	if err := (&copier{}).copyDir(src, dst); !errors.Is(err, errDestinationExists) {
+		t.Fatalf("expected errDestinationExists, got %v", err)
+	}
+
+	writeTestFiles(t, src, map[string]string{"sub/b.txt": "changed"})
+	writeTestFiles(t, dst, map[string]string{"extra.txt": "kept"})
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(src, "sub", "b.txt"), modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&copier{overwrite: true, preserveMode: true}).copyDir(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "sub", "b.txt")); string(data) != "changed" {
+		t.Fatalf("expected overwritten content, got %q", data)
+	}
+	if info, err := os.Stat(filepath.Join(dst, "sub", "b.txt")); err != nil || !info.ModTime().Equal(modTime) {
+		t.Fatalf("expected modification time %v, got %v (%v)", modTime, info, err)
+	}
+	// directories are merged
+	if _, err := os.Stat(filepath.Join(dst, "extra.txt")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCopierCopyDirIntoSymlinkedDirectory(t *testing.T) {
+	src := t.TempDir()
+	writeTestFiles(t, src, map[string]string{"sub/b.txt": "b"})
+
+	dst := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(dst, "sub")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := (&copier{overwrite: true}).copyDir(src, dst); err != nil {
+		t.Fatal(err)
+	}
+
+	// the link is replaced by a directory, nothing is written through it
+	if _, err := os.Stat(filepath.Join(outside, "b.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing to be copied through the link, got %v", err)
+	}
+	if info, err := os.Lstat(filepath.Join(dst, "sub")); err != nil || !info.IsDir() {
+		t.Fatalf("expected sub to be a directory, got %v (%v)", info, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "sub", "b.txt")); string(data) != "b" {
+		t.Fatalf("unexpected content %q", data)
+	}
+}
@@ -28,6 +28,11 @@ func CreateFolder(c *gin.Context) {
 		return
 	}
 
+	path, ok := checkPath(c, path)
+	if !ok {
+		return
+	}
+
 	// Get the permission mode from query params, default to 0755
 	mode := c.Query("mode")
 	var perm os.FileMode = 0755
@@ -0,0 +1,121 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/fsutil"
+	"github.com/cofy-x/deck/apps/daemon/pkg/workspace"
+	"github.com/gin-gonic/gin"
+)
+
+// Link types
+const (
+	LinkTypeSymlink = "symlink"
+	LinkTypeHard    = "hard"
+)
+
+// CreateLink godoc
+//
+//	@Summary		Create a symlink or hard link
+//	@Description	Create a link at path pointing to target. A symlink target is stored as given and may be relative to the directory of the link; it does not have to exist. A hard link target must be an existing file.
+//	@Tags			file-system
+//	@Param			path		query	string	true	"Path of the new link"
+//	@Param			target		query	string	true	"File or directory the link points to"
+//	@Param			type		query	string	false	"Link type, symlink (default) or hard"
+//	@Param			overwrite	query	boolean	false	"Replace an existing file or link at path"
+//	@Success		201
+//	@Router			/files/link [post]
+//
+//	@id				CreateLink
+func CreateLink(c *gin.Context) {
+	path := c.Query("path")
+	target := c.Query("target")
+
+	if path == "" || target == "" {
+		c.AbortWithError(http.StatusBadRequest, errors.New("path and target are required"))
+		return
+	}
+
+	linkType := c.DefaultQuery("type", LinkTypeSymlink)
+	if linkType != LinkTypeSymlink && linkType != LinkTypeHard {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid link type: %s", linkType))
+		return
+	}
+
+	path, ok := checkPathNoFollow(c, path)
+	if !ok {
+		return
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid path: %w", err))
+		return
+	}
+
+	// a relative symlink target is resolved from the directory of the link
+	resolvedTarget := target
+	if !filepath.IsAbs(target) {
+		resolvedTarget = filepath.Join(filepath.Dir(absPath), target)
+	}
+	resolvedTarget, err = workspace.Check(resolvedTarget)
+	if err != nil {
+		c.AbortWithError(http.StatusForbidden, err)
+		return
+	}
+
+	if linkType == LinkTypeHard {
+		info, err := os.Stat(resolvedTarget)
+		if err != nil {
+			if os.IsNotExist(err) {
+				c.AbortWithError(http.StatusNotFound, err)
+				return
+			}
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		if info.IsDir() {
+			c.AbortWithError(http.StatusBadRequest, errors.New("cannot hard link a directory"))
+			return
+		}
+	}
+
+	if info, err := os.Lstat(absPath); err == nil {
+		if info.IsDir() || c.Query("overwrite") != "true" {
+			c.AbortWithError(http.StatusConflict, errDestinationExists)
+			return
+		}
+	}
+
+	// the link is created under a temporary name and renamed into place, so
+	// an existing file is replaced atomically
+	var tmp string
+	if linkType == LinkTypeHard {
+		tmp, err = fsutil.StageHardlink(absPath, resolvedTarget)
+	} else {
+		tmp, err = fsutil.StageSymlink(absPath, target)
+	}
+	if err == nil {
+		if err = os.Rename(tmp, absPath); err != nil {
+			os.Remove(tmp)
+		}
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		if os.IsPermission(err) {
+			c.AbortWithError(http.StatusForbidden, err)
+			return
+		}
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	c.Status(http.StatusCreated)
+}
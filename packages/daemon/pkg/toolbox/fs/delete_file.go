@@ -11,10 +11,11 @@ import (
 // DeleteFile godoc
 //
 //	@Summary		Delete a file or directory
-//	@Description	Delete a file or directory at the specified path
+//	@Description	Delete a file or directory at the specified path. With trash, the path is moved to the trash instead, from where it can be restored until it expires.
 //	@Tags			file-system
 //	@Param			path		query	string	true	"File or directory path to delete"
 //	@Param			recursive	query	boolean	false	"Enable recursive deletion for directories"
+//	@Param			trash		query	boolean	false	"Move to the trash instead of deleting permanently"
 //	@Success		204
 //	@Router			/files [delete]
 //
@@ -26,6 +27,11 @@ func DeleteFile(c *gin.Context) {
 		return
 	}
 
+	path, ok := checkPathNoFollow(c, path)
+	if !ok {
+		return
+	}
+
 	// Check if recursive deletion is requested
 	recursive := c.Query("recursive") == "true"
 
@@ -50,6 +56,23 @@ func DeleteFile(c *gin.Context) {
 		return
 	}
 
+	if c.Query("trash") == "true" {
+		if fileTrash == nil {
+			c.AbortWithError(http.StatusServiceUnavailable, errTrashDisabled)
+			return
+		}
+		if _, err := fileTrash.put(path); err != nil {
+			if os.IsPermission(err) {
+				c.AbortWithError(http.StatusForbidden, err)
+				return
+			}
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+		return
+	}
+
 	var deleteErr error
 	if recursive {
 		deleteErr = os.RemoveAll(path)
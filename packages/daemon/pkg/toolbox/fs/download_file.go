@@ -13,11 +13,14 @@ import (
 // DownloadFile godoc
 //
 //	@Summary		Download a file
-//	@Description	Download a file by providing its path
+//	@Description	Download a file by providing its path. Supports Range requests; If-Range and If-None-Match are matched against the returned ETag.
 //	@Tags			file-system
 //	@Produce		octet-stream
-//	@Param			path	query	string	true	"File path to download"
-//	@Success		200		{file}	binary
+//	@Param			path		query	string	true	"File path to download"
+//	@Param			Range		header	string	false	"Byte range to download, e.g. bytes=0-1023"
+//	@Param			If-Range	header	string	false	"Only honor Range if the file still has this ETag"
+//	@Success		200			{file}	binary
+//	@Success		206			{file}	binary
 //	@Router			/files/download [get]
 //
 //	@id				DownloadFile
@@ -28,6 +31,11 @@ func DownloadFile(c *gin.Context) {
 		return
 	}
 
+	requestedPath, ok := checkPath(c, requestedPath)
+	if !ok {
+		return
+	}
+
 	absPath, err := filepath.Abs(requestedPath)
 	if err != nil {
 		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid path: %w", err))
@@ -60,6 +68,13 @@ func DownloadFile(c *gin.Context) {
 	c.Header("Expires", "0")
 	c.Header("Cache-Control", "must-revalidate")
 	c.Header("Pragma", "public")
+	c.Header("ETag", fileETag(fileInfo))
 
 	c.File(absPath)
 }
+
+// fileETag derives a strong ETag from the size and modification time of a
+// file, which is enough to detect changes between resumed downloads.
+func fileETag(info os.FileInfo) string {
+	return fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size())
+}
@@ -11,6 +11,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/cofy-x/deck/apps/daemon/pkg/workspace"
 	"github.com/gin-gonic/gin"
 )
 
@@ -64,12 +65,19 @@ func DownloadFiles(c *gin.Context) {
 	defer mw.Close() // ensure final boundary is written
 
 	for _, path := range req.Paths {
-		if !fileExists(path) {
+		// parts are named after the requested path
+		checked, err := workspace.Check(path)
+		if err != nil {
+			writeErrorPart(c, mw, path, err.Error())
+			continue
+		}
+
+		if !fileExists(checked) {
 			writeErrorPart(c, mw, path, fmt.Sprintf("file not found or invalid: %s", path))
 			continue
 		}
 
-		f, err := os.Open(path)
+		f, err := os.Open(checked)
 		if err != nil {
 			writeErrorPart(c, mw, path, fmt.Sprintf("error opening file: %v", err))
 			continue
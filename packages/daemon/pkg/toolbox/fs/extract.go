@@ -0,0 +1,473 @@
+package fs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/fsutil"
+)
+
+const (
+	// maxSymlinkTarget bounds the size of symlink targets read from zip
+	// entries.
+	maxSymlinkTarget = 4096
+	// maxSymlinkDepth bounds the symlinks followed to resolve a path, like
+	// the kernel's limit.
+	maxSymlinkDepth = 40
+)
+
+var errUnsafeEntry = errors.New("archive entry escapes the target directory")
+
+// ExtractArchive godoc
+//
+//	@Summary		Extract an archive
+//	@Description	Unpack an uploaded tar, tar.gz or zip archive into the target directory. The format is detected from the content. Entries that would be written outside the target directory, including through symlinks, are rejected. Existing files are overwritten.
+//	@Tags			file-system
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			path	query		string	true	"Directory to extract into, created if missing"
+//	@Param			file	formData	file	true	"Archive to extract"
+//	@Success		200		{object}	ExtractResult
+//	@Router			/files/extract [post]
+//
+//	@id				ExtractArchive
+func ExtractArchive(c *gin.Context) {
+	path := c.Query("path")
+	if path == "" {
+		c.AbortWithError(http.StatusBadRequest, errors.New("path is required"))
+		return
+	}
+
+	path, ok := checkPath(c, path)
+	if !ok {
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	defer file.Close()
+
+	dest, err := filepath.Abs(path)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid path: %w", err))
+		return
+	}
+
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		if os.IsPermission(err) {
+			c.AbortWithError(http.StatusForbidden, err)
+			return
+		}
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	// all containment checks are made against the real location
+	root, err := filepath.EvalSymlinks(dest)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	e := &archiveExtractor{root: root, result: ExtractResult{Path: dest}}
+
+	magic := make([]byte, 512)
+	n, _ := file.ReadAt(magic, 0)
+	magic = magic[:n]
+
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gz, gzErr := gzip.NewReader(file)
+		if gzErr != nil {
+			c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid gzip stream: %w", gzErr))
+			return
+		}
+		defer gz.Close()
+		err = e.extractTar(tar.NewReader(gz))
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")), bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+		zr, zipErr := zip.NewReader(file, fileHeader.Size)
+		if zipErr != nil {
+			c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid zip archive: %w", zipErr))
+			return
+		}
+		err = e.extractZip(zr)
+	case len(magic) >= 262 && string(magic[257:262]) == "ustar":
+		err = e.extractTar(tar.NewReader(file))
+	default:
+		c.AbortWithError(http.StatusBadRequest, errors.New("unsupported archive format"))
+		return
+	}
+
+	if err != nil {
+		if os.IsPermission(err) {
+			c.AbortWithError(http.StatusForbidden, err)
+			return
+		}
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, e.result)
+}
+
+// archiveExtractor writes archive entries below root, refusing any entry
+// that would end up outside of it.
+type archiveExtractor struct {
+	root   string
+	result ExtractResult
+	// the symlinks created so far
+	links []string
+}
+
+func (e *archiveExtractor) extractTar(tr *tar.Reader) error {
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return removeEscapingLinks(e.root, e.links)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid tar archive: %w", err)
+		}
+
+		mode := os.FileMode(hdr.Mode).Perm()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = e.dir(hdr.Name, mode)
+		case tar.TypeReg:
+			err = e.file(hdr.Name, mode, hdr.ModTime, tr)
+		case tar.TypeSymlink:
+			err = e.symlink(hdr.Name, hdr.Linkname)
+		case tar.TypeLink:
+			err = e.hardlink(hdr.Name, hdr.Linkname)
+		default:
+			// devices, fifos and extended headers are not extracted
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", hdr.Name, err)
+		}
+	}
+}
+
+func (e *archiveExtractor) extractZip(zr *zip.Reader) error {
+	for _, f := range zr.File {
+		if err := e.zipEntry(f); err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+	}
+	return removeEscapingLinks(e.root, e.links)
+}
+
+func (e *archiveExtractor) zipEntry(f *zip.File) error {
+	mode := f.Mode()
+	switch {
+	case mode.IsDir():
+		return e.dir(f.Name, mode.Perm())
+	case mode&os.ModeSymlink != 0:
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		target, err := io.ReadAll(io.LimitReader(rc, maxSymlinkTarget))
+		if err != nil {
+			return err
+		}
+		return e.symlink(f.Name, string(target))
+	case mode.IsRegular():
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		return e.file(f.Name, mode.Perm(), f.Modified, rc)
+	default:
+		return nil
+	}
+}
+
+func (e *archiveExtractor) dir(name string, mode os.FileMode) error {
+	target, err := e.target(name)
+	if err != nil || target == e.root {
+		return err
+	}
+	if err := e.checkParents(target); err != nil {
+		return err
+	}
+	// an existing symlink would be followed by the chmod below
+	if info, err := os.Lstat(target); err == nil && !info.IsDir() {
+		return fmt.Errorf("%s exists and is not a directory", target)
+	} else if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	// keep directories writable so their entries can be extracted
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return err
+	}
+	if err := os.Chmod(target, mode|0700); err != nil {
+		return err
+	}
+	e.result.Directories++
+	return nil
+}
+
+func (e *archiveExtractor) file(name string, mode os.FileMode, modTime time.Time, r io.Reader) error {
+	target, err := e.prepare(name)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	_, copyErr := io.Copy(f, r)
+	closeErr := f.Close()
+	if copyErr != nil {
+		return copyErr
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+
+	// the create mode is subject to the umask
+	if err := os.Chmod(target, mode); err != nil {
+		return err
+	}
+	if !modTime.IsZero() {
+		_ = os.Chtimes(target, modTime, modTime)
+	}
+
+	e.result.Files++
+	return nil
+}
+
+func (e *archiveExtractor) symlink(name, linkTarget string) error {
+	if linkTarget == "" || filepath.IsAbs(linkTarget) {
+		return errUnsafeEntry
+	}
+
+	target, err := e.prepare(name)
+	if err != nil {
+		return err
+	}
+	if err := checkLinkTarget(e.root, target, linkTarget, nil); err != nil {
+		return err
+	}
+
+	if err := os.Symlink(linkTarget, target); err != nil {
+		return err
+	}
+	e.links = append(e.links, target)
+	e.result.Symlinks++
+	return nil
+}
+
+func (e *archiveExtractor) hardlink(name, linkName string) error {
+	source, err := e.target(linkName)
+	if err != nil {
+		return err
+	}
+	if err := e.checkParents(source); err != nil {
+		return err
+	}
+	info, err := os.Lstat(source)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return errors.New("hard link target is not a regular file")
+	}
+
+	target, err := e.prepare(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Link(source, target); err != nil {
+		return err
+	}
+	e.result.Files++
+	return nil
+}
+
+// target maps an archive entry name to a path below root.
+func (e *archiveExtractor) target(name string) (string, error) {
+	name = filepath.FromSlash(name)
+	if filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", errUnsafeEntry
+	}
+	target := filepath.Join(e.root, name)
+	if !fsutil.Within(e.root, target) {
+		return "", errUnsafeEntry
+	}
+	return target, nil
+}
+
+// prepare resolves the target of a non-directory entry, creates its parent
+// directories and removes whatever currently occupies the target so that
+// the entry never writes through an existing symlink.
+func (e *archiveExtractor) prepare(name string) (string, error) {
+	target, err := e.target(name)
+	if err != nil {
+		return "", err
+	}
+	if target == e.root {
+		return "", errUnsafeEntry
+	}
+	if err := e.checkParents(target); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", err
+	}
+
+	info, err := os.Lstat(target)
+	if err == nil {
+		if info.IsDir() {
+			return "", fmt.Errorf("%s is a directory", target)
+		}
+		if err := os.Remove(target); err != nil {
+			return "", err
+		}
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+	return target, nil
+}
+
+// checkParents makes sure the closest existing ancestor of path resolves to
+// a location below root. Missing ancestors are created as plain directories
+// afterwards, so they cannot redirect the write.
+func (e *archiveExtractor) checkParents(path string) error {
+	dir := filepath.Dir(path)
+	for {
+		if _, err := os.Lstat(dir); err == nil {
+			break
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		dir = filepath.Dir(dir)
+	}
+
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	if !fsutil.Within(e.root, resolved) {
+		return errUnsafeEntry
+	}
+	return nil
+}
+
+// checkLinkTarget makes sure that a symlink at link pointing to linkTarget
+// resolves to a location below the real directory root, the way the kernel
+// would resolve it given the files currently on disk. links holds symlinks
+// that are about to be created, by their path, and takes precedence over the
+// disk.
+func checkLinkTarget(root, link, linkTarget string, links map[string]string) error {
+	if linkTarget == "" || filepath.IsAbs(linkTarget) {
+		return errUnsafeEntry
+	}
+	rel, err := filepath.Rel(root, filepath.Dir(link))
+	if err != nil {
+		return err
+	}
+	dir, err := resolveIn(root, rel, links, 0)
+	if err != nil {
+		return err
+	}
+	resolved, err := resolveIn(dir, filepath.FromSlash(linkTarget), links, 0)
+	if err != nil {
+		return err
+	}
+	if !fsutil.Within(root, resolved) {
+		return errUnsafeEntry
+	}
+	return nil
+}
+
+// resolveIn resolves the relative path name from the real directory dir,
+// following symlinks one component at a time, so ".." after a symlink leads
+// where the link took it. Missing components are taken as directories.
+func resolveIn(dir, name string, links map[string]string, depth int) (string, error) {
+	if depth > maxSymlinkDepth {
+		return "", errors.New("too many levels of symbolic links")
+	}
+	if filepath.IsAbs(name) {
+		dir = string(filepath.Separator)
+	}
+
+	for _, part := range strings.Split(name, string(filepath.Separator)) {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			dir = filepath.Dir(dir)
+			continue
+		}
+
+		next := filepath.Join(dir, part)
+		linkTarget, isLink := links[next]
+		if !isLink {
+			info, err := os.Lstat(next)
+			if err != nil && !os.IsNotExist(err) {
+				return "", err
+			}
+			if err != nil || info.Mode()&os.ModeSymlink == 0 {
+				dir = next
+				continue
+			}
+			if linkTarget, err = os.Readlink(next); err != nil {
+				return "", err
+			}
+		}
+
+		var err error
+		if dir, err = resolveIn(dir, filepath.FromSlash(linkTarget), links, depth+1); err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+// removeEscapingLinks checks the symlinks at the given paths once they are
+// all in place, since a link may lead through others created after it, and
+// removes those that resolve outside of root.
+func removeEscapingLinks(root string, links []string) error {
+	var unsafe error
+	for _, link := range links {
+		linkTarget, err := os.Readlink(link)
+		if err != nil {
+			// replaced by something else
+			continue
+		}
+		if err := checkLinkTarget(root, link, linkTarget, nil); err != nil {
+			os.Remove(link)
+			if unsafe == nil {
+				unsafe = fmt.Errorf("%s: %w", link, err)
+			}
+		}
+	}
+	return unsafe
+}
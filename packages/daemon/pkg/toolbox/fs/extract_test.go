@@ -0,0 +1,160 @@
+package fs
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type tarEntry struct {
+	name     string
+	typeflag byte
+	linkname string
+	body     string
+}
+
+func buildTar(t *testing.T, entries []tarEntry) *tar.Reader {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Typeflag: e.typeflag, Linkname: e.linkname, Mode: 0644, Size: int64(len(e.body))}
+		if e.typeflag == tar.TypeDir {
+			hdr.Mode = 0755
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return tar.NewReader(&buf)
+}
+
+func TestExtractTar(t *testing.T) {
+	root := t.TempDir()
+	e := &archiveExtractor{root: root}
+
+	err := e.extractTar(buildTar(t, []tarEntry{
+		{name: "dir/", typeflag: tar.TypeDir},
+		{name: "dir/a.txt", typeflag: tar.TypeReg, body: "hello"},
+		{name: "dir/link", typeflag: tar.TypeSymlink, linkname: "a.txt"},
+		{name: "b.txt", typeflag: tar.TypeLink, linkname: "dir/a.txt"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, "dir", "link"))
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("unexpected content through symlink: %q, %v", data, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "b.txt")); string(data) != "hello" {
+		t.Fatalf("unexpected hard link content: %q", data)
+	}
+	if e.result.Files != 2 || e.result.Directories != 1 || e.result.Symlinks != 1 {
+		t.Fatalf("unexpected result: %+v", e.result)
+	}
+}
+
+func TestExtractTarRejectsEscapes(t *testing.T) {
+	tests := map[string][]tarEntry{
+		"parent path":       {{name: "../evil", typeflag: tar.TypeReg, body: "x"}},
+		"nested parent":     {{name: "a/../../evil", typeflag: tar.TypeReg, body: "x"}},
+		"absolute path":     {{name: "/tmp/evil", typeflag: tar.TypeReg, body: "x"}},
+		"absolute symlink":  {{name: "link", typeflag: tar.TypeSymlink, linkname: "/etc"}},
+		"escaping symlink":  {{name: "a/link", typeflag: tar.TypeSymlink, linkname: "../../outside"}},
+		"escaping hardlink": {{name: "link", typeflag: tar.TypeLink, linkname: "../outside"}},
+		"chained symlinks": {
+			{name: "d/", typeflag: tar.TypeDir},
+			{name: "d/up", typeflag: tar.TypeSymlink, linkname: ".."},
+			{name: "x", typeflag: tar.TypeSymlink, linkname: "d/up/.."},
+			{name: "x/", typeflag: tar.TypeDir},
+			{name: "x/pwn/", typeflag: tar.TypeDir},
+		},
+		// x is safe until d/up is replaced
+		"replaced symlink": {
+			{name: "d/", typeflag: tar.TypeDir},
+			{name: "d/up", typeflag: tar.TypeSymlink, linkname: "."},
+			{name: "x", typeflag: tar.TypeSymlink, linkname: "d/up/.."},
+			{name: "d/up", typeflag: tar.TypeSymlink, linkname: ".."},
+		},
+	}
+
+	for name, entries := range tests {
+		t.Run(name, func(t *testing.T) {
+			root := filepath.Join(t.TempDir(), "root")
+			if err := os.Mkdir(root, 0755); err != nil {
+				t.Fatal(err)
+			}
+			e := &archiveExtractor{root: root}
+			if err := e.extractTar(buildTar(t, entries)); !errors.Is(err, errUnsafeEntry) {
+				t.Fatalf("expected unsafe entry error, got %v", err)
+			}
+			if _, err := os.Lstat(filepath.Join(root, "x")); !os.IsNotExist(err) {
+				t.Fatalf("expected the escaping link to be removed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestExtractTarDoesNotWriteThroughExistingSymlink(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "root")
+	outside := filepath.Join(base, "outside")
+	for _, dir := range []string{root, outside} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "out")); err != nil {
+		t.Fatal(err)
+	}
+
+	e := &archiveExtractor{root: root}
+	err := e.extractTar(buildTar(t, []tarEntry{{name: "out/evil", typeflag: tar.TypeReg, body: "x"}}))
+	if !errors.Is(err, errUnsafeEntry) {
+		t.Fatalf("expected unsafe entry error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "evil")); !os.IsNotExist(err) {
+		t.Fatalf("file was written outside the root: %v", err)
+	}
+}
+
+func TestExtractTarDirectoryThroughExistingSymlink(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "root")
+	outside := filepath.Join(base, "outside")
+	for _, dir := range []string{root, outside} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "out")); err != nil {
+		t.Fatal(err)
+	}
+
+	e := &archiveExtractor{root: root}
+	if err := e.extractTar(buildTar(t, []tarEntry{{name: "out/", typeflag: tar.TypeDir}})); err == nil {
+		t.Fatal("expected a directory entry on a symlink to fail")
+	}
+	if info, err := os.Stat(outside); err != nil || info.Mode().Perm() != 0755 {
+		t.Fatalf("the directory outside the root was changed: %v, %v", info, err)
+	}
+
+	err := e.extractTar(buildTar(t, []tarEntry{{name: "out/pwn/", typeflag: tar.TypeDir}}))
+	if !errors.Is(err, errUnsafeEntry) {
+		t.Fatalf("expected unsafe entry error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "pwn")); !os.IsNotExist(err) {
+		t.Fatalf("a directory was created outside the root: %v", err)
+	}
+}
@@ -0,0 +1,270 @@
+package fs
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/common"
+	"github.com/cofy-x/deck/apps/daemon/pkg/fsutil"
+)
+
+// sniffLen is how much of the start of a file is looked at to tell its type,
+// the same amount http.DetectContentType considers.
+const sniffLen = 512
+
+// Text encodings
+const (
+	EncodingASCII   = "ascii"
+	EncodingUTF8    = "utf-8"
+	EncodingUTF8BOM = "utf-8-bom"
+	EncodingUTF16LE = "utf-16le"
+	EncodingUTF16BE = "utf-16be"
+	EncodingUnknown = "unknown"
+)
+
+// Line endings
+const (
+	LineEndingLF    = "lf"
+	LineEndingCRLF  = "crlf"
+	LineEndingCR    = "cr"
+	LineEndingMixed = "mixed"
+	LineEndingNone  = "none"
+)
+
+// readHead reads up to sniffLen bytes from the start of r.
+func readHead(r io.Reader) ([]byte, error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	return head[:n], err
+}
+
+// isBinary reports whether file looks binary judging by its first 512 bytes.
+// Empty and unreadable files are treated as binary.
+func isBinary(file *os.File) bool {
+	head, err := readHead(file)
+	if err != nil || len(head) == 0 {
+		return true
+	}
+	return isBinaryContent(head)
+}
+
+// isBinaryContent reports whether head, the start of a file, contains a NUL
+// byte. UTF-16 text, which is full of them, is recognized by its byte order
+// mark.
+func isBinaryContent(head []byte) bool {
+	switch bomEncoding(head) {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		return false
+	}
+	return bytes.IndexByte(head, 0) >= 0
+}
+
+// bomEncoding returns the encoding announced by the byte order mark at the
+// start of head, if any.
+func bomEncoding(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte{0xef, 0xbb, 0xbf}):
+		return EncodingUTF8BOM
+	case bytes.HasPrefix(head, []byte{0xff, 0xfe}):
+		return EncodingUTF16LE
+	case bytes.HasPrefix(head, []byte{0xfe, 0xff}):
+		return EncodingUTF16BE
+	}
+	return ""
+}
+
+// mimeType sniffs the content type of a file from its first bytes. Content
+// sniffing can't tell one kind of text from another, so the extension is
+// consulted for plain text.
+func mimeType(path string, head []byte) string {
+	sniffed := http.DetectContentType(head)
+	if strings.HasPrefix(sniffed, "text/plain") {
+		if byExt := mime.TypeByExtension(filepath.Ext(path)); byExt != "" {
+			return byExt
+		}
+	}
+	return sniffed
+}
+
+// fileDetails reads the file at path once to describe its content.
+func fileDetails(path string) (*FileDetails, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	head, err := readHead(file)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &FileDetails{
+		MimeType: mimeType(path, head),
+		IsBinary: isBinaryContent(head),
+	}
+
+	content := io.MultiReader(bytes.NewReader(head), file)
+	var stats *textStats
+	if !details.IsBinary {
+		stats = newTextStats(bomEncoding(head))
+		content = io.TeeReader(content, stats)
+	}
+
+	if details.Sha256, err = fsutil.Hash(content); err != nil {
+		return nil, err
+	}
+	if stats != nil {
+		lineCount := stats.lineCount()
+		details.Encoding = stats.encoding()
+		details.LineEnding = stats.lineEnding()
+		details.LineCount = &lineCount
+	}
+	return details, nil
+}
+
+// textStats collects the encoding and line statistics of the text written
+// to it.
+type textStats struct {
+	bom string
+	// the byte order mark is still to be skipped
+	skipBOM bool
+	// bytes of an incomplete UTF-16 code unit or UTF-8 sequence, carried
+	// over to the next write
+	partial []byte
+
+	ascii     bool
+	validUTF8 bool
+
+	lf, crlf, cr int
+	afterCR      bool
+	// the last line has content but no line ending yet
+	lineOpen bool
+}
+
+func newTextStats(bom string) *textStats {
+	return &textStats{bom: bom, skipBOM: bom != "", ascii: true, validUTF8: true}
+}
+
+func (s *textStats) Write(p []byte) (int, error) {
+	data := append(s.partial, p...)
+	s.partial = nil
+
+	if s.skipBOM {
+		size := 2
+		if s.bom == EncodingUTF8BOM {
+			size = 3
+		}
+		if len(data) < size {
+			s.partial = data
+			return len(p), nil
+		}
+		data = data[size:]
+		s.skipBOM = false
+	}
+
+	switch s.bom {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		for ; len(data) >= 2; data = data[2:] {
+			unit := rune(data[0]) | rune(data[1])<<8
+			if s.bom == EncodingUTF16BE {
+				unit = rune(data[0])<<8 | rune(data[1])
+			}
+			s.char(unit)
+		}
+		s.partial = append([]byte(nil), data...)
+	default:
+		// an incomplete sequence at the end is completed by the next write
+		var partial []byte
+		data, partial = common.SplitIncompleteRune(data)
+		s.partial = append([]byte(nil), partial...)
+		if s.validUTF8 && !utf8.Valid(data) {
+			s.validUTF8 = false
+		}
+		for _, b := range data {
+			if b >= utf8.RuneSelf {
+				s.ascii = false
+			}
+			s.char(rune(b))
+		}
+	}
+	return len(p), nil
+}
+
+func (s *textStats) char(c rune) {
+	if s.afterCR {
+		s.afterCR = false
+		if c == '\n' {
+			s.crlf++
+			return
+		}
+		s.cr++
+	}
+
+	switch c {
+	case '\r':
+		s.afterCR = true
+		s.lineOpen = false
+	case '\n':
+		s.lf++
+		s.lineOpen = false
+	default:
+		s.lineOpen = true
+	}
+}
+
+func (s *textStats) endings() (lf, crlf, cr int) {
+	cr = s.cr
+	if s.afterCR {
+		cr++
+	}
+	return s.lf, s.crlf, cr
+}
+
+func (s *textStats) lineCount() int {
+	lf, crlf, cr := s.endings()
+	n := lf + crlf + cr
+	if s.lineOpen {
+		n++
+	}
+	return n
+}
+
+func (s *textStats) lineEnding() string {
+	lf, crlf, cr := s.endings()
+	ending := LineEndingNone
+	for _, kind := range []struct {
+		count  int
+		ending string
+	}{{lf, LineEndingLF}, {crlf, LineEndingCRLF}, {cr, LineEndingCR}} {
+		if kind.count == 0 {
+			continue
+		}
+		if ending != LineEndingNone {
+			return LineEndingMixed
+		}
+		ending = kind.ending
+	}
+	return ending
+}
+
+func (s *textStats) encoding() string {
+	switch {
+	case s.bom != "":
+		return s.bom
+	case !s.validUTF8 || len(s.partial) > 0:
+		return EncodingUnknown
+	case s.ascii:
+		return EncodingASCII
+	}
+	return EncodingUTF8
+}
@@ -0,0 +1,84 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/fsutil"
+)
+
+func utf16LE(s string) []byte {
+	data := []byte{0xff, 0xfe}
+	for _, unit := range utf16.Encode([]rune(s)) {
+		data = append(data, byte(unit), byte(unit>>8))
+	}
+	return data
+}
+
+func TestFileDetails(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name       string
+		content    []byte
+		binary     bool
+		encoding   string
+		lineEnding string
+		lineCount  int
+	}{
+		{"empty.txt", nil, false, EncodingASCII, LineEndingNone, 0},
+		{"lf.txt", []byte("a\nb\n"), false, EncodingASCII, LineEndingLF, 2},
+		{"crlf.txt", []byte("a\r\nb"), false, EncodingASCII, LineEndingCRLF, 2},
+		{"cr.txt", []byte("a\rb\r"), false, EncodingASCII, LineEndingCR, 2},
+		{"mixed.txt", []byte("a\r\nb\nc"), false, EncodingASCII, LineEndingMixed, 3},
+		{"utf8.txt", []byte("hé\n"), false, EncodingUTF8, LineEndingLF, 1},
+		{"bom.txt", []byte("\xef\xbb\xbfa\r\n"), false, EncodingUTF8BOM, LineEndingCRLF, 1},
+		{"latin1.txt", []byte("h\xe9\n"), false, EncodingUnknown, LineEndingLF, 1},
+		{"utf16.txt", utf16LE("a\r\nb\r\n"), false, EncodingUTF16LE, LineEndingCRLF, 2},
+		{"bin", []byte{0x7f, 'E', 'L', 'F', 0, 0, '\n'}, true, "", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(path, tt.content, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			details, err := fileDetails(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if details.IsBinary != tt.binary || details.Encoding != tt.encoding || details.LineEnding != tt.lineEnding {
+				t.Fatalf("unexpected details %+v", details)
+			}
+			if tt.binary {
+				if details.LineCount != nil {
+					t.Fatalf("expected no line count for a binary file, got %d", *details.LineCount)
+				}
+			} else if details.LineCount == nil || *details.LineCount != tt.lineCount {
+				t.Fatalf("expected %d lines, got %v", tt.lineCount, details.LineCount)
+			}
+			if details.Sha256 != fsutil.HashBytes(tt.content) {
+				t.Fatalf("unexpected hash %s", details.Sha256)
+			}
+		})
+	}
+}
+
+func TestTextStatsSplitWrites(t *testing.T) {
+	content := []byte("éé\r\né")
+
+	// every split point, including inside a multi-byte sequence and between
+	// \r and \n
+	for i := range content {
+		stats := newTextStats("")
+		stats.Write(content[:i])
+		stats.Write(content[i:])
+		if stats.encoding() != EncodingUTF8 || stats.lineEnding() != LineEndingCRLF || stats.lineCount() != 2 {
+			t.Fatalf("split at %d: got %s %s %d", i, stats.encoding(), stats.lineEnding(), stats.lineCount())
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/diff"
+	"github.com/cofy-x/deck/apps/daemon/pkg/fsutil"
+	"github.com/gin-gonic/gin"
+)
+
+// Line edit operations
+const (
+	EditLinesReplace = "replace"
+	EditLinesInsert  = "insert"
+	EditLinesDelete  = "delete"
+)
+
+// lineEditMu serializes line edits, so edits based on the same content
+// can't both pass the check before the write.
+var lineEditMu sync.Mutex
+
+var errFileChanged = errors.New("file has changed")
+
+// GetFileLines godoc
+//
+//	@Summary		Read a range of lines
+//	@Description	Read the lines start to end (1-based, inclusive) of a text file, without line endings. The response contains the total number of lines and the hash of the file to use as expectedHash in EditLines.
+//	@Tags			file-system
+//	@Produce		json
+//	@Param			path	query		string	true	"File path"
+//	@Param			start	query		integer	false	"First line to read (default: 1)"
+//	@Param			end		query		integer	false	"Last line to read (default: last line of the file)"
+//	@Success		200		{object}	FileLinesResponse
+//	@Router			/files/lines [get]
+//
+//	@id				GetFileLines
+func GetFileLines(c *gin.Context) {
+	requestedPath := c.Query("path")
+	if requestedPath == "" {
+		c.AbortWithError(http.StatusBadRequest, errors.New("path is required"))
+		return
+	}
+
+	start := 1
+	if value := c.Query("start"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			c.AbortWithError(http.StatusBadRequest, errors.New("start must be a positive integer"))
+			return
+		}
+		start = n
+	}
+
+	end := -1
+	if value := c.Query("end"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < start-1 {
+			c.AbortWithError(http.StatusBadRequest, errors.New("end must be an integer not less than start - 1"))
+			return
+		}
+		end = n
+	}
+
+	absPath, content, _, ok := readTextFile(c, requestedPath)
+	if !ok {
+		return
+	}
+
+	lines := diff.SplitLines(string(content))
+	if end < 0 || end > len(lines) {
+		end = len(lines)
+	}
+
+	result := make([]string, 0, max(0, end-start+1))
+	for i := start; i <= end; i++ {
+		result = append(result, trimLineEnding(lines[i-1]))
+	}
+
+	c.JSON(http.StatusOK, FileLinesResponse{
+		Path:       absPath,
+		Start:      start,
+		End:        start + len(result) - 1,
+		TotalLines: len(lines),
+		Lines:      result,
+		Hash:       fsutil.HashBytes(content),
+	})
+}
+
+// EditLines godoc
+//
+//	@Summary		Edit a range of lines
+//	@Description	Replace, insert or delete lines of a text file. When expectedHash is set and the file no longer has that hash, nothing is written and 409 is returned. Right before the file is replaced it is checked again, and 409 is returned as well when another writer changed it during the edit. A write landing between that last check and the replacement, or through a file handle opened earlier, is not detected. New lines use the line ending of the file.
+//	@Tags			file-system
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		EditLinesRequest	true	"Edit request"
+//	@Success		200		{object}	EditLinesResponse
+//	@Router			/files/lines [post]
+//
+//	@id				EditLines
+func EditLines(c *gin.Context) {
+	var req EditLinesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	if req.Path == "" {
+		c.AbortWithError(http.StatusBadRequest, errors.New("path is required"))
+		return
+	}
+
+	switch req.Operation {
+	case EditLinesReplace, EditLinesInsert, EditLinesDelete:
+	default:
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid operation: %q", req.Operation))
+		return
+	}
+
+	lineEditMu.Lock()
+	defer lineEditMu.Unlock()
+
+	absPath, content, info, ok := readTextFile(c, req.Path)
+	if !ok {
+		return
+	}
+
+	if req.ExpectedHash != nil {
+		if current := fsutil.HashBytes(content); !strings.EqualFold(current, strings.TrimPrefix(*req.ExpectedHash, "sha256:")) {
+			c.AbortWithError(http.StatusConflict, fmt.Errorf("%w: expected hash %s, current hash %s", errFileChanged, *req.ExpectedHash, current))
+			return
+		}
+	}
+
+	lines := diff.SplitLines(string(content))
+
+	end, err := editRange(req, len(lines))
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	var replacement []string
+	if req.Operation != EditLinesDelete {
+		replacement = req.Lines
+	}
+
+	newContent := spliceLines(lines, req.Start-1, end, replacement)
+	if err := replaceUnchanged(absPath, content, []byte(newContent), info); err != nil {
+		if errors.Is(err, errFileChanged) {
+			c.AbortWithError(http.StatusConflict, err)
+			return
+		}
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, EditLinesResponse{
+		Path:       absPath,
+		TotalLines: len(lines) - (end - req.Start + 1) + len(replacement),
+		Hash:       fsutil.HashBytes([]byte(newContent)),
+	})
+}
+
+// editRange validates the lines an edit of a file with total lines applies
+// to and returns the last of them. An insert applies to the empty range
+// before start, so end is start - 1.
+func editRange(req EditLinesRequest, total int) (int, error) {
+	if req.Operation == EditLinesInsert {
+		if req.Start < 1 || req.Start > total+1 {
+			return 0, fmt.Errorf("start must be between 1 and %d", total+1)
+		}
+		return req.Start - 1, nil
+	}
+
+	end := req.Start
+	if req.End != nil {
+		end = *req.End
+	}
+	if req.Start < 1 || end < req.Start || end > total {
+		return 0, fmt.Errorf("invalid line range %d-%d for a file with %d lines", req.Start, end, total)
+	}
+	return end, nil
+}
+
+// replaceUnchanged replaces the file at path with data like writeFileAtomic,
+// unless its content is no longer base. The content is checked once data is
+// staged, right before the rename.
+func replaceUnchanged(path string, base, data []byte, info os.FileInfo) error {
+	path, err := writeTarget(path)
+	if err != nil {
+		return err
+	}
+
+	tmpPath, err := stageFile(path, data, info)
+	if err != nil {
+		return err
+	}
+
+	current, err := fsutil.HashFile(path)
+	if err == nil && current != fsutil.HashBytes(base) {
+		err = fmt.Errorf("%w while it was edited", errFileChanged)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// readTextFile resolves and reads the file at path, aborting the request
+// when that fails.
+func readTextFile(c *gin.Context, path string) (string, []byte, os.FileInfo, bool) {
+	path, ok := checkPath(c, path)
+	if !ok {
+		return "", nil, nil, false
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid path: %w", err))
+		return "", nil, nil, false
+	}
+
+	info, err := os.Stat(absPath)
+	if err == nil && info.IsDir() {
+		err = errors.New("path must be a file")
+	}
+
+	var content []byte
+	if err == nil {
+		content, err = os.ReadFile(absPath)
+	}
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.AbortWithError(http.StatusNotFound, err)
+			return "", nil, nil, false
+		}
+		if os.IsPermission(err) {
+			c.AbortWithError(http.StatusForbidden, err)
+			return "", nil, nil, false
+		}
+		c.AbortWithError(http.StatusBadRequest, err)
+		return "", nil, nil, false
+	}
+
+	return absPath, content, info, true
+}
+
+// spliceLines replaces lines[from:to] with replacement. lines keep their
+// line endings; the replacement lines get the line ending of the file. A
+// missing newline at the end of the file is preserved.
+func spliceLines(lines []string, from, to int, replacement []string) string {
+	eol := "\n"
+	if len(lines) > 0 && strings.HasSuffix(lines[0], "\r\n") {
+		eol = "\r\n"
+	}
+
+	noFinalNewline := len(lines) > 0 && !strings.HasSuffix(lines[len(lines)-1], "\n")
+
+	var sb strings.Builder
+	write := func(line string) {
+		sb.WriteString(line)
+		if !strings.HasSuffix(line, "\n") {
+			sb.WriteString(eol)
+		}
+	}
+
+	for _, line := range lines[:from] {
+		write(line)
+	}
+	for _, line := range replacement {
+		sb.WriteString(line)
+		sb.WriteString(eol)
+	}
+	for _, line := range lines[to:] {
+		write(line)
+	}
+
+	result := sb.String()
+	if noFinalNewline {
+		result = strings.TrimSuffix(result, eol)
+	}
+	return result
+}
+
+func trimLineEnding(line string) string {
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r")
+}
@@ -0,0 +1,95 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/diff"
+)
+
+func TestSpliceLines(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		from, to    int
+		replacement []string
+		expected    string
+	}{
+		{"replace", "a\nb\nc\n", 1, 2, []string{"B"}, "a\nB\nc\n"},
+		{"insert at start", "a\nb\n", 0, 0, []string{"x"}, "x\na\nb\n"},
+		{"insert at end", "a\nb\n", 2, 2, []string{"x"}, "a\nb\nx\n"},
+		{"delete", "a\nb\nc\n", 0, 2, nil, "c\n"},
+		{"crlf", "a\r\nb\r\n", 1, 1, []string{"x", "y"}, "a\r\nx\r\ny\r\nb\r\n"},
+		{"no final newline", "a\nb", 1, 2, []string{"B"}, "a\nB"},
+		{"append without final newline", "a\nb", 2, 2, []string{"c"}, "a\nb\nc"},
+		{"delete last line without final newline", "a\nb", 1, 2, nil, "a"},
+		{"empty file", "", 0, 0, []string{"a"}, "a\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := spliceLines(diff.SplitLines(tt.content), tt.from, tt.to, tt.replacement)
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestEditRange(t *testing.T) {
+	end := func(n int) *int { return &n }
+	tests := []struct {
+		req      EditLinesRequest
+		expected int
+		valid    bool
+	}{
+		{EditLinesRequest{Operation: EditLinesReplace, Start: 2}, 2, true},
+		{EditLinesRequest{Operation: EditLinesReplace, Start: 1, End: end(3)}, 3, true},
+		{EditLinesRequest{Operation: EditLinesDelete, Start: 3, End: end(3)}, 3, true},
+		{EditLinesRequest{Operation: EditLinesInsert, Start: 1}, 0, true},
+		{EditLinesRequest{Operation: EditLinesInsert, Start: 4}, 3, true},
+		{EditLinesRequest{Operation: EditLinesInsert, Start: 5}, 0, false},
+		{EditLinesRequest{Operation: EditLinesInsert, Start: 0}, 0, false},
+		{EditLinesRequest{Operation: EditLinesReplace, Start: 0}, 0, false},
+		{EditLinesRequest{Operation: EditLinesReplace, Start: 2, End: end(1)}, 0, false},
+		{EditLinesRequest{Operation: EditLinesDelete, Start: 1, End: end(4)}, 0, false},
+	}
+
+	for _, tt := range tests {
+		got, err := editRange(tt.req, 3)
+		if tt.valid && (err != nil || got != tt.expected) {
+			t.Errorf("%+v: expected end %d, got %d, %v", tt.req, tt.expected, got, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%+v: expected an error", tt.req)
+		}
+	}
+}
+
+func TestReplaceUnchanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(path, []byte("changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// another writer replaced the content the edit was based on
+	if err := replaceUnchanged(path, []byte("base\n"), []byte("edit\n"), nil); !errors.Is(err, errFileChanged) {
+		t.Fatalf("expected errFileChanged, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "changed\n" {
+		t.Fatalf("expected the other write to be kept, got %q", data)
+	}
+
+	if err := replaceUnchanged(path, []byte("changed\n"), []byte("edit\n"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "edit\n" {
+		t.Fatalf("expected the edit to be written, got %q", data)
+	}
+	// no temporary files are left behind
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Fatalf("expected only the file, got %v", entries)
+	}
+}
@@ -2,24 +2,58 @@ package fs
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 )
 
+// maxLineSize bounds the length of a single line considered by FindInFiles,
+// longer lines are skipped.
+const maxLineSize = 1024 * 1024
+
+type searchTask struct {
+	// position of the file in the walk
+	index int
+	path  string
+}
+
+type searchResult struct {
+	index   int
+	matches []Match
+}
+
+// lineMatcher returns the byte offsets of the first match in line.
+type lineMatcher func(line string) (start, end int, ok bool)
+
 // FindInFiles godoc
 //
 //	@Summary		Find text in files
-//	@Description	Search for text pattern within files in a directory
+//	@Description	Search for a text or regex pattern within files in a directory. Files ignored by .gitignore and the .git directory are skipped unless noIgnore is set. Matches are returned in the order the directory is walked, so maxResults always keeps the same matches. Lines longer than 1MiB are skipped.
 //	@Tags			file-system
 //	@Produce		json
-//	@Param			path	query	string	true	"Directory path to search in"
-//	@Param			pattern	query	string	true	"Text pattern to search for"
-//	@Success		200		{array}	Match
+//	@Param			path		query	string		true	"Directory path to search in"
+//	@Param			pattern		query	string		true	"Text pattern to search for"
+//	@Param			regex		query	boolean		false	"Treat pattern as a regular expression"
+//	@Param			ignoreCase	query	boolean		false	"Match case-insensitively"
+//	@Param			include		query	[]string	false	"Only search files matching these globs"			collectionFormat(multi)
+//	@Param			exclude		query	[]string	false	"Skip files and directories matching these globs"	collectionFormat(multi)
+//	@Param			noIgnore	query	boolean		false	"Do not respect .gitignore files"
+//	@Param			context		query	integer		false	"Number of context lines to return before and after each match"
+//	@Param			maxResults	query	integer		false	"Maximum number of matches to return (0 means no limit)"
+//	@Success		200			{array}	Match
 //	@Router			/files/find [get]
 //
 //	@id				FindInFiles
@@ -31,59 +65,280 @@ func FindInFiles(c *gin.Context) {
 		return
 	}
 
-	var matches = make([]Match, 0)
-	err := filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
-		if err != nil {
-			return filepath.SkipDir
+	path, ok := checkPath(c, path)
+	if !ok {
+		return
+	}
+
+	contextLines, err := parseNonNegativeInt(c.Query("context"))
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid context: %w", err))
+		return
+	}
+
+	maxResults, err := parseNonNegativeInt(c.Query("maxResults"))
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid maxResults: %w", err))
+		return
+	}
+
+	match, err := newLineMatcher(pattern, c.Query("regex") == "true", c.Query("ignoreCase") == "true")
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid pattern: %w", err))
+		return
+	}
+
+	root := filepath.Clean(path)
+	if _, err := os.Stat(root); err != nil {
+		if os.IsNotExist(err) {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
 		}
+		if os.IsPermission(err) {
+			c.AbortWithError(http.StatusForbidden, err)
+			return
+		}
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	filter := newWalkFilter(root, splitGlobs(c.QueryArray("include")), splitGlobs(c.QueryArray("exclude")), c.Query("noIgnore") == "true")
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	files := make(chan searchTask, 256)
+	results := make(chan searchResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range files {
+				if ctx.Err() != nil {
+					continue
+				}
+				results <- searchResult{index: task.index, matches: searchFile(task.path, match, contextLines)}
+			}
+		}()
+	}
 
-		if !info.Mode().IsRegular() {
-			return nil
+	go func() {
+		index := 0
+		_ = filepath.WalkDir(root, func(filePath string, d iofs.DirEntry, err error) error {
+			if err != nil {
+				if d != nil && d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if ctx.Err() != nil {
+				return filepath.SkipAll
+			}
+
+			if d.IsDir() {
+				if filter.skipDir(filePath) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if !d.Type().IsRegular() || filter.skipFile(filePath) {
+				return nil
+			}
+
+			select {
+			case files <- searchTask{index: index, path: filePath}:
+				index++
+				return nil
+			case <-ctx.Done():
+				return filepath.SkipAll
+			}
+		})
+		close(files)
+		wg.Wait()
+		close(results)
+	}()
+
+	// Files finish in any order, so results are only taken in the order of
+	// the walk. That way maxResults always keeps the same matches.
+	var matches = make([]Match, 0)
+	finished := map[int]searchResult{}
+	next := 0
+	for result := range results {
+		finished[result.index] = result
+		for maxResults == 0 || len(matches) < maxResults {
+			result, ok := finished[next]
+			if !ok {
+				break
+			}
+			delete(finished, next)
+			next++
+			matches = append(matches, result.matches...)
 		}
+		if maxResults > 0 && len(matches) >= maxResults {
+			cancel()
+		}
+	}
 
-		file, err := os.Open(filePath)
-		if err != nil {
-			return nil
+	if maxResults > 0 && len(matches) > maxResults {
+		matches = matches[:maxResults]
+	}
+
+	c.JSON(http.StatusOK, matches)
+}
+
+func newLineMatcher(pattern string, isRegex, ignoreCase bool) (lineMatcher, error) {
+	if !isRegex && !ignoreCase {
+		return func(line string) (int, int, bool) {
+			idx := strings.Index(line, pattern)
+			if idx < 0 {
+				return 0, 0, false
+			}
+			return idx, idx + len(pattern), true
+		}, nil
+	}
+
+	expr := pattern
+	if !isRegex {
+		expr = regexp.QuoteMeta(pattern)
+	}
+	if ignoreCase {
+		expr = "(?i)" + expr
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(line string) (int, int, bool) {
+		loc := re.FindStringIndex(line)
+		if loc == nil {
+			return 0, 0, false
 		}
-		defer file.Close()
+		return loc[0], loc[1], true
+	}, nil
+}
+
+// searchFile scans a single text file and returns its matches. Binary and
+// unreadable files yield no matches. Lines longer than maxLineSize, like
+// those of minified code, are skipped and left out of the context.
+func searchFile(filePath string, match lineMatcher, contextLines int) []Match {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	if isBinary(file) {
+		return nil
+	}
+
+	if _, err := file.Seek(0, 0); err != nil {
+		return nil
+	}
+
+	var matches []Match
+	// lines preceding the current one, capped at contextLines
+	var before []string
+	// indexes into matches still collecting trailing context
+	var pending []int
 
-		buf := make([]byte, 512)
-		n, err := file.Read(buf)
+	reader := bufio.NewReaderSize(file, 64*1024)
+	lineNum := 1
+	for ; ; lineNum++ {
+		data, tooLong, err := readLine(reader)
 		if err != nil {
-			return nil
+			break
 		}
+		if tooLong {
+			continue
+		}
+		line := string(data)
 
-		for i := 0; i < n; i++ {
-			// skip binary files
-			if buf[i] == 0 {
-				return nil
+		if contextLines > 0 {
+			remaining := pending[:0]
+			for _, idx := range pending {
+				matches[idx].ContextAfter = append(matches[idx].ContextAfter, line)
+				if len(matches[idx].ContextAfter) < contextLines {
+					remaining = append(remaining, idx)
+				}
 			}
+			pending = remaining
 		}
 
-		_, err = file.Seek(0, 0)
-		if err != nil {
-			return nil
+		if start, end, ok := match(line); ok {
+			m := Match{
+				File:      filePath,
+				Line:      lineNum,
+				Column:    start + 1,
+				EndColumn: end + 1,
+				Content:   line,
+			}
+			if contextLines > 0 {
+				m.ContextBefore = append([]string{}, before...)
+				m.ContextAfter = []string{}
+				pending = append(pending, len(matches))
+			}
+			matches = append(matches, m)
 		}
 
-		scanner := bufio.NewScanner(file)
-		lineNum := 1
-		for scanner.Scan() {
-			if strings.Contains(scanner.Text(), pattern) {
-				matches = append(matches, Match{
-					File:    filePath,
-					Line:    lineNum,
-					Content: scanner.Text(),
-				})
+		if contextLines > 0 {
+			before = append(before, line)
+			if len(before) > contextLines {
+				before = before[1:]
 			}
-			lineNum++
 		}
-		return nil
-	})
+	}
 
-	if err != nil {
-		c.AbortWithError(http.StatusBadRequest, err)
-		return
+	return matches
+}
+
+// readLine reads the next line from r without its line ending. The content
+// of a line longer than maxLineSize is discarded and tooLong is set.
+func readLine(r *bufio.Reader) (line []byte, tooLong bool, err error) {
+	for read := false; ; read = true {
+		chunk, err := r.ReadSlice('\n')
+		if err == io.EOF && (read || len(chunk) > 0) {
+			// the last line has no line ending
+			err = nil
+		}
+		if err != nil && err != bufio.ErrBufferFull {
+			return nil, false, err
+		}
+		// with room for \r\n, line is too long either way
+		if !tooLong && len(line)+len(chunk) > maxLineSize+2 {
+			tooLong = true
+			line = nil
+		}
+		if !tooLong {
+			line = append(line, chunk...)
+		}
+		if err == nil {
+			break
+		}
 	}
 
-	c.JSON(http.StatusOK, matches)
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	if len(line) > maxLineSize {
+		return nil, true, nil
+	}
+	return line, tooLong, nil
+}
+
+func parseNonNegativeInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, errors.New("must not be negative")
+	}
+	return n, nil
 }
@@ -0,0 +1,32 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSearchFileLongLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "long.txt")
+	content := "needle\n" + strings.Repeat("x", maxLineSize+1) + "\r\nneedle\r\n" + strings.Repeat("needle", maxLineSize)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	match, err := newLineMatcher("needle", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches := searchFile(path, match, 1)
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 matches, got %d", len(matches))
+	}
+	// the overlong line is skipped, but still counted
+	if matches[1].Line != 3 || matches[1].Content != "needle" {
+		t.Fatalf("expected needle on line 3, got %+v", matches[1])
+	}
+	if len(matches[0].ContextAfter) != 1 || matches[0].ContextAfter[0] != "needle" {
+		t.Fatalf("expected the overlong line to be left out of the context, got %q", matches[0].ContextAfter)
+	}
+}
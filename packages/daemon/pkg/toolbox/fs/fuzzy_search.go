@@ -0,0 +1,295 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultFuzzyLimit is the number of results returned when no limit is given.
+const defaultFuzzyLimit = 50
+
+// FuzzySearchFiles godoc
+//
+//	@Summary		Fuzzy search files by path
+//	@Description	Find files whose path relative to the search directory contains the characters of the query in order, like fzf. Results are ranked, matches at the start of path segments and words, consecutive matches and matches in the file name score higher. The query is case-insensitive unless it contains an upper case letter. Files ignored by .gitignore are skipped unless noIgnore is set.
+//	@Tags			file-system
+//	@Produce		json
+//	@Param			path		query		string		true	"Directory to search in"
+//	@Param			query		query		string		false	"Fuzzy query, an empty query lists the shortest paths first"
+//	@Param			include		query		[]string	false	"Only consider files matching these globs, ** matches any number of directories"	collectionFormat(multi)
+//	@Param			exclude		query		[]string	false	"Skip files and directories matching these globs"									collectionFormat(multi)
+//	@Param			noIgnore	query		boolean		false	"Do not skip files ignored by .gitignore"
+//	@Param			limit		query		integer		false	"Maximum number of results (default 50)"
+//	@Success		200			{object}	FuzzySearchResponse
+//	@Router			/files/search/fuzzy [get]
+//
+//	@id				FuzzySearchFiles
+func FuzzySearchFiles(c *gin.Context) {
+	path := c.Query("path")
+	if path == "" {
+		c.AbortWithError(http.StatusBadRequest, errors.New("path is required"))
+		return
+	}
+
+	limit, err := parseNonNegativeInt(c.Query("limit"))
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid limit: %w", err))
+		return
+	}
+	if limit == 0 {
+		limit = defaultFuzzyLimit
+	}
+
+	include := splitGlobs(c.QueryArray("include"))
+	exclude := splitGlobs(c.QueryArray("exclude"))
+	for _, glob := range append(include, exclude...) {
+		if _, err := filepath.Match(glob, ""); err != nil {
+			c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid glob %q: %w", glob, err))
+			return
+		}
+	}
+
+	path, ok := checkPath(c, path)
+	if !ok {
+		return
+	}
+
+	root := filepath.Clean(path)
+	info, err := os.Stat(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		if os.IsPermission(err) {
+			c.AbortWithError(http.StatusForbidden, err)
+			return
+		}
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	if !info.IsDir() {
+		c.AbortWithError(http.StatusBadRequest, errors.New("path must be a directory"))
+		return
+	}
+
+	query := []rune(strings.TrimSpace(c.Query("query")))
+	caseSensitive := false
+	for _, r := range query {
+		if unicode.IsUpper(r) {
+			caseSensitive = true
+			break
+		}
+	}
+	if !caseSensitive {
+		for i, r := range query {
+			query[i] = unicode.ToLower(r)
+		}
+	}
+
+	ctx := c.Request.Context()
+	filter := newWalkFilter(root, include, exclude, c.Query("noIgnore") == "true")
+
+	matches := make([]FuzzyMatch, 0)
+	_ = filepath.WalkDir(root, func(filePath string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			return filepath.SkipAll
+		}
+
+		if d.IsDir() {
+			if filter.skipDir(filePath) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if filter.skipFile(filePath) || escapesWorkspace(filePath, d.Type()&os.ModeSymlink != 0) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		score, positions, ok := fuzzyMatch(query, rel, caseSensitive)
+		if !ok {
+			return nil
+		}
+		matches = append(matches, FuzzyMatch{
+			Path:         filePath,
+			RelativePath: rel,
+			Score:        score,
+			Positions:    positions,
+		})
+		return nil
+	})
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if len(a.RelativePath) != len(b.RelativePath) {
+			return len(a.RelativePath) < len(b.RelativePath)
+		}
+		return a.RelativePath < b.RelativePath
+	})
+
+	total := len(matches)
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	c.JSON(http.StatusOK, FuzzySearchResponse{Matches: matches, Total: total})
+}
+
+// Scores of the fuzzy matcher. Every matched character scores, characters at
+// the start of a path segment or word and consecutive runs get a bonus, gaps
+// between matched characters cost.
+const (
+	fuzzyScoreMatch       = 16
+	fuzzyGapStart         = 3
+	fuzzyGapExtension     = 1
+	fuzzyBonusSegment     = 10
+	fuzzyBonusBoundary    = 8
+	fuzzyBonusCamel       = 7
+	fuzzyBonusConsecutive = 4
+	fuzzyBonusBaseName    = 2
+)
+
+// fuzzyNone marks positions a query prefix can't end at.
+const fuzzyNone = math.MinInt / 2
+
+// fuzzyMatch reports whether query, already lower case unless caseSensitive,
+// is a subsequence of text. It returns the score of the best alignment and the
+// rune indexes of text it matched.
+func fuzzyMatch(query []rune, text string, caseSensitive bool) (int, []int, bool) {
+	if len(query) == 0 {
+		return 0, []int{}, true
+	}
+
+	runes := []rune(text)
+	if !caseSensitive {
+		for i, r := range runes {
+			runes[i] = unicode.ToLower(r)
+		}
+	}
+
+	// cheap rejection, most candidates don't contain the query at all
+	i := 0
+	for _, r := range runes {
+		if r == query[i] {
+			if i++; i == len(query) {
+				break
+			}
+		}
+	}
+	if i < len(query) {
+		return 0, nil, false
+	}
+
+	bonus := fuzzyBonuses(text)
+
+	// score[i][j] is the best score of query[:i+1] with query[i] matched at
+	// runes[j], from[i][j] where query[i-1] was matched then
+	score := make([][]int, len(query))
+	from := make([][]int, len(query))
+	for i := range query {
+		score[i] = make([]int, len(runes))
+		from[i] = make([]int, len(runes))
+
+		// best score of query[:i] ending before j-1, less the gap to j
+		gapScore, gapFrom := fuzzyNone, -1
+		for j := range runes {
+			score[i][j] = fuzzyNone
+			if i > 0 && j >= 2 {
+				gapScore -= fuzzyGapExtension
+				if prev := score[i-1][j-2]; prev != fuzzyNone && prev-fuzzyGapStart > gapScore {
+					gapScore, gapFrom = prev-fuzzyGapStart, j-2
+				}
+			}
+
+			if runes[j] != query[i] {
+				continue
+			}
+
+			if i == 0 {
+				score[i][j] = fuzzyScoreMatch + bonus[j]
+				from[i][j] = -1
+				continue
+			}
+
+			best, bestFrom := gapScore, gapFrom
+			if j > 0 && score[i-1][j-1] != fuzzyNone && score[i-1][j-1]+fuzzyBonusConsecutive > best {
+				best, bestFrom = score[i-1][j-1]+fuzzyBonusConsecutive, j-1
+			}
+			if bestFrom < 0 {
+				continue
+			}
+			score[i][j] = best + fuzzyScoreMatch + bonus[j]
+			from[i][j] = bestFrom
+		}
+	}
+
+	last := len(query) - 1
+	end := -1
+	for j := range runes {
+		if score[last][j] != fuzzyNone && (end < 0 || score[last][j] > score[last][end]) {
+			end = j
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+
+	positions := make([]int, len(query))
+	for i, j := last, end; i >= 0; i-- {
+		positions[i] = j
+		j = from[i][j]
+	}
+	return score[last][end], positions, true
+}
+
+// fuzzyBonuses returns the bonus for matching each rune of text.
+func fuzzyBonuses(text string) []int {
+	baseStart := utf8.RuneCountInString(text[:strings.LastIndexByte(text, '/')+1])
+
+	bonus := make([]int, 0, len(text))
+	prev := '/'
+	for _, r := range text {
+		b := 0
+		switch {
+		case prev == '/':
+			b = fuzzyBonusSegment
+		case strings.ContainsRune("_-. ", prev):
+			b = fuzzyBonusBoundary
+		case unicode.IsLower(prev) && unicode.IsUpper(r), !unicode.IsDigit(prev) && unicode.IsDigit(r):
+			b = fuzzyBonusCamel
+		}
+		if len(bonus) >= baseStart {
+			b += fuzzyBonusBaseName
+		}
+		bonus = append(bonus, b)
+		prev = r
+	}
+	return bonus
+}
@@ -0,0 +1,69 @@
+package fs
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	if _, _, ok := fuzzyMatch([]rune("xyz"), "src/main.go", false); ok {
+		t.Fatal("expected no match")
+	}
+	if _, _, ok := fuzzyMatch([]rune("Main"), "src/main.go", true); ok {
+		t.Fatal("expected an upper case query to be case sensitive")
+	}
+
+	// the best alignment is chosen over the first one
+	_, positions, ok := fuzzyMatch([]rune("main"), "domain/main.go", false)
+	if !ok {
+		t.Fatal("expected a match")
+	}
+	if expected := []int{7, 8, 9, 10}; !reflect.DeepEqual(positions, expected) {
+		t.Fatalf("expected positions %v, got %v", expected, positions)
+	}
+
+	_, positions, _ = fuzzyMatch([]rune("fb"), "fooBar.go", false)
+	if expected := []int{0, 3}; !reflect.DeepEqual(positions, expected) {
+		t.Fatalf("expected positions %v, got %v", expected, positions)
+	}
+}
+
+func TestFuzzyRanking(t *testing.T) {
+	candidates := []string{
+		"docs/mechanics/readme.md",
+		"pkg/toolbox/fs/move_file.go",
+		"pkg/toolbox/fs/types.go",
+		"cmd/main.go",
+		"pkg/toolbox/fs/search_files.go",
+	}
+
+	rank := func(query string) []string {
+		type result struct {
+			path  string
+			score int
+		}
+		var results []result
+		for _, candidate := range candidates {
+			if score, _, ok := fuzzyMatch([]rune(query), candidate, false); ok {
+				results = append(results, result{candidate, score})
+			}
+		}
+		sort.SliceStable(results, func(i, j int) bool { return results[i].score > results[j].score })
+		var paths []string
+		for _, r := range results {
+			paths = append(paths, r.path)
+		}
+		return paths
+	}
+
+	if got := rank("main"); len(got) == 0 || got[0] != "cmd/main.go" {
+		t.Fatalf("expected cmd/main.go first, got %v", got)
+	}
+	if got := rank("sf"); len(got) == 0 || got[0] != "pkg/toolbox/fs/search_files.go" {
+		t.Fatalf("expected search_files.go first, got %v", got)
+	}
+	if got := rank("fstypes"); len(got) != 1 || got[0] != "pkg/toolbox/fs/types.go" {
+		t.Fatalf("expected only types.go, got %v", got)
+	}
+}
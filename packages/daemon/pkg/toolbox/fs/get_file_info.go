@@ -14,10 +14,11 @@ import (
 // GetFileInfo godoc
 //
 //	@Summary		Get file information
-//	@Description	Get detailed information about a file or directory
+//	@Description	Get detailed information about a file or directory. With detail, the content of a regular file is read to report its MIME type, whether it is binary, the text encoding, line endings, line count and SHA-256.
 //	@Tags			file-system
 //	@Produce		json
 //	@Param			path	query		string	true	"File or directory path"
+//	@Param			detail	query		boolean	false	"Include content details of a regular file"
 //	@Success		200		{object}	FileInfo
 //	@Router			/files/info [get]
 //
@@ -29,7 +30,18 @@ func GetFileInfo(c *gin.Context) {
 		return
 	}
 
+	path, ok := checkPath(c, path)
+	if !ok {
+		return
+	}
+
 	info, err := getFileInfo(path)
+	if err == nil && c.Query("detail") == "true" {
+		// opening anything but a regular file could block, a FIFO for one
+		if stat, statErr := os.Stat(path); statErr == nil && stat.Mode().IsRegular() {
+			info.Details, err = fileDetails(path)
+		}
+	}
 	if err != nil {
 		if os.IsNotExist(err) {
 			c.AbortWithError(http.StatusNotFound, err)
@@ -49,11 +61,22 @@ func GetFileInfo(c *gin.Context) {
 func getFileInfo(path string) (FileInfo, error) {
 	info, err := os.Stat(path)
 	if err != nil {
-		return FileInfo{}, err
+		// a broken symlink is reported as the link itself
+		linkInfo, lerr := os.Lstat(path)
+		if lerr != nil || linkInfo.Mode()&os.ModeSymlink == 0 {
+			return FileInfo{}, err
+		}
+		info = linkInfo
 	}
+	return newFileInfo(path, info), nil
+}
 
+// newFileInfo converts the info of the file at path. The info describes the
+// file a symlink points to, the link itself is reported by IsSymlink and
+// LinkTarget.
+func newFileInfo(path string, info os.FileInfo) FileInfo {
 	stat := info.Sys().(*syscall.Stat_t)
-	return FileInfo{
+	fileInfo := FileInfo{
 		Name:        info.Name(),
 		Size:        info.Size(),
 		Mode:        info.Mode().String(),
@@ -62,5 +85,10 @@ func getFileInfo(path string) (FileInfo, error) {
 		Owner:       strconv.FormatUint(uint64(stat.Uid), 10),
 		Group:       strconv.FormatUint(uint64(stat.Gid), 10),
 		Permissions: fmt.Sprintf("%04o", info.Mode().Perm()),
-	}, nil
+	}
+	if target, err := os.Readlink(path); err == nil {
+		fileInfo.IsSymlink = true
+		fileInfo.LinkTarget = target
+	}
+	return fileInfo
 }
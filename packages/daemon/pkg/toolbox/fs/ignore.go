@@ -0,0 +1,144 @@
+package fs
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/fsutil"
+)
+
+const gitignoreFile = ".gitignore"
+
+// ignoreRules accumulates .gitignore patterns discovered while walking a tree.
+// Patterns are scoped to the directory they were read from, so a single rule
+// set can be shared by the whole walk.
+type ignoreRules struct {
+	root     string
+	patterns []gitignore.Pattern
+}
+
+func newIgnoreRules(root string) *ignoreRules {
+	return &ignoreRules{root: root}
+}
+
+// load reads dir/.gitignore (if present) and appends its patterns.
+func (r *ignoreRules) load(dir string) {
+	f, err := os.Open(filepath.Join(dir, gitignoreFile))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	domain := r.split(dir)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		r.patterns = append(r.patterns, gitignore.ParsePattern(line, domain))
+	}
+}
+
+// ignored reports whether path should be skipped. The .git directory is
+// always ignored.
+func (r *ignoreRules) ignored(path string, isDir bool) bool {
+	if isDir && filepath.Base(path) == ".git" {
+		return true
+	}
+
+	parts := r.split(path)
+	if len(parts) == 0 {
+		return false
+	}
+
+	for i := len(r.patterns) - 1; i >= 0; i-- {
+		if match := r.patterns[i].Match(parts, isDir); match > gitignore.NoMatch {
+			return match == gitignore.Exclude
+		}
+	}
+	return false
+}
+
+func (r *ignoreRules) split(path string) []string {
+	rel, err := filepath.Rel(r.root, path)
+	if err != nil || rel == "." {
+		return nil
+	}
+	return strings.Split(filepath.ToSlash(rel), "/")
+}
+
+// walkFilter decides which entries of a tree walk are visited. It combines
+// .gitignore handling with include and exclude globs.
+type walkFilter struct {
+	root     string
+	include  []string
+	exclude  []string
+	noIgnore bool
+	rules    *ignoreRules
+}
+
+func newWalkFilter(root string, include, exclude []string, noIgnore bool) *walkFilter {
+	return &walkFilter{
+		root:     root,
+		include:  include,
+		exclude:  exclude,
+		noIgnore: noIgnore,
+		rules:    newIgnoreRules(root),
+	}
+}
+
+// skipDir reports whether the directory should not be descended into. It
+// must be called for every directory before its children are visited.
+func (f *walkFilter) skipDir(path string) bool {
+	if f.excludedDir(path) {
+		return true
+	}
+	if !f.noIgnore {
+		f.rules.load(path)
+	}
+	return false
+}
+
+// excludedDir reports whether the directory is ignored or excluded, without
+// loading its .gitignore.
+func (f *walkFilter) excludedDir(path string) bool {
+	if path == f.root {
+		return false
+	}
+	if !f.noIgnore && f.rules.ignored(path, true) {
+		return true
+	}
+	return len(f.exclude) > 0 && fsutil.MatchAnyGlob(f.exclude, f.root, path)
+}
+
+// skipFile reports whether the file should be left out of the results.
+func (f *walkFilter) skipFile(path string) bool {
+	if !f.noIgnore && f.rules.ignored(path, false) {
+		return true
+	}
+	if len(f.exclude) > 0 && fsutil.MatchAnyGlob(f.exclude, f.root, path) {
+		return true
+	}
+	if len(f.include) > 0 && !fsutil.MatchAnyGlob(f.include, f.root, path) {
+		return true
+	}
+	return false
+}
+
+// splitGlobs flattens repeated and comma separated glob query values.
+func splitGlobs(values []string) []string {
+	var globs []string
+	for _, value := range values {
+		for _, glob := range strings.Split(value, ",") {
+			if glob = strings.TrimSpace(glob); glob != "" {
+				globs = append(globs, glob)
+			}
+		}
+	}
+	return globs
+}
@@ -1,21 +1,37 @@
 package fs
 
 import (
+	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
+// ListFiles sort keys
+const (
+	SortByName    = "name"
+	SortBySize    = "size"
+	SortByModTime = "modTime"
+)
+
 // ListFiles godoc
 //
 //	@Summary		List files and directories
-//	@Description	List files and directories in the specified path
+//	@Description	List files and directories in the specified path, sorted and optionally paginated. The total number of entries is returned in the X-Total-Count header.
 //	@Tags			file-system
 //	@Produce		json
-//	@Param			path	query	string	false	"Directory path to list (defaults to working directory)"
-//	@Success		200		{array}	FileInfo
+//	@Param			path	query		string	false	"Directory path to list (defaults to working directory)"
+//	@Param			sortBy	query		string	false	"Sort key: name (default), size or modTime"
+//	@Param			order	query		string	false	"Sort order: asc (default) or desc"
+//	@Param			offset	query		integer	false	"Number of entries to skip"
+//	@Param			limit	query		integer	false	"Maximum number of entries to return (default: all)"
+//	@Success		200		{array}		FileInfo
+//	@Header			200		{integer}	X-Total-Count	"Total number of entries in the directory"
 //	@Router			/files [get]
 //
 //	@id				ListFiles
@@ -25,7 +41,36 @@ func ListFiles(c *gin.Context) {
 		path = "."
 	}
 
-	files, err := os.ReadDir(path)
+	path, ok := checkPath(c, path)
+	if !ok {
+		return
+	}
+
+	sortBy := c.DefaultQuery("sortBy", SortByName)
+	if sortBy != SortByName && sortBy != SortBySize && sortBy != SortByModTime {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid sortBy: %s", sortBy))
+		return
+	}
+
+	order := c.DefaultQuery("order", "asc")
+	if order != "asc" && order != "desc" {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid order: %s", order))
+		return
+	}
+
+	offset, err := parseNonNegativeInt(c.Query("offset"))
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid offset: %w", err))
+		return
+	}
+
+	limit, err := parseNonNegativeInt(c.Query("limit"))
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid limit: %w", err))
+		return
+	}
+
+	entries, err := os.ReadDir(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			c.AbortWithError(http.StatusNotFound, err)
@@ -39,14 +84,77 @@ func ListFiles(c *gin.Context) {
 		return
 	}
 
-	var fileInfos = make([]FileInfo, 0)
-	for _, file := range files {
-		info, err := getFileInfo(filepath.Join(path, file.Name()))
-		if err != nil {
-			continue
+	c.Header("X-Total-Count", strconv.Itoa(len(entries)))
+
+	// ReadDir already sorts by name, so only the requested page needs to be
+	// stat'ed. Other sort keys need the info of every entry.
+	var infos []os.FileInfo
+	if sortBy == SortByName {
+		if order == "desc" {
+			slices.Reverse(entries)
+		}
+		for _, entry := range paginate(entries, offset, limit) {
+			if info, err := entryInfo(path, entry); err == nil {
+				infos = append(infos, info)
+			}
+		}
+	} else {
+		for _, entry := range entries {
+			if info, err := entryInfo(path, entry); err == nil {
+				infos = append(infos, info)
+			}
 		}
-		fileInfos = append(fileInfos, info)
+		sortInfos(infos, sortBy, order)
+		infos = paginate(infos, offset, limit)
+	}
+
+	var fileInfos = make([]FileInfo, 0, len(infos))
+	for _, info := range infos {
+		fileInfos = append(fileInfos, newFileInfo(filepath.Join(path, info.Name()), info))
 	}
 
 	c.JSON(http.StatusOK, fileInfos)
 }
+
+// entryInfo returns the info of a directory entry, following symlinks like
+// os.Stat unless the link is broken.
+func entryInfo(dir string, entry os.DirEntry) (os.FileInfo, error) {
+	info, err := entry.Info()
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		if target, err := os.Stat(filepath.Join(dir, entry.Name())); err == nil {
+			return target, nil
+		}
+	}
+	return info, nil
+}
+
+// sortInfos sorts infos by size or modification time. Entries with equal keys
+// keep their order, which is by name as returned by os.ReadDir.
+func sortInfos(infos []os.FileInfo, sortBy, order string) {
+	sort.SliceStable(infos, func(i, j int) bool {
+		a, b := infos[i], infos[j]
+		if order == "desc" {
+			a, b = b, a
+		}
+		if sortBy == SortBySize {
+			return a.Size() < b.Size()
+		}
+		return a.ModTime().Before(b.ModTime())
+	})
+}
+
+// paginate returns the page of items starting at offset with at most limit
+// items, or all remaining items when limit is 0.
+func paginate[T any](items []T, offset, limit int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
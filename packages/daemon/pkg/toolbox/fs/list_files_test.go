@@ -0,0 +1,82 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPaginate(t *testing.T) {
+	items := []int{0, 1, 2, 3, 4}
+
+	tests := []struct {
+		offset, limit int
+		want          []int
+	}{
+		{0, 0, []int{0, 1, 2, 3, 4}},
+		{0, 2, []int{0, 1}},
+		{3, 0, []int{3, 4}},
+		{3, 5, []int{3, 4}},
+		{4, 1, []int{4}},
+		{5, 0, nil},
+		{10, 2, nil},
+	}
+	for _, tt := range tests {
+		if got := paginate(items, tt.offset, tt.limit); !slices.Equal(got, tt.want) {
+			t.Errorf("paginate(%d, %d) = %v, want %v", tt.offset, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestSortInfos(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Now().Add(-time.Hour)
+	files := []struct {
+		name    string
+		size    int
+		modTime time.Time
+	}{
+		{"a", 20, base.Add(2 * time.Minute)},
+		{"b", 10, base},
+		{"c", 20, base.Add(time.Minute)},
+	}
+
+	var infos []os.FileInfo
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		if err := os.WriteFile(path, make([]byte, f.size), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, f.modTime, f.modTime); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		infos = append(infos, info)
+	}
+
+	tests := []struct {
+		sortBy, order string
+		want          []string
+	}{
+		{SortBySize, "asc", []string{"b", "a", "c"}},
+		{SortBySize, "desc", []string{"a", "c", "b"}},
+		{SortByModTime, "asc", []string{"b", "c", "a"}},
+		{SortByModTime, "desc", []string{"a", "c", "b"}},
+	}
+	for _, tt := range tests {
+		sorted := slices.Clone(infos)
+		sortInfos(sorted, tt.sortBy, tt.order)
+		var names []string
+		for _, info := range sorted {
+			names = append(names, info.Name())
+		}
+		if !slices.Equal(names, tt.want) {
+			t.Errorf("sortInfos(%s, %s) = %v, want %v", tt.sortBy, tt.order, names, tt.want)
+		}
+	}
+}
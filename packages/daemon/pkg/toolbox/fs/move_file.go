@@ -31,6 +31,16 @@ func MoveFile(c *gin.Context) {
 		return
 	}
 
+	sourcePath, ok := checkPathNoFollow(c, sourcePath)
+	if !ok {
+		return
+	}
+
+	destPath, ok = checkPath(c, destPath)
+	if !ok {
+		return
+	}
+
 	// Get absolute paths
 	absSourcePath, err := filepath.Abs(sourcePath)
 	if err != nil {
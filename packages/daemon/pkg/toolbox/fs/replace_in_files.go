@@ -1,18 +1,22 @@
 package fs
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"regexp"
 	"strings"
 
+	"github.com/cofy-x/deck/apps/daemon/pkg/diff"
+	"github.com/cofy-x/deck/apps/daemon/pkg/workspace"
 	"github.com/gin-gonic/gin"
 )
 
 // ReplaceInFiles godoc
 //
 //	@Summary		Replace text in files
-//	@Description	Replace text pattern with new value in multiple files
+//	@Description	Replace text or regex pattern with new value in multiple files. With dryRun set, no file is written and a unified diff is returned for each file.
 //	@Tags			file-system
 //	@Accept			json
 //	@Produce		json
@@ -28,10 +32,44 @@ func ReplaceInFiles(c *gin.Context) {
 		return
 	}
 
+	newValue := ""
+	if req.NewValue != nil {
+		newValue = *req.NewValue
+	}
+
+	limit := -1
+	if req.MaxReplacements != nil {
+		if *req.MaxReplacements <= 0 {
+			c.AbortWithError(http.StatusBadRequest, errors.New("maxReplacements must be greater than 0"))
+			return
+		}
+		limit = *req.MaxReplacements
+	}
+
+	replace := func(content string) (string, int) {
+		n := strings.Count(content, req.Pattern)
+		if limit >= 0 && n > limit {
+			n = limit
+		}
+		return strings.Replace(content, req.Pattern, newValue, n), n
+	}
+
+	if req.Regex {
+		re, err := regexp.Compile(req.Pattern)
+		if err != nil {
+			c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid pattern: %w", err))
+			return
+		}
+		replace = func(content string) (string, int) {
+			return replaceRegex(re, content, newValue, limit)
+		}
+	}
+
 	results := make([]ReplaceResult, 0, len(req.Files))
 
 	for _, filePath := range req.Files {
-		content, err := os.ReadFile(filePath)
+		// results are reported under the requested path
+		checked, err := workspace.Check(filePath)
 		if err != nil {
 			results = append(results, ReplaceResult{
 				File:    filePath,
@@ -41,14 +79,17 @@ func ReplaceInFiles(c *gin.Context) {
 			continue
 		}
 
-		newValue := ""
-		if req.NewValue != nil {
-			newValue = *req.NewValue
+		info, err := os.Stat(checked)
+		if err != nil {
+			results = append(results, ReplaceResult{
+				File:    filePath,
+				Success: false,
+				Error:   err.Error(),
+			})
+			continue
 		}
 
-		newContent := strings.ReplaceAll(string(content), req.Pattern, newValue)
-
-		err = os.WriteFile(filePath, []byte(newContent), 0644)
+		content, err := os.ReadFile(checked)
 		if err != nil {
 			results = append(results, ReplaceResult{
 				File:    filePath,
@@ -58,11 +99,56 @@ func ReplaceInFiles(c *gin.Context) {
 			continue
 		}
 
+		newContent, count := replace(string(content))
+
+		if req.DryRun {
+			patch := diff.Unified(filePath, filePath, string(content), newContent)
+			results = append(results, ReplaceResult{
+				File:         filePath,
+				Success:      true,
+				Replacements: count,
+				Diff:         &patch,
+			})
+			continue
+		}
+
+		if newContent != string(content) {
+			if err := writeFileAtomic(checked, []byte(newContent), info); err != nil {
+				results = append(results, ReplaceResult{
+					File:    filePath,
+					Success: false,
+					Error:   err.Error(),
+				})
+				continue
+			}
+		}
+
 		results = append(results, ReplaceResult{
-			File:    filePath,
-			Success: true,
+			File:         filePath,
+			Success:      true,
+			Replacements: count,
 		})
 	}
 
 	c.JSON(http.StatusOK, results)
 }
+
+// replaceRegex replaces up to limit matches of re in content (all matches
+// when limit is negative), expanding $1 style references in template.
+func replaceRegex(re *regexp.Regexp, content, template string, limit int) (string, int) {
+	matches := re.FindAllStringSubmatchIndex(content, limit)
+	if len(matches) == 0 {
+		return content, 0
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, match := range matches {
+		sb.WriteString(content[last:match[0]])
+		sb.Write(re.ExpandString(nil, template, content, match))
+		last = match[1]
+	}
+	sb.WriteString(content[last:])
+
+	return sb.String(), len(matches)
+}
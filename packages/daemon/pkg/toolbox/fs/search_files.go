@@ -2,6 +2,7 @@ package fs
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -17,6 +18,7 @@ import (
 //	@Produce		json
 //	@Param			path	query		string	true	"Directory path to search in"
 //	@Param			pattern	query		string	true	"File pattern to match (e.g., *.txt, *.go)"
+//	@Param			limit	query		integer	false	"Maximum number of results"
 //	@Success		200		{object}	SearchFilesResponse
 //	@Router			/files/search [get]
 //
@@ -29,13 +31,27 @@ func SearchFiles(c *gin.Context) {
 		return
 	}
 
-	var matches []string
-	err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+	limit, err := parseNonNegativeInt(c.Query("limit"))
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid limit: %w", err))
+		return
+	}
+
+	path, ok := checkPath(c, path)
+	if !ok {
+		return
+	}
+
+	var matches = make([]string, 0)
+	err = filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return filepath.SkipDir
 		}
 		if matched, _ := filepath.Match(pattern, info.Name()); matched {
 			matches = append(matches, path)
+			if limit > 0 && len(matches) >= limit {
+				return filepath.SkipAll
+			}
 		}
 		return nil
 	})
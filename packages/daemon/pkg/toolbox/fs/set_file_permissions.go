@@ -36,6 +36,11 @@ func SetFilePermissions(c *gin.Context) {
 		return
 	}
 
+	path, ok := checkPath(c, path)
+	if !ok {
+		return
+	}
+
 	// convert to absolute path and check existence
 	absPath, err := filepath.Abs(path)
 	if err != nil {
@@ -0,0 +1,644 @@
+package fs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/fsutil"
+)
+
+// GetManifest godoc
+//
+//	@Summary		Get the manifest of a directory
+//	@Description	List the files and symlinks below a directory with their size, mode and SHA-256 hash. Paths are slash separated and relative to the directory. Directories are implied by the paths of their entries. Files ignored by .gitignore and the .git directory are skipped unless noIgnore is set.
+//	@Tags			file-system
+//	@Produce		json
+//	@Param			path		query		string		true	"Directory path"
+//	@Param			include		query		[]string	false	"Only list files matching these globs"				collectionFormat(multi)
+//	@Param			exclude		query		[]string	false	"Skip files and directories matching these globs"	collectionFormat(multi)
+//	@Param			noIgnore	query		boolean		false	"Do not respect .gitignore files"
+//	@Success		200			{object}	Manifest
+//	@Router			/files/manifest [get]
+//
+//	@id				GetManifest
+func GetManifest(c *gin.Context) {
+	path := c.Query("path")
+	if path == "" {
+		c.AbortWithError(http.StatusBadRequest, errors.New("path is required"))
+		return
+	}
+
+	path, ok := checkPath(c, path)
+	if !ok {
+		return
+	}
+
+	root, err := filepath.Abs(path)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid path: %w", err))
+		return
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		if os.IsPermission(err) {
+			c.AbortWithError(http.StatusForbidden, err)
+			return
+		}
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	if !info.IsDir() {
+		c.AbortWithError(http.StatusBadRequest, errors.New("path must be a directory"))
+		return
+	}
+
+	filter := newWalkFilter(root, splitGlobs(c.QueryArray("include")), splitGlobs(c.QueryArray("exclude")), c.Query("noIgnore") == "true")
+
+	entries, err := buildManifest(c.Request.Context(), root, filter)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Manifest{Path: root, Entries: entries})
+}
+
+// GetMissingBlobs godoc
+//
+//	@Summary		Find the blobs missing for a sync
+//	@Description	Compare a manifest with the directory it would be materialized into and return the hashes of the file contents that are not available there yet. Only these blobs have to be uploaded to SyncFiles. Contents are found at the path of the entry or at any other path below the directory.
+//	@Tags			file-system
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		MissingBlobsRequest	true	"Manifest to compare"
+//	@Success		200		{object}	MissingBlobsResponse
+//	@Router			/files/sync/missing [post]
+//
+//	@id				GetMissingBlobs
+func GetMissingBlobs(c *gin.Context) {
+	var req MissingBlobsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	path, ok := checkPath(c, req.Path)
+	if !ok {
+		return
+	}
+
+	dest, err := filepath.Abs(path)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid path: %w", err))
+		return
+	}
+
+	// a directory that does not exist yet is planned as empty
+	root, err := filepath.EvalSymlinks(dest)
+	if os.IsNotExist(err) {
+		root, err = filepath.Clean(dest), nil
+	}
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	plan, err := newSyncPlan(root, req.Entries)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MissingBlobsResponse{Missing: plan.missing(nil)})
+}
+
+// SyncFiles godoc
+//
+//	@Summary		Materialize a manifest
+//	@Description	Make a directory match a manifest. The first part of the multipart body must be the manifest, followed by the blobs that GetMissingBlobs reported, each with its hash as file name. Contents already present below the directory are reused. When blobs are still missing nothing is written and 409 is returned with the missing hashes. Files are replaced atomically, one at a time.
+//	@Tags			file-system
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			manifest	formData	string	true	"SyncRequest as JSON"
+//	@Param			blob		formData	file	false	"Blob content, named by its hash (repeatable)"
+//	@Success		200			{object}	SyncResponse
+//	@Router			/files/sync [post]
+//
+//	@id				SyncFiles
+func SyncFiles(c *gin.Context) {
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, errors.New("invalid multipart form"))
+		return
+	}
+
+	part, err := reader.NextPart()
+	if err != nil || part.FormName() != "manifest" {
+		c.AbortWithError(http.StatusBadRequest, errors.New("the first part must be the manifest"))
+		return
+	}
+
+	var req SyncRequest
+	if err := json.NewDecoder(part).Decode(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid manifest: %w", err))
+		return
+	}
+
+	if req.Path == "" {
+		c.AbortWithError(http.StatusBadRequest, errors.New("path is required"))
+		return
+	}
+
+	path, ok := checkPath(c, req.Path)
+	if !ok {
+		return
+	}
+
+	dest, err := filepath.Abs(path)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid path: %w", err))
+		return
+	}
+
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		if os.IsPermission(err) {
+			c.AbortWithError(http.StatusForbidden, err)
+			return
+		}
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	// all containment checks are made against the real location
+	root, err := filepath.EvalSymlinks(dest)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	plan, err := newSyncPlan(root, req.Entries)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	staging, err := os.MkdirTemp(root, ".deck-sync-")
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	defer os.RemoveAll(staging)
+
+	needed := map[string]bool{}
+	for _, hash := range plan.missing(nil) {
+		needed[hash] = true
+	}
+
+	blobs := map[string]string{}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			c.AbortWithError(http.StatusBadRequest, fmt.Errorf("reading part: %w", err))
+			return
+		}
+
+		hash := part.FileName()
+		if part.FormName() != "blob" || !needed[hash] {
+			// unknown parts and blobs that are not needed are skipped
+			continue
+		}
+
+		blob, err := receiveBlob(staging, hash, part)
+		if err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		blobs[hash] = blob
+	}
+
+	if missing := plan.missing(blobs); len(missing) > 0 {
+		c.AbortWithStatusJSON(http.StatusConflict, SyncResponse{Path: dest, Missing: missing})
+		return
+	}
+
+	result, err := plan.apply(blobs)
+	if err == nil && req.Delete {
+		result.Deleted, err = plan.deleteExtra(staging)
+	}
+	if err == nil {
+		err = plan.removeEscapingLinks()
+	}
+	if err != nil {
+		if errors.Is(err, errUnsafeEntry) {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		if os.IsPermission(err) {
+			c.AbortWithError(http.StatusForbidden, err)
+			return
+		}
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	result.Path = dest
+	c.JSON(http.StatusOK, result)
+}
+
+// buildManifest lists the regular files and symlinks below root.
+func buildManifest(ctx context.Context, root string, filter *walkFilter) ([]ManifestEntry, error) {
+	entries := []ManifestEntry{}
+	err := filepath.WalkDir(root, func(path string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if filter.skipDir(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filter.skipFile(path) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		entry := ManifestEntry{
+			Path: filepath.ToSlash(rel),
+			Mode: fmt.Sprintf("%04o", info.Mode().Perm()),
+		}
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			entry.LinkTarget = &target
+		case info.Mode().IsRegular():
+			hash, err := fsutil.HashFile(path)
+			if err != nil {
+				return err
+			}
+			entry.Size = info.Size()
+			entry.Hash = hash
+		default:
+			// devices, sockets and pipes cannot be synced
+			return nil
+		}
+
+		entries = append(entries, entry)
+		return nil
+	})
+	return entries, err
+}
+
+// receiveBlob stores the content of r in dir, verifying that it has the
+// given hash.
+func receiveBlob(dir, hash string, r io.Reader) (string, error) {
+	path := filepath.Join(dir, hash)
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+
+	actual, copyErr := fsutil.Hash(io.TeeReader(r, f))
+	closeErr := f.Close()
+	if copyErr == nil {
+		copyErr = closeErr
+	}
+	if copyErr != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("blob %s: %w", hash, copyErr)
+	}
+
+	if actual != hash {
+		os.Remove(path)
+		return "", fmt.Errorf("blob %s: content has hash %s", hash, actual)
+	}
+	return path, nil
+}
+
+// syncEntry is a manifest entry resolved against the sync root.
+type syncEntry struct {
+	ManifestEntry
+	target string
+	// permission bits, zero when the manifest does not set them
+	mode os.FileMode
+	// whether the target already has the content of the entry
+	current bool
+}
+
+// syncPlan works out which entries of a manifest differ from the files
+// below root and where their contents can be found.
+type syncPlan struct {
+	root    string
+	entries []*syncEntry
+	// existing files below root by the hash of their content
+	sources map[string]string
+	// whether root does not exist yet
+	empty bool
+}
+
+func newSyncPlan(root string, manifest []ManifestEntry) (*syncPlan, error) {
+	p := &syncPlan{root: root, sources: map[string]string{}}
+	if _, err := os.Lstat(root); os.IsNotExist(err) {
+		p.empty = true
+	}
+	e := &archiveExtractor{root: root}
+	seen := map[string]bool{}
+	sizes := map[int64]bool{}
+
+	for _, m := range manifest {
+		entry, err := p.resolve(e, m)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Path, err)
+		}
+		if seen[entry.target] {
+			return nil, fmt.Errorf("%s: duplicate entry", m.Path)
+		}
+		seen[entry.target] = true
+
+		info, err := os.Lstat(entry.target)
+		switch {
+		case err != nil, p.empty:
+		case entry.LinkTarget != nil:
+			if info.Mode()&os.ModeSymlink != 0 {
+				target, err := os.Readlink(entry.target)
+				entry.current = err == nil && target == *entry.LinkTarget
+			}
+		case info.Mode().IsRegular() && info.Size() == entry.Size:
+			hash, err := fsutil.HashFile(entry.target)
+			if err != nil {
+				break
+			}
+			p.sources[hash] = entry.target
+			entry.current = hash == entry.Hash
+		}
+
+		if !entry.current && entry.LinkTarget == nil {
+			sizes[entry.Size] = true
+		}
+		p.entries = append(p.entries, entry)
+	}
+
+	// links may lead through each other, so they are resolved as if all of
+	// them were in place already
+	links := map[string]string{}
+	for _, entry := range p.entries {
+		if entry.LinkTarget != nil {
+			links[entry.target] = *entry.LinkTarget
+		}
+	}
+	for _, entry := range p.entries {
+		if entry.LinkTarget == nil {
+			continue
+		}
+		if err := checkLinkTarget(root, entry.target, *entry.LinkTarget, links); err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Path, err)
+		}
+	}
+
+	// look for renamed and copied files among the other files of the tree
+	if !p.empty && len(p.missing(nil)) > 0 {
+		p.findSources(sizes)
+	}
+	return p, nil
+}
+
+// resolve validates a manifest entry and maps it to its target below root.
+func (p *syncPlan) resolve(e *archiveExtractor, m ManifestEntry) (*syncEntry, error) {
+	target, err := e.target(m.Path)
+	if err != nil {
+		return nil, err
+	}
+	if target == p.root {
+		return nil, errors.New("invalid path")
+	}
+	if !p.empty {
+		if err := e.checkParents(target); err != nil {
+			return nil, err
+		}
+	}
+
+	entry := &syncEntry{ManifestEntry: m, target: target}
+	if m.Mode != "" {
+		mode, err := strconv.ParseUint(m.Mode, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mode: %w", err)
+		}
+		entry.mode = os.FileMode(mode).Perm()
+	}
+
+	if m.LinkTarget != nil {
+		// where the link leads is checked once all entries are known
+		if *m.LinkTarget == "" || filepath.IsAbs(filepath.FromSlash(*m.LinkTarget)) {
+			return nil, errUnsafeEntry
+		}
+		return entry, nil
+	}
+
+	if !fsutil.IsHash(m.Hash) {
+		return nil, fmt.Errorf("invalid hash %q", m.Hash)
+	}
+	if m.Size < 0 {
+		return nil, errors.New("invalid size")
+	}
+	return entry, nil
+}
+
+// findSources hashes the files below root whose size is in sizes and that
+// have not been hashed yet.
+func (p *syncPlan) findSources(sizes map[int64]bool) {
+	hashed := map[string]bool{}
+	for _, path := range p.sources {
+		hashed[path] = true
+	}
+
+	_ = filepath.WalkDir(p.root, func(path string, d iofs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() || hashed[path] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || !sizes[info.Size()] {
+			return nil
+		}
+		if hash, err := fsutil.HashFile(path); err == nil {
+			if _, ok := p.sources[hash]; !ok {
+				p.sources[hash] = path
+			}
+		}
+		return nil
+	})
+}
+
+// missing returns the hashes of changed entries whose content is neither in
+// blobs nor elsewhere below root.
+func (p *syncPlan) missing(blobs map[string]string) []string {
+	missing := []string{}
+	seen := map[string]bool{}
+	for _, entry := range p.entries {
+		if entry.current || entry.LinkTarget != nil || seen[entry.Hash] {
+			continue
+		}
+		seen[entry.Hash] = true
+		if _, ok := p.sources[entry.Hash]; ok {
+			continue
+		}
+		if _, ok := blobs[entry.Hash]; ok {
+			continue
+		}
+		missing = append(missing, entry.Hash)
+	}
+	return missing
+}
+
+// apply writes the changed entries. All new contents are staged next to
+// their targets before the first target is replaced, so files that serve as
+// the source of other entries are still intact while copying.
+func (p *syncPlan) apply(blobs map[string]string) (SyncResponse, error) {
+	var result SyncResponse
+	staged := map[*syncEntry]string{}
+	defer func() {
+		for _, tmp := range staged {
+			os.Remove(tmp)
+		}
+	}()
+
+	for _, entry := range p.entries {
+		if entry.current {
+			result.Unchanged++
+			if entry.LinkTarget == nil {
+				if info, err := os.Lstat(entry.target); err == nil && entry.mode != 0 && info.Mode().Perm() != entry.mode {
+					if err := os.Chmod(entry.target, entry.mode); err != nil {
+						return result, err
+					}
+				}
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(entry.target), 0755); err != nil {
+			return result, err
+		}
+
+		var tmp string
+		var err error
+		if entry.LinkTarget != nil {
+			tmp, err = fsutil.StageSymlink(entry.target, *entry.LinkTarget)
+		} else {
+			source, ok := blobs[entry.Hash]
+			if !ok {
+				source = p.sources[entry.Hash]
+			}
+			mode := entry.mode
+			if mode == 0 {
+				mode = 0644
+			}
+			tmp, err = fsutil.StageCopy(entry.target, source, mode)
+		}
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", entry.Path, err)
+		}
+		staged[entry] = tmp
+	}
+
+	for _, entry := range p.entries {
+		tmp, ok := staged[entry]
+		if !ok {
+			continue
+		}
+		if info, err := os.Lstat(entry.target); err == nil && info.IsDir() {
+			if err := os.RemoveAll(entry.target); err != nil {
+				return result, fmt.Errorf("%s: %w", entry.Path, err)
+			}
+		}
+		if err := os.Rename(tmp, entry.target); err != nil {
+			return result, fmt.Errorf("%s: %w", entry.Path, err)
+		}
+		delete(staged, entry)
+		result.Written++
+	}
+
+	return result, nil
+}
+
+// removeEscapingLinks checks the links of the plan again once they have been
+// written, and removes those that lead outside of root.
+func (p *syncPlan) removeEscapingLinks() error {
+	var links []string
+	for _, entry := range p.entries {
+		if entry.LinkTarget != nil {
+			links = append(links, entry.target)
+		}
+	}
+	return removeEscapingLinks(p.root, links)
+}
+
+// deleteExtra removes everything below root that is neither an entry nor a
+// directory leading to one. staging is left alone.
+func (p *syncPlan) deleteExtra(staging string) (int, error) {
+	keep := map[string]bool{}
+	for _, entry := range p.entries {
+		for path := entry.target; path != p.root && !keep[path]; path = filepath.Dir(path) {
+			keep[path] = true
+		}
+	}
+
+	deleted := 0
+	err := filepath.WalkDir(p.root, func(path string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if path == p.root || keep[path] {
+			return nil
+		}
+		if path == staging {
+			return filepath.SkipDir
+		}
+
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+		deleted++
+		if d.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return deleted, err
+}
@@ -0,0 +1,152 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/fsutil"
+)
+
+func writeTestFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSyncPlan(t *testing.T) {
+	src := t.TempDir()
+	writeTestFiles(t, src, map[string]string{
+		"same.txt":        "unchanged",
+		"moved/new.txt":   "moved content",
+		"changed.txt":     "new content",
+		"dir/created.txt": "created",
+	})
+	if err := os.Symlink("same.txt", filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := t.TempDir()
+	writeTestFiles(t, dest, map[string]string{
+		"same.txt":    "unchanged",
+		"old.txt":     "moved content",
+		"changed.txt": "old content",
+		"stale/x.txt": "stale",
+	})
+
+	manifest, err := buildManifest(context.Background(), src, newWalkFilter(src, nil, nil, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest) != 5 {
+		t.Fatalf("expected 5 manifest entries, got %d", len(manifest))
+	}
+
+	plan, err := newSyncPlan(dest, manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the moved file is found under its old name
+	missing := plan.missing(nil)
+	if len(missing) != 2 {
+		t.Fatalf("expected 2 missing blobs, got %v", missing)
+	}
+
+	blobs := map[string]string{}
+	for _, entry := range manifest {
+		for _, hash := range missing {
+			if entry.Hash == hash {
+				blobs[hash] = filepath.Join(src, filepath.FromSlash(entry.Path))
+			}
+		}
+	}
+	if len(plan.missing(blobs)) != 0 {
+		t.Fatal("expected no missing blobs after upload")
+	}
+
+	result, err := plan.apply(blobs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Written != 4 || result.Unchanged != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	result.Deleted, err = plan.deleteExtra("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Deleted != 2 {
+		t.Fatalf("expected old.txt and stale to be deleted, got %d", result.Deleted)
+	}
+
+	synced, err := buildManifest(context.Background(), dest, newWalkFilter(dest, nil, nil, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(synced) != len(manifest) {
+		t.Fatalf("expected %d entries after sync, got %d", len(manifest), len(synced))
+	}
+	for i := range manifest {
+		if synced[i].Path != manifest[i].Path || synced[i].Hash != manifest[i].Hash {
+			t.Errorf("entry %d differs: %+v != %+v", i, synced[i], manifest[i])
+		}
+	}
+}
+
+func TestSyncPlanRejectsUnsafeEntries(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	hash := fsutil.HashBytes([]byte("x"))
+	escapingLink := "../../etc"
+
+	for _, entry := range []ManifestEntry{
+		{Path: "../evil", Hash: hash, Size: 1},
+		{Path: "/etc/passwd", Hash: hash, Size: 1},
+		{Path: "escape/evil", Hash: hash, Size: 1},
+		{Path: "link", LinkTarget: &escapingLink},
+		{Path: "bad-hash", Hash: "xyz", Size: 1},
+	} {
+		if _, err := newSyncPlan(root, []ManifestEntry{entry}); err == nil {
+			t.Errorf("expected %s to be rejected", entry.Path)
+		}
+	}
+
+	// each link stays inside on its own, but x leads through d/up
+	up, chained := "..", "d/up/.."
+	throughExisting := "escape/.."
+	for name, manifest := range map[string][]ManifestEntry{
+		"chained links": {
+			{Path: "d/up", LinkTarget: &up},
+			{Path: "x", LinkTarget: &chained},
+		},
+		"existing link": {{Path: "y", LinkTarget: &throughExisting}},
+	} {
+		if _, err := newSyncPlan(root, manifest); !errors.Is(err, errUnsafeEntry) {
+			t.Errorf("%s: expected unsafe entry error, got %v", name, err)
+		}
+	}
+
+	inside := "."
+	if _, err := newSyncPlan(root, []ManifestEntry{
+		{Path: "d/up", LinkTarget: &inside},
+		{Path: "x", LinkTarget: &chained},
+	}); err != nil {
+		t.Errorf("expected links leading through each other inside root to be accepted, got %v", err)
+	}
+}
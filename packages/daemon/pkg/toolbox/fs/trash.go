@@ -0,0 +1,461 @@
+package fs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/fsutil"
+	"github.com/cofy-x/deck/packages/core-go/pkg/log"
+)
+
+var (
+	errTrashDisabled    = errors.New("trash is not configured")
+	errTrashItemMissing = errors.New("trash item not found")
+)
+
+// trash keeps soft deleted files. Each item is stored as files/<id>, with its
+// metadata in info/<id>.json. Items are removed once they are older than
+// maxAge, and the oldest ones once there are more than maxItems. Zero limits
+// are not enforced.
+type trash struct {
+	dir      string
+	maxAge   time.Duration
+	maxItems int
+
+	mu sync.Mutex
+}
+
+var fileTrash *trash
+
+// SetTrash configures the trash soft deletes move files into. It should be
+// placed on the same filesystem as the workspace, moving into it is a rename
+// then.
+func SetTrash(dir string, maxAge time.Duration, maxItems int) {
+	fileTrash = &trash{dir: dir, maxAge: maxAge, maxItems: maxItems}
+}
+
+// ListTrash godoc
+//
+//	@Summary		List the trash
+//	@Description	List the files and directories in the trash, most recently deleted first
+//	@Tags			file-system
+//	@Produce		json
+//	@Success		200	{array}	TrashItem
+//	@Router			/files/trash [get]
+//
+//	@id				ListTrash
+func ListTrash(c *gin.Context) {
+	if fileTrash == nil {
+		c.AbortWithError(http.StatusServiceUnavailable, errTrashDisabled)
+		return
+	}
+
+	items, err := fileTrash.list()
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// RestoreTrashItem godoc
+//
+//	@Summary		Restore a trash item
+//	@Description	Move a file or directory out of the trash, back to its original path or to the given path. Missing parent directories are created.
+//	@Tags			file-system
+//	@Param			itemId	path	string	true	"Trash item ID"
+//	@Param			path	query	string	false	"Path to restore to (default: the original path)"
+//	@Success		200
+//	@Router			/files/trash/{itemId}/restore [post]
+//
+//	@id				RestoreTrashItem
+func RestoreTrashItem(c *gin.Context) {
+	if fileTrash == nil {
+		c.AbortWithError(http.StatusServiceUnavailable, errTrashDisabled)
+		return
+	}
+
+	item, err := fileTrash.get(c.Param("itemId"))
+	if err != nil {
+		if errors.Is(err, errTrashItemMissing) {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	path := item.OriginalPath
+	if p := c.Query("path"); p != "" {
+		path = p
+	}
+
+	path, ok := checkPathNoFollow(c, path)
+	if !ok {
+		return
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid path: %w", err))
+		return
+	}
+
+	if err := fileTrash.restore(item, absPath); err != nil {
+		if errors.Is(err, errDestinationExists) {
+			c.AbortWithError(http.StatusConflict, err)
+			return
+		}
+		if errors.Is(err, errTrashItemMissing) {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		if os.IsPermission(err) {
+			c.AbortWithError(http.StatusForbidden, err)
+			return
+		}
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// PurgeTrashItem godoc
+//
+//	@Summary		Purge a trash item
+//	@Description	Permanently delete a file or directory from the trash
+//	@Tags			file-system
+//	@Param			itemId	path	string	true	"Trash item ID"
+//	@Success		204
+//	@Router			/files/trash/{itemId} [delete]
+//
+//	@id				PurgeTrashItem
+func PurgeTrashItem(c *gin.Context) {
+	if fileTrash == nil {
+		c.AbortWithError(http.StatusServiceUnavailable, errTrashDisabled)
+		return
+	}
+
+	item, err := fileTrash.get(c.Param("itemId"))
+	if err != nil {
+		if errors.Is(err, errTrashItemMissing) {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := fileTrash.purge(item.Id); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// PurgeTrash godoc
+//
+//	@Summary		Empty the trash
+//	@Description	Permanently delete everything in the trash
+//	@Tags			file-system
+//	@Success		204
+//	@Router			/files/trash [delete]
+//
+//	@id				PurgeTrash
+func PurgeTrash(c *gin.Context) {
+	if fileTrash == nil {
+		c.AbortWithError(http.StatusServiceUnavailable, errTrashDisabled)
+		return
+	}
+
+	items, err := fileTrash.list()
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	for _, item := range items {
+		if err := fileTrash.purge(item.Id); err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (t *trash) dataPath(id string) string {
+	return filepath.Join(t.dir, "files", id)
+}
+
+func (t *trash) infoPath(id string) string {
+	return filepath.Join(t.dir, "info", id+".json")
+}
+
+// put moves path into the trash.
+func (t *trash) put(path string) (*TrashItem, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if fsutil.Within(absPath, t.dir) || fsutil.Within(t.dir, absPath) {
+		return nil, fmt.Errorf("cannot move %s to the trash", absPath)
+	}
+
+	info, err := os.Lstat(absPath)
+	if err != nil {
+		return nil, err
+	}
+
+	item := &TrashItem{
+		Id:           uuid.NewString(),
+		OriginalPath: absPath,
+		IsDir:        info.IsDir(),
+		Size:         treeSize(absPath, info),
+		DeletedAt:    time.Now(),
+	}
+
+	for _, dir := range []string{filepath.Dir(t.dataPath(item.Id)), filepath.Dir(t.infoPath(item.Id))} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// the metadata is written first, an item without data is skipped and
+	// cleaned up later, data without metadata could never be found again
+	if err := writeFileAtomic(t.infoPath(item.Id), data, nil); err != nil {
+		return nil, err
+	}
+	if err := moveEntry(absPath, t.dataPath(item.Id)); err != nil {
+		os.Remove(t.infoPath(item.Id))
+		return nil, err
+	}
+
+	t.prune()
+	return item, nil
+}
+
+func (t *trash) get(id string) (*TrashItem, error) {
+	// ids are generated by put, anything else can't name an item
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errTrashItemMissing
+	}
+
+	data, err := os.ReadFile(t.infoPath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errTrashItemMissing
+		}
+		return nil, err
+	}
+
+	var item TrashItem
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// list returns the items in the trash, most recently deleted first.
+func (t *trash) list() ([]TrashItem, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune()
+	return t.items()
+}
+
+func (t *trash) items() ([]TrashItem, error) {
+	entries, err := os.ReadDir(filepath.Join(t.dir, "info"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	items := make([]TrashItem, 0, len(entries))
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok {
+			continue
+		}
+		item, err := t.get(id)
+		if err != nil {
+			continue
+		}
+		if _, err := os.Lstat(t.dataPath(id)); err != nil {
+			continue
+		}
+		items = append(items, *item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items, nil
+}
+
+func (t *trash) restore(item *TrashItem, path string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, err := os.Lstat(t.dataPath(item.Id)); err != nil {
+		if os.IsNotExist(err) {
+			return errTrashItemMissing
+		}
+		return err
+	}
+
+	if _, err := os.Lstat(path); err == nil {
+		return fmt.Errorf("%w: %s", errDestinationExists, path)
+	}
+
+	// the parent may have been deleted as well
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := moveEntry(t.dataPath(item.Id), path); err != nil {
+		return err
+	}
+	return os.Remove(t.infoPath(item.Id))
+}
+
+func (t *trash) purge(id string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.remove(id)
+}
+
+func (t *trash) remove(id string) error {
+	if err := removeAllWritable(t.dataPath(id)); err != nil {
+		return err
+	}
+	if err := os.Remove(t.infoPath(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// prune removes expired items, and the oldest items beyond maxItems. It is
+// called with mu held.
+func (t *trash) prune() {
+	items, err := t.items()
+	if err != nil {
+		log.Warnf("failed to list the trash: %v", err)
+		return
+	}
+
+	for i, item := range items {
+		expired := t.maxAge > 0 && time.Since(item.DeletedAt) > t.maxAge
+		if expired || (t.maxItems > 0 && i >= t.maxItems) {
+			if err := t.remove(item.Id); err != nil {
+				log.Warnf("failed to remove %s from the trash: %v", item.OriginalPath, err)
+			}
+		}
+	}
+
+	// metadata left behind by an interrupted put
+	entries, _ := os.ReadDir(filepath.Join(t.dir, "info"))
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok {
+			continue
+		}
+		if _, err := os.Lstat(t.dataPath(id)); os.IsNotExist(err) {
+			if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) > time.Minute {
+				os.Remove(t.infoPath(id))
+			}
+		}
+	}
+}
+
+// moveEntry renames src to dst, falling back to copying and removing src
+// when they are on different filesystems.
+func moveEntry(src, dst string) error {
+	err := os.Rename(src, dst)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	cp := &copier{preserveMode: true}
+	switch {
+	case info.IsDir():
+		err = cp.copyDir(src, dst)
+	case info.Mode()&os.ModeSymlink != 0:
+		err = cp.copySymlink(src, dst)
+	case info.Mode().IsRegular():
+		err = cp.copyFile(src, dst, info)
+	default:
+		return fmt.Errorf("cannot move %s across filesystems", src)
+	}
+	if err != nil {
+		removeAllWritable(dst)
+		return err
+	}
+	return removeAllWritable(src)
+}
+
+// removeAllWritable is os.RemoveAll that also removes the contents of
+// read-only directories.
+func removeAllWritable(path string) error {
+	err := os.RemoveAll(path)
+	if err == nil || !os.IsPermission(err) {
+		return err
+	}
+
+	_ = filepath.WalkDir(path, func(p string, d iofs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			_ = os.Chmod(p, 0700)
+		}
+		return nil
+	})
+	return os.RemoveAll(path)
+}
+
+// treeSize returns the size of a file, or the total size of the files below
+// a directory.
+func treeSize(path string, info os.FileInfo) int64 {
+	if !info.IsDir() {
+		return info.Size()
+	}
+
+	var size int64
+	_ = filepath.WalkDir(path, func(_ string, d iofs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
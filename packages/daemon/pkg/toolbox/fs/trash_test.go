@@ -0,0 +1,92 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTrashRestore(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"dir/a.txt":     "a",
+		"dir/sub/b.txt": "bb",
+	})
+	tr := &trash{dir: filepath.Join(t.TempDir(), "trash")}
+
+	dir := filepath.Join(root, "dir")
+	item, err := tr.put(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !item.IsDir || item.Size != 3 || item.OriginalPath != dir {
+		t.Fatalf("unexpected item %+v", item)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatal("expected dir to be moved to the trash")
+	}
+
+	items, err := tr.list()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Id != item.Id {
+		t.Fatalf("unexpected items %+v", items)
+	}
+
+	writeTestFiles(t, root, map[string]string{"dir/new.txt": "new"})
+	if err := tr.restore(item, dir); !errors.Is(err, errDestinationExists) {
+		t.Fatalf("expected errDestinationExists, got %v", err)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tr.restore(item, dir); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "sub", "b.txt")); string(data) != "bb" {
+		t.Fatalf("unexpected content %q", data)
+	}
+	if items, _ := tr.list(); len(items) != 0 {
+		t.Fatalf("expected an empty trash, got %+v", items)
+	}
+
+	if _, err := tr.put(filepath.Dir(tr.dir)); err == nil {
+		t.Fatal("expected moving the trash into itself to fail")
+	}
+}
+
+func TestTrashRetention(t *testing.T) {
+	root := t.TempDir()
+	tr := &trash{dir: filepath.Join(t.TempDir(), "trash"), maxAge: time.Hour, maxItems: 2}
+
+	var ids []string
+	for _, name := range []string{"a", "b", "c"} {
+		writeTestFiles(t, root, map[string]string{name: name})
+		item, err := tr.put(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, item.Id)
+	}
+
+	// the oldest item is dropped once there are more than maxItems
+	items, err := tr.list()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Id != ids[2] || items[1].Id != ids[1] {
+		t.Fatalf("unexpected items %+v", items)
+	}
+	if _, err := os.Lstat(tr.dataPath(ids[0])); !os.IsNotExist(err) {
+		t.Fatal("expected the data of the dropped item to be removed")
+	}
+
+	tr.maxAge = time.Nanosecond
+	if items, _ := tr.list(); len(items) != 0 {
+		t.Fatalf("expected expired items to be removed, got %+v", items)
+	}
+}
@@ -0,0 +1,147 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultTreeDepth = 3
+
+// GetFileTree godoc
+//
+//	@Summary		Get a directory tree
+//	@Description	Get the nested tree of a directory down to the given depth. Directory sizes and counts include everything below them, also beyond the depth limit. Symlinks are reported with their target and not followed. Entries ignored by .gitignore are left out unless noIgnore is set.
+//	@Tags			file-system
+//	@Produce		json
+//	@Param			path		query		string		false	"Directory path (defaults to working directory)"
+//	@Param			depth		query		integer		false	"Number of levels to list below the directory (default: 3)"
+//	@Param			include		query		[]string	false	"Only list files matching these globs"				collectionFormat(multi)
+//	@Param			exclude		query		[]string	false	"Skip files and directories matching these globs"	collectionFormat(multi)
+//	@Param			noIgnore	query		boolean		false	"Do not respect .gitignore files"
+//	@Success		200			{object}	FileTreeNode
+//	@Router			/files/tree [get]
+//
+//	@id				GetFileTree
+func GetFileTree(c *gin.Context) {
+	path := c.Query("path")
+	if path == "" {
+		path = "."
+	}
+
+	path, ok := checkPath(c, path)
+	if !ok {
+		return
+	}
+
+	depth := defaultTreeDepth
+	if value := c.Query("depth"); value != "" {
+		n, err := parseNonNegativeInt(value)
+		if err != nil {
+			c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid depth: %w", err))
+			return
+		}
+		depth = n
+	}
+
+	root, err := filepath.Abs(path)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid path: %w", err))
+		return
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		if os.IsPermission(err) {
+			c.AbortWithError(http.StatusForbidden, err)
+			return
+		}
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	if !info.IsDir() {
+		c.AbortWithError(http.StatusBadRequest, errors.New("path must be a directory"))
+		return
+	}
+
+	filter := newWalkFilter(root, splitGlobs(c.QueryArray("include")), splitGlobs(c.QueryArray("exclude")), c.Query("noIgnore") == "true")
+
+	tree := buildTree(root, info, depth, filter)
+	tree.Name = filepath.Base(root)
+	c.JSON(http.StatusOK, tree)
+}
+
+// buildTree returns the node of the directory at path. Children are listed
+// down to depth levels, deeper entries only count towards the totals.
+func buildTree(path string, info os.FileInfo, depth int, filter *walkFilter) FileTreeNode {
+	node := FileTreeNode{
+		Name:  info.Name(),
+		Path:  path,
+		IsDir: true,
+	}
+
+	filter.skipDir(path)
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		node.Error = err.Error()
+		return node
+	}
+
+	for _, entry := range entries {
+		childPath := filepath.Join(path, entry.Name())
+
+		childInfo, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		var child FileTreeNode
+		if entry.IsDir() {
+			if filter.excludedDir(childPath) {
+				continue
+			}
+			child = buildTree(childPath, childInfo, depth-1, filter)
+			node.DirCount += child.DirCount + 1
+			node.FileCount += child.FileCount
+		} else {
+			if filter.skipFile(childPath) {
+				continue
+			}
+			child = FileTreeNode{
+				Name: entry.Name(),
+				Path: childPath,
+				Size: childInfo.Size(),
+			}
+			if childInfo.Mode()&os.ModeSymlink != 0 {
+				child.IsSymlink = true
+				child.LinkTarget, _ = os.Readlink(childPath)
+			}
+			node.FileCount++
+		}
+		node.Size += child.Size
+
+		if depth > 0 {
+			node.Children = append(node.Children, child)
+		} else {
+			node.Truncated = true
+		}
+	}
+
+	// directories first, then by name
+	sort.SliceStable(node.Children, func(i, j int) bool {
+		return node.Children[i].IsDir && !node.Children[j].IsDir
+	})
+
+	return node
+}
@@ -0,0 +1,68 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildTreeBeyondDepth(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"a.txt":              "abc",
+		"sub/b.txt":          "hello",
+		"sub/deep/c.txt":     "1234567",
+		"sub/deep/more/d.md": "x",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree := buildTree(root, info, 1, newWalkFilter(root, nil, nil, true))
+
+	if tree.Size != 16 || tree.FileCount != 4 || tree.DirCount != 3 {
+		t.Fatalf("unexpected root totals: size %d, files %d, dirs %d", tree.Size, tree.FileCount, tree.DirCount)
+	}
+	if tree.Truncated {
+		t.Fatal("expected the root to list its children")
+	}
+	if len(tree.Children) != 2 || tree.Children[0].Name != "sub" || tree.Children[1].Name != "a.txt" {
+		t.Fatalf("expected sub before a.txt, got %+v", tree.Children)
+	}
+
+	sub := tree.Children[0]
+	if !sub.Truncated || len(sub.Children) != 0 {
+		t.Fatalf("expected sub to be truncated without children, got %+v", sub)
+	}
+	if sub.Size != 13 || sub.FileCount != 3 || sub.DirCount != 2 {
+		t.Fatalf("unexpected sub totals: size %d, files %d, dirs %d", sub.Size, sub.FileCount, sub.DirCount)
+	}
+
+	tree = buildTree(root, info, 0, newWalkFilter(root, nil, nil, true))
+	if !tree.Truncated || len(tree.Children) != 0 || tree.Size != 16 || tree.FileCount != 4 {
+		t.Fatalf("unexpected tree at depth 0: %+v", tree)
+	}
+}
+
+func TestBuildTreeEmptyDirectory(t *testing.T) {
+	root := t.TempDir()
+	info, err := os.Stat(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree := buildTree(root, info, 0, newWalkFilter(root, nil, nil, true))
+	if tree.Truncated || tree.Size != 0 || tree.FileCount != 0 || tree.DirCount != 0 {
+		t.Fatalf("unexpected tree for an empty directory: %+v", tree)
+	}
+}
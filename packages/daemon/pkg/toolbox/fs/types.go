@@ -1,5 +1,7 @@
 package fs
 
+import "time"
+
 type FileInfo struct {
 	Name        string `json:"name" validate:"required"`
 	Size        int64  `json:"size" validate:"required"`
@@ -9,30 +11,271 @@ type FileInfo struct {
 	Owner       string `json:"owner" validate:"required"`
 	Group       string `json:"group" validate:"required"`
 	Permissions string `json:"permissions" validate:"required"`
+	IsSymlink   bool   `json:"isSymlink" validate:"required"`
+	LinkTarget  string `json:"linkTarget,omitempty" validate:"optional"`
+	// Content details of a regular file, only returned on request
+	Details *FileDetails `json:"details,omitempty" validate:"optional"`
 } //	@name	FileInfo
 
+type FileDetails struct {
+	MimeType string `json:"mimeType" validate:"required"`
+	IsBinary bool   `json:"isBinary" validate:"required"`
+	// ascii, utf-8, utf-8-bom, utf-16le, utf-16be or unknown. Not set for binary files.
+	Encoding string `json:"encoding,omitempty" validate:"optional"`
+	// lf, crlf, cr, mixed or none. Not set for binary files.
+	LineEnding string `json:"lineEnding,omitempty" validate:"optional"`
+	// Not set for binary files
+	LineCount *int   `json:"lineCount,omitempty" validate:"optional"`
+	Sha256    string `json:"sha256" validate:"required"`
+} //	@name	FileDetails
+
 type ReplaceRequest struct {
 	Files    []string `json:"files" validate:"required"`
 	Pattern  string   `json:"pattern" validate:"required"`
 	NewValue *string  `json:"newValue" validate:"required"`
+	// Treat pattern as a regular expression; newValue may reference groups as $1 or ${name}
+	Regex bool `json:"regex,omitempty" validate:"optional"`
+	// Maximum number of replacements per file, unlimited when not set
+	MaxReplacements *int `json:"maxReplacements,omitempty" validate:"optional"`
+	// Compute the changes and return a diff without writing any file
+	DryRun bool `json:"dryRun,omitempty" validate:"optional"`
 } //	@name	ReplaceRequest
 
 type ReplaceResult struct {
-	File    string `json:"file"`
-	Success bool   `json:"success"`
-	Error   string `json:"error,omitempty"`
+	File         string `json:"file"`
+	Success      bool   `json:"success"`
+	Error        string `json:"error,omitempty"`
+	Replacements int    `json:"replacements"`
+	// Unified diff of the change, only set for dry runs
+	Diff *string `json:"diff,omitempty"`
 } //	@name	ReplaceResult
 
 type Match struct {
 	File    string `json:"file" validate:"required"`
 	Line    int    `json:"line" validate:"required"`
 	Content string `json:"content" validate:"required"`
+	// 1-based byte offset of the first match on the line
+	Column int `json:"column" validate:"required"`
+	// 1-based byte offset just past the end of the first match
+	EndColumn     int      `json:"endColumn" validate:"required"`
+	ContextBefore []string `json:"contextBefore,omitempty" validate:"optional"`
+	ContextAfter  []string `json:"contextAfter,omitempty" validate:"optional"`
 } //	@name	Match
 
 type SearchFilesResponse struct {
 	Files []string `json:"files" validate:"required"`
 } //	@name	SearchFilesResponse
 
+type FuzzyMatch struct {
+	Path string `json:"path" validate:"required"`
+	// Path relative to the search directory, the text the query was matched against
+	RelativePath string `json:"relativePath" validate:"required"`
+	Score        int    `json:"score" validate:"required"`
+	// Indexes of the matched characters in relativePath, counted in unicode code points
+	Positions []int `json:"positions" validate:"required"`
+} //	@name	FuzzyMatch
+
+type FuzzySearchResponse struct {
+	Matches []FuzzyMatch `json:"matches" validate:"required"`
+	// Number of matching files, including those cut off by the limit
+	Total int `json:"total" validate:"required"`
+} //	@name	FuzzySearchResponse
+
 type FilesDownloadRequest struct {
 	Paths []string `json:"paths" validate:"required"`
 } //	@name	FilesDownloadRequest
+
+type FileWatchEvent struct {
+	// One of create, modify, delete, rename or error
+	Type  string    `json:"type" validate:"required"`
+	Path  string    `json:"path,omitempty" validate:"optional"`
+	IsDir bool      `json:"isDir" validate:"required"`
+	Time  time.Time `json:"time" validate:"required"`
+	Error string    `json:"error,omitempty" validate:"optional"`
+} //	@name	FileWatchEvent
+
+type CreateUploadSessionRequest struct {
+	Path string `json:"path" validate:"required"`
+	// Total size in bytes, when known in advance
+	Size *int64 `json:"size,omitempty" validate:"optional"`
+	// Octal permission mode of the final file (default: 0644, or the mode of the file being replaced)
+	Mode *string `json:"mode,omitempty" validate:"optional"`
+} //	@name	CreateUploadSessionRequest
+
+type UploadSession struct {
+	UploadId string `json:"uploadId" validate:"required"`
+	Path     string `json:"path" validate:"required"`
+	// Number of bytes received so far, the next chunk must start here
+	Offset    int64     `json:"offset" validate:"required"`
+	Size      *int64    `json:"size,omitempty" validate:"optional"`
+	CreatedAt time.Time `json:"createdAt" validate:"required"`
+	UpdatedAt time.Time `json:"updatedAt" validate:"required"`
+} //	@name	UploadSession
+
+type CompleteUploadRequest struct {
+	// Hex encoded SHA-256 of the whole file, optionally prefixed with "sha256:"
+	Checksum string `json:"checksum" validate:"required"`
+} //	@name	CompleteUploadRequest
+
+type ExtractResult struct {
+	Path        string `json:"path" validate:"required"`
+	Files       int    `json:"files" validate:"required"`
+	Directories int    `json:"directories" validate:"required"`
+	Symlinks    int    `json:"symlinks" validate:"required"`
+} //	@name	ExtractResult
+
+type PatchRequest struct {
+	// Unified diff, may contain several files
+	Patch string `json:"patch" validate:"required"`
+	// Directory relative file names in the patch are resolved against, defaults to the working directory
+	Path *string `json:"path,omitempty" validate:"optional"`
+	// Number of leading path components to strip from file names, like patch -p. Defaults to 1 when all names carry git style a/ and b/ prefixes, 0 otherwise
+	Strip *int `json:"strip,omitempty" validate:"optional"`
+	// Maximum number of context lines ignored at each end of a hunk that does not match exactly (default: 2)
+	Fuzz *int `json:"fuzz,omitempty" validate:"optional"`
+	// Check the patch and return the resulting diffs without writing any file
+	DryRun bool `json:"dryRun,omitempty" validate:"optional"`
+} //	@name	PatchRequest
+
+type PatchResponse struct {
+	// Whether the changes were written, false for dry runs and conflicts
+	Applied bool          `json:"applied" validate:"required"`
+	Files   []PatchResult `json:"files" validate:"required"`
+} //	@name	PatchResponse
+
+type PatchResult struct {
+	File    string `json:"file"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+	// One of create, modify, delete or rename
+	Operation string            `json:"operation"`
+	Hunks     []PatchHunkResult `json:"hunks"`
+	// Unified diff of the change, only set for dry runs
+	Diff *string `json:"diff,omitempty"`
+} //	@name	PatchResult
+
+type PatchHunkResult struct {
+	// 1-based index of the hunk within its file
+	Hunk    int  `json:"hunk" validate:"required"`
+	Applied bool `json:"applied" validate:"required"`
+	// 1-based line where the hunk matched, or where it was expected
+	Line int `json:"line" validate:"required"`
+	// Distance in lines from the position in the hunk header
+	Offset int `json:"offset" validate:"required"`
+	// Number of context lines ignored at each end of the hunk
+	Fuzz   int    `json:"fuzz" validate:"required"`
+	Reason string `json:"reason,omitempty" validate:"optional"`
+	// Lines the hunk expected to find, for hunks that did not apply
+	Expected []string `json:"expected,omitempty" validate:"optional"`
+	// Lines found at the expected position, for hunks that did not apply
+	Actual []string `json:"actual,omitempty" validate:"optional"`
+} //	@name	PatchHunkResult
+
+type FileLinesResponse struct {
+	Path string `json:"path" validate:"required"`
+	// 1-based number of the first returned line
+	Start int `json:"start" validate:"required"`
+	// 1-based number of the last returned line, start - 1 when no line was returned
+	End        int      `json:"end" validate:"required"`
+	TotalLines int      `json:"totalLines" validate:"required"`
+	Lines      []string `json:"lines" validate:"required"`
+	// SHA-256 of the whole file, to be passed as expectedHash when editing it
+	Hash string `json:"hash" validate:"required"`
+} //	@name	FileLinesResponse
+
+type EditLinesRequest struct {
+	Path string `json:"path" validate:"required"`
+	// One of replace, insert or delete
+	Operation string `json:"operation" validate:"required"`
+	// 1-based first line of the range. For insert, the line the new lines are inserted before; totalLines + 1 appends
+	Start int `json:"start" validate:"required"`
+	// 1-based last line of the range for replace and delete, defaults to start
+	End *int `json:"end,omitempty" validate:"optional"`
+	// Lines to insert or to replace the range with, without line endings
+	Lines []string `json:"lines,omitempty" validate:"optional"`
+	// SHA-256 the file must still have, otherwise the edit fails with 409
+	ExpectedHash *string `json:"expectedHash,omitempty" validate:"optional"`
+} //	@name	EditLinesRequest
+
+type EditLinesResponse struct {
+	Path       string `json:"path" validate:"required"`
+	TotalLines int    `json:"totalLines" validate:"required"`
+	// SHA-256 of the file after the edit
+	Hash string `json:"hash" validate:"required"`
+} //	@name	EditLinesResponse
+
+type FileTreeNode struct {
+	Name       string `json:"name" validate:"required"`
+	Path       string `json:"path" validate:"required"`
+	IsDir      bool   `json:"isDir" validate:"required"`
+	IsSymlink  bool   `json:"isSymlink,omitempty" validate:"optional"`
+	LinkTarget string `json:"linkTarget,omitempty" validate:"optional"`
+	// Size of a file, or the total size of the files below a directory
+	Size int64 `json:"size" validate:"required"`
+	// Number of files below a directory, at any depth
+	FileCount int `json:"fileCount,omitempty" validate:"optional"`
+	// Number of directories below a directory, at any depth
+	DirCount int `json:"dirCount,omitempty" validate:"optional"`
+	// Whether the children of the directory were left out because of the depth limit
+	Truncated bool           `json:"truncated,omitempty" validate:"optional"`
+	Children  []FileTreeNode `json:"children,omitempty" validate:"optional"`
+	// Set when the directory could not be read
+	Error string `json:"error,omitempty" validate:"optional"`
+} //	@name	FileTreeNode
+
+type ManifestEntry struct {
+	// Slash separated path relative to the manifest root
+	Path string `json:"path" validate:"required"`
+	Size int64  `json:"size" validate:"optional"`
+	// Octal permission mode, e.g. 0644
+	Mode string `json:"mode" validate:"optional"`
+	// Hex encoded SHA-256 of the content, not set for symlinks
+	Hash string `json:"hash,omitempty" validate:"optional"`
+	// Target of a symlink
+	LinkTarget *string `json:"linkTarget,omitempty" validate:"optional"`
+} //	@name	ManifestEntry
+
+type Manifest struct {
+	Path    string          `json:"path" validate:"required"`
+	Entries []ManifestEntry `json:"entries" validate:"required"`
+} //	@name	Manifest
+
+type MissingBlobsRequest struct {
+	// Directory the manifest would be materialized into
+	Path    string          `json:"path" validate:"required"`
+	Entries []ManifestEntry `json:"entries" validate:"optional"`
+} //	@name	MissingBlobsRequest
+
+type MissingBlobsResponse struct {
+	// Hashes of the blobs that have to be uploaded
+	Missing []string `json:"missing" validate:"required"`
+} //	@name	MissingBlobsResponse
+
+type SyncRequest struct {
+	// Directory to materialize the manifest into, created if missing
+	Path    string          `json:"path" validate:"required"`
+	Entries []ManifestEntry `json:"entries" validate:"optional"`
+	// Remove files and directories below path that are not in the manifest
+	Delete bool `json:"delete,omitempty" validate:"optional"`
+} //	@name	SyncRequest
+
+type SyncResponse struct {
+	Path string `json:"path" validate:"required"`
+	// Number of files and symlinks that were written
+	Written int `json:"written" validate:"required"`
+	// Number of entries that were already up to date
+	Unchanged int `json:"unchanged" validate:"required"`
+	// Number of files and directories that were removed
+	Deleted int `json:"deleted" validate:"required"`
+	// Hashes of blobs that were neither uploaded nor found on disk
+	Missing []string `json:"missing,omitempty" validate:"optional"`
+} //	@name	SyncResponse
+
+type TrashItem struct {
+	Id           string `json:"id" validate:"required"`
+	OriginalPath string `json:"originalPath" validate:"required"`
+	IsDir        bool   `json:"isDir" validate:"required"`
+	// Size of the file, or the total size of the files below a directory
+	Size      int64     `json:"size" validate:"required"`
+	DeletedAt time.Time `json:"deletedAt" validate:"required"`
+} //	@name	TrashItem
@@ -26,6 +26,11 @@ func UploadFile(c *gin.Context) {
 		return
 	}
 
+	path, ok := checkPath(c, path)
+	if !ok {
+		return
+	}
+
 	file, err := c.FormFile("file")
 	if err != nil {
 		c.AbortWithError(http.StatusBadRequest, err)
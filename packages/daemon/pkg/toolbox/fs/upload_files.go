@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/cofy-x/deck/apps/daemon/pkg/workspace"
 	"github.com/gin-gonic/gin"
 )
 
@@ -63,14 +64,20 @@ func UploadFiles(c *gin.Context) {
 				continue
 			}
 
-			if d := filepath.Dir(dest); d != "" {
+			checked, err := workspace.Check(dest)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", dest, err))
+				continue
+			}
+
+			if d := filepath.Dir(checked); d != "" {
 				if err := os.MkdirAll(d, 0o755); err != nil {
 					errs = append(errs, fmt.Sprintf("%s: mkdir %s: %v", dest, d, err))
 					continue
 				}
 			}
 
-			f, err := os.Create(dest)
+			f, err := os.Create(checked)
 			if err != nil {
 				errs = append(errs, fmt.Sprintf("%s: create: %v", dest, err))
 				continue
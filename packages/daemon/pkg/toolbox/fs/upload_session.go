@@ -0,0 +1,453 @@
+package fs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	cmap "github.com/orcaman/concurrent-map/v2"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/fsutil"
+	"github.com/cofy-x/deck/packages/core-go/pkg/log"
+)
+
+// uploadSessionTTL is how long an upload session may stay idle before it is
+// discarded together with its partial data.
+const uploadSessionTTL = 24 * time.Hour
+
+// uploadSession is a resumable upload. Chunks are appended to a hidden
+// partial file next to the destination, which is renamed into place once the
+// upload is completed.
+type uploadSession struct {
+	info     UploadSession
+	partPath string
+	mode     os.FileMode
+
+	// serializes chunk writes and completion
+	mu sync.Mutex
+}
+
+// uploadSessionMetadata is what is kept of an upload session across
+// restarts. The offset is taken from the size of the partial file instead.
+type uploadSessionMetadata struct {
+	Info     UploadSession `json:"info"`
+	PartPath string        `json:"partPath"`
+	Mode     os.FileMode   `json:"mode"`
+}
+
+var (
+	uploadSessions = cmap.New[*uploadSession]()
+	// where the metadata of the sessions is kept, as <id>.json
+	uploadSessionsDir string
+)
+
+// SetUploadSessions sets the directory the metadata of upload sessions is
+// kept in, and restores the sessions of a previous run from it so their
+// uploads can be resumed. It must be called before the server starts.
+func SetUploadSessions(dir string) {
+	uploadSessionsDir = dir
+	restoreUploadSessions()
+}
+
+// CreateUploadSession godoc
+//
+//	@Summary		Create an upload session
+//	@Description	Start a resumable upload to the specified path. Upload the content in chunks with UploadChunk, then call CompleteUploadSession. Sessions survive daemon restarts, resume from the offset reported by GetUploadSession.
+//	@Tags			file-system
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		CreateUploadSessionRequest	true	"Upload session request"
+//	@Success		201		{object}	UploadSession
+//	@Router			/files/uploads [post]
+//
+//	@id				CreateUploadSession
+func CreateUploadSession(c *gin.Context) {
+	var req CreateUploadSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	if req.Size != nil && *req.Size < 0 {
+		c.AbortWithError(http.StatusBadRequest, errors.New("size must not be negative"))
+		return
+	}
+
+	path, ok := checkPath(c, req.Path)
+	if !ok {
+		return
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid path: %w", err))
+		return
+	}
+
+	existing, err := os.Stat(absPath)
+	if err == nil && existing.IsDir() {
+		c.AbortWithError(http.StatusBadRequest, errors.New("path must be a file"))
+		return
+	}
+
+	// keep the mode of a file that is being replaced unless told otherwise
+	var mode os.FileMode = 0644
+	if req.Mode != nil {
+		modeNum, err := strconv.ParseUint(*req.Mode, 8, 32)
+		if err != nil {
+			c.AbortWithError(http.StatusBadRequest, errors.New("invalid mode format"))
+			return
+		}
+		mode = os.FileMode(modeNum)
+	} else if existing != nil {
+		mode = existing.Mode().Perm()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(absPath), 0755); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	pruneUploadSessions()
+
+	id := uuid.NewString()
+	partPath := filepath.Join(filepath.Dir(absPath), "."+filepath.Base(absPath)+".upload-"+id)
+
+	part, err := os.OpenFile(partPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if os.IsPermission(err) {
+			c.AbortWithError(http.StatusForbidden, err)
+			return
+		}
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	part.Close()
+
+	now := time.Now()
+	session := &uploadSession{
+		info: UploadSession{
+			UploadId:  id,
+			Path:      absPath,
+			Offset:    0,
+			Size:      req.Size,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		partPath: partPath,
+		mode:     mode,
+	}
+	if err := saveUploadSession(session); err != nil {
+		os.Remove(partPath)
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	uploadSessions.Set(id, session)
+
+	c.JSON(http.StatusCreated, session.info)
+}
+
+// GetUploadSession godoc
+//
+//	@Summary		Get upload session
+//	@Description	Get the state of an upload session, including the offset to resume from
+//	@Tags			file-system
+//	@Produce		json
+//	@Param			uploadId	path		string	true	"Upload session ID"
+//	@Success		200			{object}	UploadSession
+//	@Router			/files/uploads/{uploadId} [get]
+//
+//	@id				GetUploadSession
+func GetUploadSession(c *gin.Context) {
+	session, ok := uploadSessions.Get(c.Param("uploadId"))
+	if !ok {
+		c.AbortWithError(http.StatusNotFound, errors.New("upload session not found"))
+		return
+	}
+
+	session.mu.Lock()
+	info := session.info
+	session.mu.Unlock()
+
+	c.JSON(http.StatusOK, info)
+}
+
+// UploadChunk godoc
+//
+//	@Summary		Upload a chunk
+//	@Description	Append the raw request body to an upload session. The offset must match the current session offset, otherwise 409 is returned with the current session state.
+//	@Tags			file-system
+//	@Accept			octet-stream
+//	@Produce		json
+//	@Param			uploadId	path		string	true	"Upload session ID"
+//	@Param			offset		query		integer	true	"Byte offset of the chunk"
+//	@Param			chunk		body		string	true	"Chunk content"
+//	@Success		200			{object}	UploadSession
+//	@Failure		409			{object}	UploadSession
+//	@Router			/files/uploads/{uploadId} [put]
+//
+//	@id				UploadChunk
+func UploadChunk(c *gin.Context) {
+	session, ok := uploadSessions.Get(c.Param("uploadId"))
+	if !ok {
+		c.AbortWithError(http.StatusNotFound, errors.New("upload session not found"))
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.Query("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.AbortWithError(http.StatusBadRequest, errors.New("invalid offset"))
+		return
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	if offset != session.info.Offset {
+		c.AbortWithStatusJSON(http.StatusConflict, session.info)
+		return
+	}
+
+	body := io.Reader(c.Request.Body)
+	if session.info.Size != nil {
+		remaining := *session.info.Size - offset
+		if c.Request.ContentLength > remaining {
+			c.AbortWithError(http.StatusRequestEntityTooLarge, errors.New("chunk exceeds declared upload size"))
+			return
+		}
+		// one extra byte detects bodies without a content length that are too large
+		body = io.LimitReader(body, remaining+1)
+	}
+
+	part, err := os.OpenFile(session.partPath, os.O_WRONLY, 0600)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	defer part.Close()
+
+	if _, err := part.Seek(offset, io.SeekStart); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	n, copyErr := io.Copy(part, body)
+	session.info.Offset += n
+	session.info.UpdatedAt = time.Now()
+
+	if session.info.Size != nil && session.info.Offset > *session.info.Size {
+		// drop the overflow so the session can be resumed at the declared size
+		session.info.Offset = *session.info.Size
+		_ = part.Truncate(session.info.Offset)
+		c.AbortWithError(http.StatusRequestEntityTooLarge, errors.New("chunk exceeds declared upload size"))
+		return
+	}
+
+	if copyErr != nil {
+		// keep what was written, the client resumes from the reported offset
+		log.Debugf("Upload %s interrupted at offset %d: %v", session.info.UploadId, session.info.Offset, copyErr)
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("failed to write chunk: %w", copyErr))
+		return
+	}
+
+	c.JSON(http.StatusOK, session.info)
+}
+
+// CompleteUploadSession godoc
+//
+//	@Summary		Complete an upload session
+//	@Description	Verify the SHA-256 checksum of the uploaded content and move it to the destination path
+//	@Tags			file-system
+//	@Accept			json
+//	@Produce		json
+//	@Param			uploadId	path		string					true	"Upload session ID"
+//	@Param			request		body		CompleteUploadRequest	true	"Completion request"
+//	@Success		200			{object}	UploadSession
+//	@Router			/files/uploads/{uploadId}/complete [post]
+//
+//	@id				CompleteUploadSession
+func CompleteUploadSession(c *gin.Context) {
+	var req CompleteUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	session, ok := uploadSessions.Get(c.Param("uploadId"))
+	if !ok {
+		c.AbortWithError(http.StatusNotFound, errors.New("upload session not found"))
+		return
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	if session.info.Size != nil && session.info.Offset != *session.info.Size {
+		c.AbortWithError(http.StatusConflict, fmt.Errorf("upload incomplete: %d of %d bytes received", session.info.Offset, *session.info.Size))
+		return
+	}
+
+	checksum, err := fsutil.HashFile(session.partPath)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	expected := strings.ToLower(strings.TrimPrefix(req.Checksum, "sha256:"))
+	if checksum != expected {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("checksum mismatch: expected %s, got %s", expected, checksum))
+		return
+	}
+
+	if err := os.Chmod(session.partPath, session.mode); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := os.Rename(session.partPath, session.info.Path); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	removeUploadSession(session)
+	c.JSON(http.StatusOK, session.info)
+}
+
+// DeleteUploadSession godoc
+//
+//	@Summary		Abort an upload session
+//	@Description	Abort an upload session and discard the data uploaded so far
+//	@Tags			file-system
+//	@Param			uploadId	path	string	true	"Upload session ID"
+//	@Success		204
+//	@Router			/files/uploads/{uploadId} [delete]
+//
+//	@id				DeleteUploadSession
+func DeleteUploadSession(c *gin.Context) {
+	session, ok := uploadSessions.Get(c.Param("uploadId"))
+	if !ok {
+		c.AbortWithError(http.StatusNotFound, errors.New("upload session not found"))
+		return
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	removeUploadSession(session)
+	if err := os.Remove(session.partPath); err != nil && !os.IsNotExist(err) {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// pruneUploadSessions discards sessions that have been idle for longer than
+// uploadSessionTTL.
+func pruneUploadSessions() {
+	for id, session := range uploadSessions.Items() {
+		if !session.mu.TryLock() {
+			continue
+		}
+		if time.Since(session.info.UpdatedAt) > uploadSessionTTL {
+			removeUploadSession(session)
+			_ = os.Remove(session.partPath)
+			log.Debugf("Discarded stale upload session %s", id)
+		}
+		session.mu.Unlock()
+	}
+}
+
+func uploadSessionPath(id string) string {
+	return filepath.Join(uploadSessionsDir, id+".json")
+}
+
+// saveUploadSession writes the metadata of a new session, when a directory
+// is set for it.
+func saveUploadSession(session *uploadSession) error {
+	if uploadSessionsDir == "" {
+		return nil
+	}
+	data, err := json.Marshal(uploadSessionMetadata{
+		Info:     session.info,
+		PartPath: session.partPath,
+		Mode:     session.mode,
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(uploadSessionsDir, 0700); err != nil {
+		return err
+	}
+	return writeFileAtomic(uploadSessionPath(session.info.UploadId), data, nil)
+}
+
+// removeUploadSession forgets a session, the caller takes care of its
+// partial file.
+func removeUploadSession(session *uploadSession) {
+	uploadSessions.Remove(session.info.UploadId)
+	if uploadSessionsDir == "" {
+		return
+	}
+	if err := os.Remove(uploadSessionPath(session.info.UploadId)); err != nil && !os.IsNotExist(err) {
+		log.Warnf("Failed to remove upload session %s: %v", session.info.UploadId, err)
+	}
+}
+
+// restoreUploadSessions loads the sessions found in uploadSessionsDir.
+// Sessions whose partial file is gone are dropped, and expired ones are
+// discarded with their partial file.
+func restoreUploadSessions() {
+	entries, err := os.ReadDir(uploadSessionsDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("Failed to read upload sessions: %v", err)
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(uploadSessionsDir, entry.Name())
+
+		var metadata uploadSessionMetadata
+		data, err := os.ReadFile(path)
+		if err == nil {
+			err = json.Unmarshal(data, &metadata)
+		}
+		if err != nil {
+			log.Warnf("Failed to load upload session %s: %v", entry.Name(), err)
+			continue
+		}
+
+		// whatever made it to disk before the restart can be resumed from
+		part, err := os.Lstat(metadata.PartPath)
+		if err != nil || !part.Mode().IsRegular() {
+			log.Debugf("Dropped upload session %s without partial data", metadata.Info.UploadId)
+			os.Remove(path)
+			continue
+		}
+		metadata.Info.Offset = part.Size()
+		metadata.Info.UpdatedAt = part.ModTime()
+
+		uploadSessions.Set(metadata.Info.UploadId, &uploadSession{
+			info:     metadata.Info,
+			partPath: metadata.PartPath,
+			mode:     metadata.Mode,
+		})
+	}
+
+	pruneUploadSessions()
+}
@@ -0,0 +1,62 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRestoreUploadSessions(t *testing.T) {
+	dir := t.TempDir()
+	uploadSessionsDir = filepath.Join(dir, "uploads")
+	t.Cleanup(func() { uploadSessionsDir = "" })
+
+	newSession := func(id string) *uploadSession {
+		now := time.Now()
+		return &uploadSession{
+			info: UploadSession{
+				UploadId:  id,
+				Path:      filepath.Join(dir, id),
+				CreatedAt: now,
+				UpdatedAt: now,
+			},
+			partPath: filepath.Join(dir, "."+id+".upload-"+id),
+			mode:     0640,
+		}
+	}
+
+	resumed := newSession("resumed")
+	if err := os.WriteFile(resumed.partPath, []byte("abc"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// its partial file is gone
+	lost := newSession("lost")
+	for _, session := range []*uploadSession{resumed, lost} {
+		if err := saveUploadSession(session); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	SetUploadSessions(uploadSessionsDir)
+	t.Cleanup(func() {
+		for _, id := range uploadSessions.Keys() {
+			uploadSessions.Remove(id)
+		}
+	})
+
+	session, ok := uploadSessions.Get("resumed")
+	if !ok {
+		t.Fatal("expected the session to be restored")
+	}
+	if session.info.Offset != 3 || session.mode != 0640 {
+		t.Errorf("expected offset 3 and mode 0640, got %d and %o", session.info.Offset, session.mode)
+	}
+
+	if _, ok := uploadSessions.Get("lost"); ok {
+		t.Error("expected the session without partial data to be dropped")
+	}
+	if _, err := os.Stat(uploadSessionPath("lost")); !os.IsNotExist(err) {
+		t.Errorf("expected the metadata of the dropped session to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,328 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/cofy-x/deck/packages/core-go/pkg/log"
+)
+
+const (
+	defaultWatchDebounce = 100 * time.Millisecond
+	watchWriteWait       = 10 * time.Second
+	// events are flushed at the latest this many debounce windows after the
+	// first one, so a path that keeps changing does not hold back the batch
+	watchMaxDebounces = 4
+)
+
+// File watch event types
+const (
+	WatchEventCreate = "create"
+	WatchEventModify = "modify"
+	WatchEventDelete = "delete"
+	WatchEventRename = "rename"
+	WatchEventError  = "error"
+)
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// WatchFiles godoc
+//
+//	@Summary		Watch files for changes
+//	@Description	Stream create, modify, delete and rename events for a path over WebSocket. Events are coalesced per path within the debounce window, and sent at the latest four windows after the first one of a batch. Directories ignored by .gitignore are not watched unless noIgnore is set.
+//	@Tags			file-system
+//	@Param			path		query		string			true	"File or directory path to watch"
+//	@Param			recursive	query		boolean			false	"Watch subdirectories recursively"
+//	@Param			include		query		[]string		false	"Only report files matching these globs"			collectionFormat(multi)
+//	@Param			exclude		query		[]string		false	"Skip files and directories matching these globs"	collectionFormat(multi)
+//	@Param			noIgnore	query		boolean			false	"Do not respect .gitignore files"
+//	@Param			debounce	query		integer			false	"Debounce window in milliseconds (default: 100, 0 disables debouncing)"
+//	@Success		101			{object}	FileWatchEvent	"Switching Protocols - events are sent as JSON text messages"
+//	@Router			/files/watch [get]
+//
+//	@id				WatchFiles
+func WatchFiles(c *gin.Context) {
+	path := c.Query("path")
+	if path == "" {
+		c.AbortWithError(http.StatusBadRequest, errors.New("path is required"))
+		return
+	}
+
+	path, ok := checkPath(c, path)
+	if !ok {
+		return
+	}
+
+	debounce := defaultWatchDebounce
+	if value := c.Query("debounce"); value != "" {
+		ms, err := parseNonNegativeInt(value)
+		if err != nil {
+			c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid debounce: %w", err))
+			return
+		}
+		debounce = time.Duration(ms) * time.Millisecond
+	}
+
+	root := filepath.Clean(path)
+	rootInfo, err := os.Stat(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		if os.IsPermission(err) {
+			c.AbortWithError(http.StatusForbidden, err)
+			return
+		}
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	w := &fileWatcher{
+		root:      root,
+		recursive: c.Query("recursive") == "true" && rootInfo.IsDir(),
+		filter:    newWalkFilter(root, splitGlobs(c.QueryArray("include")), splitGlobs(c.QueryArray("exclude")), c.Query("noIgnore") == "true"),
+		dirs:      map[string]struct{}{},
+		pending:   map[string]*FileWatchEvent{},
+	}
+
+	w.watcher, err = fsnotify.NewWatcher()
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to create watcher: %w", err))
+		return
+	}
+	defer w.watcher.Close()
+
+	if err := w.add(root, rootInfo.IsDir()); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to watch path: %w", err))
+		return
+	}
+
+	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Errorf("Failed to upgrade websocket: %v", err)
+		return
+	}
+	defer ws.Close()
+	w.conn = ws
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	// The client is not expected to send anything, reading only detects disconnects.
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	w.run(ctx, debounce)
+
+	_ = ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+}
+
+// fileWatcher turns fsnotify events for one WebSocket client into coalesced
+// FileWatchEvents.
+type fileWatcher struct {
+	root      string
+	recursive bool
+	filter    *walkFilter
+	watcher   *fsnotify.Watcher
+	conn      *websocket.Conn
+
+	// watched directories, used to tell whether a removed path was a directory
+	dirs map[string]struct{}
+
+	// events waiting for the debounce window to close, in arrival order
+	pending map[string]*FileWatchEvent
+	order   []string
+}
+
+// add watches path and, for recursive watchers, all directories below it.
+func (w *fileWatcher) add(path string, isDir bool) error {
+	if !isDir {
+		return w.watcher.Add(path)
+	}
+
+	if !w.recursive {
+		w.filter.skipDir(path)
+		w.dirs[path] = struct{}{}
+		return w.watcher.Add(path)
+	}
+
+	return filepath.WalkDir(path, func(dirPath string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() && dirPath != path {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if w.filter.skipDir(dirPath) {
+			return filepath.SkipDir
+		}
+		if err := w.watcher.Add(dirPath); err != nil {
+			return err
+		}
+		w.dirs[dirPath] = struct{}{}
+		return nil
+	})
+}
+
+func (w *fileWatcher) run(ctx context.Context, debounce time.Duration) {
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	// when the first event of the pending batch arrived
+	var batchStart time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if !w.handle(event) {
+				continue
+			}
+			now := time.Now()
+			if batchStart.IsZero() {
+				batchStart = now
+			}
+			wait := flushDelay(batchStart, now, debounce)
+			if wait <= 0 {
+				timer.Stop()
+				batchStart = time.Time{}
+				if err := w.flush(); err != nil {
+					return
+				}
+				continue
+			}
+			timer.Reset(wait)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			if err := w.send(&FileWatchEvent{Type: WatchEventError, Time: time.Now(), Error: err.Error()}); err != nil {
+				return
+			}
+		case <-timer.C:
+			batchStart = time.Time{}
+			if err := w.flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// flushDelay returns how long to wait before flushing a batch that started at
+// batchStart. Every event restarts the debounce window, but a batch is never
+// held back for more than watchMaxDebounces windows.
+func flushDelay(batchStart, now time.Time, debounce time.Duration) time.Duration {
+	return min(debounce, batchStart.Add(watchMaxDebounces*debounce).Sub(now))
+}
+
+// handle records an fsnotify event and reports whether it was kept.
+func (w *fileWatcher) handle(event fsnotify.Event) bool {
+	var eventType string
+	switch {
+	case event.Has(fsnotify.Create):
+		eventType = WatchEventCreate
+	case event.Has(fsnotify.Remove):
+		eventType = WatchEventDelete
+	case event.Has(fsnotify.Rename):
+		eventType = WatchEventRename
+	case event.Has(fsnotify.Write), event.Has(fsnotify.Chmod):
+		eventType = WatchEventModify
+	default:
+		return false
+	}
+
+	path := event.Name
+	_, isDir := w.dirs[path]
+	if eventType == WatchEventCreate || eventType == WatchEventModify {
+		if info, err := os.Lstat(path); err == nil {
+			isDir = info.IsDir()
+		}
+	}
+
+	if isDir {
+		if w.filter.excludedDir(path) {
+			return false
+		}
+		if eventType == WatchEventCreate && w.recursive {
+			if err := w.add(path, true); err != nil {
+				log.Debugf("Failed to watch new directory %s: %v", path, err)
+			}
+		}
+		if eventType == WatchEventDelete || eventType == WatchEventRename {
+			delete(w.dirs, path)
+		}
+	} else if path != w.root && w.filter.skipFile(path) {
+		return false
+	}
+
+	next := &FileWatchEvent{Type: eventType, Path: path, IsDir: isDir, Time: time.Now()}
+
+	prev, exists := w.pending[path]
+	switch {
+	case !exists:
+		w.order = append(w.order, path)
+	case prev.Type == WatchEventCreate && eventType == WatchEventModify:
+		// a new file being written is still a create
+		prev.Time = next.Time
+		return true
+	case prev.Type == WatchEventCreate && (eventType == WatchEventDelete || eventType == WatchEventRename):
+		// created and gone again within the window
+		delete(w.pending, path)
+		if i := slices.Index(w.order, path); i >= 0 {
+			w.order = slices.Delete(w.order, i, i+1)
+		}
+		return true
+	}
+	w.pending[path] = next
+	return true
+}
+
+func (w *fileWatcher) flush() error {
+	for _, path := range w.order {
+		event, ok := w.pending[path]
+		if !ok {
+			continue
+		}
+		if err := w.send(event); err != nil {
+			return err
+		}
+		delete(w.pending, path)
+	}
+	w.order = w.order[:0]
+	return nil
+}
+
+func (w *fileWatcher) send(event *FileWatchEvent) error {
+	if err := w.conn.SetWriteDeadline(time.Now().Add(watchWriteWait)); err != nil {
+		return err
+	}
+	return w.conn.WriteJSON(event)
+}
@@ -0,0 +1,111 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func newTestWatcher(root string) *fileWatcher {
+	return &fileWatcher{
+		root:    root,
+		filter:  newWalkFilter(root, nil, nil, true),
+		dirs:    map[string]struct{}{root: {}},
+		pending: map[string]*FileWatchEvent{},
+	}
+}
+
+func TestWatchCreateThenModify(t *testing.T) {
+	root := t.TempDir()
+	w := newTestWatcher(root)
+	path := filepath.Join(root, "new.txt")
+	if err := os.WriteFile(path, []byte("data"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if !w.handle(fsnotify.Event{Name: path, Op: fsnotify.Create}) {
+		t.Fatal("expected the create to be kept")
+	}
+	created := w.pending[path].Time
+	time.Sleep(time.Millisecond)
+	if !w.handle(fsnotify.Event{Name: path, Op: fsnotify.Write}) {
+		t.Fatal("expected the write to be kept")
+	}
+
+	event, ok := w.pending[path]
+	if !ok || event.Type != WatchEventCreate {
+		t.Fatalf("expected a pending create, got %+v", event)
+	}
+	if !event.Time.After(created) {
+		t.Fatalf("expected the create time to move to the write, got %v after %v", event.Time, created)
+	}
+	if len(w.order) != 1 || w.order[0] != path {
+		t.Fatalf("unexpected order %v", w.order)
+	}
+}
+
+func TestWatchCreateThenDelete(t *testing.T) {
+	root := t.TempDir()
+	w := newTestWatcher(root)
+	kept := filepath.Join(root, "kept.txt")
+	gone := filepath.Join(root, "gone.txt")
+	if err := os.WriteFile(kept, []byte("data"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	w.handle(fsnotify.Event{Name: gone, Op: fsnotify.Create})
+	w.handle(fsnotify.Event{Name: kept, Op: fsnotify.Write})
+	if !w.handle(fsnotify.Event{Name: gone, Op: fsnotify.Remove}) {
+		t.Fatal("expected the remove to be kept")
+	}
+
+	if _, ok := w.pending[gone]; ok {
+		t.Fatalf("expected %s to be dropped, got %+v", gone, w.pending[gone])
+	}
+	if len(w.order) != 1 || w.order[0] != kept {
+		t.Fatalf("unexpected order %v", w.order)
+	}
+	if event := w.pending[kept]; event == nil || event.Type != WatchEventModify {
+		t.Fatalf("expected a pending modify for %s, got %+v", kept, event)
+	}
+}
+
+func TestWatchModifyThenDelete(t *testing.T) {
+	root := t.TempDir()
+	w := newTestWatcher(root)
+	path := filepath.Join(root, "old.txt")
+
+	w.handle(fsnotify.Event{Name: path, Op: fsnotify.Write})
+	w.handle(fsnotify.Event{Name: path, Op: fsnotify.Remove})
+
+	if event := w.pending[path]; event == nil || event.Type != WatchEventDelete {
+		t.Fatalf("expected a pending delete, got %+v", event)
+	}
+	if len(w.order) != 1 {
+		t.Fatalf("unexpected order %v", w.order)
+	}
+}
+
+func TestFlushDelay(t *testing.T) {
+	debounce := 100 * time.Millisecond
+	start := time.Now()
+
+	tests := []struct {
+		elapsed time.Duration
+		want    time.Duration
+	}{
+		{0, debounce},
+		{2 * debounce, debounce},
+		{3*debounce + debounce/2, debounce / 2},
+		{4 * debounce, 0},
+		{5 * debounce, -debounce},
+	}
+	for _, tt := range tests {
+		if got := flushDelay(start, start.Add(tt.elapsed), debounce); got != tt.want {
+			t.Errorf("flushDelay after %v = %v, want %v", tt.elapsed, got, tt.want)
+		}
+	}
+}
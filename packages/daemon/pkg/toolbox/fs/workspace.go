@@ -0,0 +1,40 @@
+package fs
+
+import (
+	"net/http"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/workspace"
+	"github.com/gin-gonic/gin"
+)
+
+// checkPath confines path to the workspace roots and returns the path to
+// operate on. The request is aborted with 403 when path escapes the roots.
+func checkPath(c *gin.Context, path string) (string, bool) {
+	checked, err := workspace.Check(path)
+	if err != nil {
+		c.AbortWithError(http.StatusForbidden, err)
+		return "", false
+	}
+	return checked, true
+}
+
+// checkPathNoFollow is like checkPath for operations that act on a symlink
+// itself rather than on its target.
+func checkPathNoFollow(c *gin.Context, path string) (string, bool) {
+	checked, err := workspace.CheckNoFollow(path)
+	if err != nil {
+		c.AbortWithError(http.StatusForbidden, err)
+		return "", false
+	}
+	return checked, true
+}
+
+// escapesWorkspace reports whether a file found while walking a tree inside
+// the workspace is a symlink leading out of it.
+func escapesWorkspace(path string, isSymlink bool) bool {
+	if !isSymlink || !workspace.Enabled() {
+		return false
+	}
+	_, err := workspace.Check(path)
+	return err != nil
+}
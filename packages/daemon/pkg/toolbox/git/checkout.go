@@ -27,8 +27,13 @@ func CheckoutBranch(c *gin.Context) {
 		return
 	}
 
+	path, ok := checkPath(c, req.Path)
+	if !ok {
+		return
+	}
+
 	gitService := git.Service{
-		WorkDir: req.Path,
+		WorkDir: path,
 	}
 
 	if err := gitService.Checkout(req.Branch); err != nil {
@@ -44,8 +44,13 @@ func CloneRepository(c *gin.Context) {
 		repo.Sha = *req.CommitID
 	}
 
+	path, ok := checkPath(c, req.Path)
+	if !ok {
+		return
+	}
+
 	gitService := git.Service{
-		WorkDir: req.Path,
+		WorkDir: path,
 	}
 
 	var auth *go_git_http.BasicAuth
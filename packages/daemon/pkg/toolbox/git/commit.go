@@ -30,8 +30,13 @@ func CommitChanges(c *gin.Context) {
 		return
 	}
 
+	path, ok := checkPath(c, req.Path)
+	if !ok {
+		return
+	}
+
 	gitService := git.Service{
-		WorkDir: req.Path,
+		WorkDir: path,
 	}
 
 	commitSha, err := gitService.Commit(req.Message, &go_git.CommitOptions{
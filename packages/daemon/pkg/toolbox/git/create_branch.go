@@ -27,8 +27,13 @@ func CreateBranch(c *gin.Context) {
 		return
 	}
 
+	path, ok := checkPath(c, req.Path)
+	if !ok {
+		return
+	}
+
 	gitService := git.Service{
-		WorkDir: req.Path,
+		WorkDir: path,
 	}
 
 	if err := gitService.CreateBranch(req.Name); err != nil {
@@ -26,6 +26,11 @@ func GetCommitHistory(c *gin.Context) {
 		return
 	}
 
+	path, ok := checkPath(c, path)
+	if !ok {
+		return
+	}
+
 	gitService := git.Service{
 		WorkDir: path,
 	}
@@ -26,6 +26,11 @@ func ListBranches(c *gin.Context) {
 		return
 	}
 
+	path, ok := checkPath(c, path)
+	if !ok {
+		return
+	}
+
 	gitService := git.Service{
 		WorkDir: path,
 	}
@@ -37,8 +37,13 @@ func PushChanges(c *gin.Context) {
 		}
 	}
 
+	path, ok := checkPath(c, req.Path)
+	if !ok {
+		return
+	}
+
 	gitService := git.Service{
-		WorkDir: req.Path,
+		WorkDir: path,
 	}
 
 	err := gitService.Push(auth)
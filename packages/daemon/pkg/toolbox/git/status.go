@@ -26,6 +26,11 @@ func GetStatus(c *gin.Context) {
 		return
 	}
 
+	path, ok := checkPath(c, path)
+	if !ok {
+		return
+	}
+
 	gitService := git.Service{
 		WorkDir: path,
 	}
@@ -0,0 +1,19 @@
+package git
+
+import (
+	"net/http"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/workspace"
+	"github.com/gin-gonic/gin"
+)
+
+// checkPath confines the repository path to the workspace roots. The request
+// is aborted with 403 when path escapes the roots.
+func checkPath(c *gin.Context, path string) (string, bool) {
+	checked, err := workspace.Check(path)
+	if err != nil {
+		c.AbortWithError(http.StatusForbidden, err)
+		return "", false
+	}
+	return checked, true
+}
@@ -0,0 +1,268 @@
+package job
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/toolbox/process"
+	"github.com/cofy-x/deck/apps/daemon/pkg/workspace"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const followInterval = 200 * time.Millisecond
+
+type JobController struct {
+	queue *queue
+}
+
+// NewJobController creates a controller that runs at most maxConcurrency
+// jobs at a time, as many as there are CPUs when it is not positive. The
+// output of the jobs is kept in configDir/jobs.
+func NewJobController(configDir string, maxConcurrency int) *JobController {
+	if maxConcurrency <= 0 {
+		maxConcurrency = runtime.NumCPU()
+	}
+	return &JobController{
+		queue: &queue{
+			dir:            filepath.Join(configDir, "jobs"),
+			maxConcurrency: maxConcurrency,
+			jobs:           map[string]*job{},
+		},
+	}
+}
+
+// SubmitJob godoc
+//
+//	@Summary		Submit a job
+//	@Description	Queue a command to run detached from the request. Jobs run concurrently up to the configured limit, the highest priority first. The ID of the job is returned right away.
+//	@Tags			job
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		SubmitJobRequest	true	"Job"
+//	@Success		202		{object}	Job
+//	@Router			/jobs [post]
+//
+//	@id				SubmitJob
+func (jc *JobController) SubmitJob(c *gin.Context) {
+	var request SubmitJobRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	executeRequest := process.ExecuteRequest{
+		Command: request.Command,
+		Argv:    request.Argv,
+		Envs:    request.Envs,
+		Cwd:     request.Cwd,
+		Limits:  request.Limits,
+	}
+	if request.Command != "" {
+		shell := true
+		executeRequest.Shell = &shell
+	}
+	cmd, err := process.NewCommand(executeRequest)
+	if err != nil {
+		if errors.Is(err, workspace.ErrOutsideRoots) {
+			c.AbortWithError(http.StatusForbidden, err)
+			return
+		}
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	j := &job{
+		info: Job{
+			Id:          uuid.NewString(),
+			Command:     request.Command,
+			Argv:        request.Argv,
+			Priority:    request.Priority,
+			Status:      JobStatusQueued,
+			SubmittedAt: time.Now(),
+		},
+		cmd:    cmd,
+		limits: request.Limits,
+		cancel: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if request.Timeout != nil {
+		j.timeout = time.Duration(*request.Timeout) * time.Second
+	}
+
+	// the output can be read while the job is queued
+	j.outputPath = filepath.Join(jc.queue.dir, j.info.Id, "output.log")
+	err = os.MkdirAll(filepath.Dir(j.outputPath), 0755)
+	if err == nil {
+		err = os.WriteFile(j.outputPath, nil, 0644)
+	}
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	info := j.info
+	jc.queue.submit(j)
+
+	c.JSON(http.StatusAccepted, info)
+}
+
+// ListJobs godoc
+//
+//	@Summary		List jobs
+//	@Description	Get the jobs in the order they were submitted. Only the latest 1000 finished jobs are kept.
+//	@Tags			job
+//	@Produce		json
+//	@Param			status	query	string	false	"Only jobs with this status"
+//	@Success		200		{array}	Job
+//	@Router			/jobs [get]
+//
+//	@id				ListJobs
+func (jc *JobController) ListJobs(c *gin.Context) {
+	c.JSON(http.StatusOK, jc.queue.list(JobStatus(c.Query("status"))))
+}
+
+// GetJob godoc
+//
+//	@Summary		Get a job
+//	@Description	Get the status of a job, and its exit code once it has finished
+//	@Tags			job
+//	@Produce		json
+//	@Param			jobId	path		string	true	"Job ID"
+//	@Success		200		{object}	Job
+//	@Router			/jobs/{jobId} [get]
+//
+//	@id				GetJob
+func (jc *JobController) GetJob(c *gin.Context) {
+	_, info, ok := jc.queue.get(c.Param("jobId"))
+	if !ok {
+		c.AbortWithError(http.StatusNotFound, errJobNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// CancelJob godoc
+//
+//	@Summary		Cancel a job
+//	@Description	Remove a queued job from the queue, or kill the process group of a running job and wait until it has exited
+//	@Tags			job
+//	@Produce		json
+//	@Param			jobId	path		string	true	"Job ID"
+//	@Success		200		{object}	Job
+//	@Router			/jobs/{jobId}/cancel [post]
+//
+//	@id				CancelJob
+func (jc *JobController) CancelJob(c *gin.Context) {
+	done, err := jc.queue.cancel(c.Param("jobId"))
+	if err != nil {
+		abortWithJobError(c, err)
+		return
+	}
+
+	select {
+	case <-done:
+	case <-c.Request.Context().Done():
+		return
+	}
+
+	_, info, _ := jc.queue.get(c.Param("jobId"))
+	c.JSON(http.StatusOK, info)
+}
+
+// DeleteJob godoc
+//
+//	@Summary		Delete a job
+//	@Description	Delete a finished job with its output
+//	@Tags			job
+//	@Param			jobId	path	string	true	"Job ID"
+//	@Success		204
+//	@Router			/jobs/{jobId} [delete]
+//
+//	@id				DeleteJob
+func (jc *JobController) DeleteJob(c *gin.Context) {
+	if err := jc.queue.remove(c.Param("jobId")); err != nil {
+		abortWithJobError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetJobOutput godoc
+//
+//	@Summary		Get the output of a job
+//	@Description	Get the combined stdout and stderr of a job. With follow the response stays open and streams new output until the job has finished.
+//	@Tags			job
+//	@Produce		text/plain
+//	@Param			jobId	path		string	true	"Job ID"
+//	@Param			follow	query		boolean	false	"Stream output as it is written"
+//	@Success		200		{string}	string	"Output"
+//	@Router			/jobs/{jobId}/output [get]
+//
+//	@id				GetJobOutput
+func (jc *JobController) GetJobOutput(c *gin.Context) {
+	j, _, ok := jc.queue.get(c.Param("jobId"))
+	if !ok {
+		c.AbortWithError(http.StatusNotFound, errJobNotFound)
+		return
+	}
+	follow := c.Query("follow") == "true"
+
+	output, err := os.Open(j.outputPath)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	defer output.Close()
+
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	if follow {
+		c.Header("Cache-Control", "no-cache")
+		// keep reverse proxies from buffering the stream
+		c.Header("X-Accel-Buffering", "no")
+	}
+	c.Status(http.StatusOK)
+
+	if _, err := io.Copy(c.Writer, output); err != nil || !follow {
+		return
+	}
+	c.Writer.Flush()
+
+	ctx := c.Request.Context()
+	ticker := time.NewTicker(followInterval)
+	defer ticker.Stop()
+	for {
+		finished := false
+		select {
+		case <-ctx.Done():
+			return
+		case <-j.done:
+			finished = true
+		case <-ticker.C:
+		}
+
+		n, err := io.Copy(c.Writer, output)
+		if err != nil || finished {
+			return
+		}
+		if n > 0 {
+			c.Writer.Flush()
+		}
+	}
+}
+
+func abortWithJobError(c *gin.Context, err error) {
+	if errors.Is(err, errJobNotFound) {
+		c.AbortWithError(http.StatusNotFound, err)
+		return
+	}
+	c.AbortWithError(http.StatusConflict, err)
+}
@@ -0,0 +1,276 @@
+package job
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/cgroup"
+	"github.com/cofy-x/deck/apps/daemon/pkg/toolbox/process"
+	"github.com/cofy-x/deck/packages/core-go/pkg/log"
+)
+
+// maxFinishedJobs is how many finished jobs are kept, the oldest are
+// removed with their output first.
+const maxFinishedJobs = 1000
+
+var (
+	errJobNotFound = errors.New("job not found")
+	errJobFinished = errors.New("job has already finished")
+)
+
+type job struct {
+	// guarded by the mutex of the queue
+	info Job
+
+	seq        uint64
+	cmd        *exec.Cmd
+	limits     *cgroup.Limits
+	timeout    time.Duration
+	outputPath string
+	// closed to cancel the running job
+	cancel chan struct{}
+	// closed once the job has finished
+	done chan struct{}
+}
+
+// queue runs the submitted jobs, at most maxConcurrency at a time.
+type queue struct {
+	dir            string
+	maxConcurrency int
+
+	mu      sync.Mutex
+	jobs    map[string]*job
+	queued  []*job
+	running int
+	// IDs of the finished jobs, oldest first
+	finished []string
+	seq      uint64
+}
+
+func (q *queue) submit(j *job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.seq++
+	j.seq = q.seq
+	q.jobs[j.info.Id] = j
+	q.queued = append(q.queued, j)
+	q.dispatchLocked()
+}
+
+// dispatchLocked starts queued jobs while there are free slots, the highest
+// priority first.
+func (q *queue) dispatchLocked() {
+	for q.running < q.maxConcurrency && len(q.queued) > 0 {
+		next := 0
+		for i, j := range q.queued {
+			if j.info.Priority > q.queued[next].info.Priority {
+				next = i
+			}
+		}
+		j := q.queued[next]
+		q.queued = append(q.queued[:next], q.queued[next+1:]...)
+
+		q.running++
+		j.info.Status = JobStatusRunning
+		go q.run(j)
+	}
+}
+
+// run runs a dequeued job until it exits, is cancelled or times out.
+func (q *queue) run(j *job) {
+	output, err := os.OpenFile(j.outputPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		q.finish(j, JobStatusFailed, nil, err)
+		return
+	}
+	defer output.Close()
+	j.cmd.Stdout = output
+	j.cmd.Stderr = output
+
+	group, err := process.StartCommand(j.cmd, j.limits)
+	if err != nil {
+		q.finish(j, JobStatusFailed, nil, err)
+		return
+	}
+	defer group.Remove()
+
+	pid := j.cmd.Process.Pid
+	// Register this PID to prevent zombie reaper from racing with cmd.Wait()
+	registry := process.GetRegistry()
+	registry.Register(pid)
+	defer registry.Unregister(pid)
+
+	now := time.Now()
+	q.mu.Lock()
+	j.info.Pid = &pid
+	j.info.StartedAt = &now
+	q.mu.Unlock()
+	log.Debugf("[jobs] Started job %s PID=%d", j.info.Id, pid)
+
+	var timeout <-chan time.Time
+	if j.timeout > 0 {
+		timer := time.NewTimer(j.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	waitErr := make(chan error, 1)
+	go func() {
+		waitErr <- j.cmd.Wait()
+	}()
+
+	status := JobStatusExited
+	timedOut := false
+	select {
+	case err = <-waitErr:
+	case <-j.cancel:
+		status = JobStatusCancelled
+		process.KillProcessGroup(j.cmd)
+		err = <-waitErr
+	case <-timeout:
+		timedOut = true
+		process.KillProcessGroup(j.cmd)
+		err = <-waitErr
+	}
+	exitCode := process.CommandExitCode(j.cmd, err)
+
+	q.mu.Lock()
+	j.info.TimedOut = timedOut
+	j.info.OomKilled = group.OOMKills() > 0
+	q.mu.Unlock()
+	q.finish(j, status, &exitCode, nil)
+}
+
+// finish records the end of a job and starts the next queued one.
+func (q *queue) finish(j *job, status JobStatus, exitCode *int, err error) {
+	if err != nil {
+		log.Warnf("[jobs] Failed to start job %s: %v", j.info.Id, err)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.running--
+	q.finishLocked(j, status, exitCode, err)
+	q.dispatchLocked()
+}
+
+func (q *queue) finishLocked(j *job, status JobStatus, exitCode *int, err error) {
+	now := time.Now()
+	j.info.Status = status
+	j.info.Pid = nil
+	j.info.ExitCode = exitCode
+	j.info.EndedAt = &now
+	if err != nil {
+		j.info.Error = err.Error()
+	}
+	close(j.done)
+
+	q.finished = append(q.finished, j.info.Id)
+	for len(q.finished) > maxFinishedJobs {
+		id := q.finished[0]
+		q.finished = q.finished[1:]
+		if old, ok := q.jobs[id]; ok {
+			delete(q.jobs, id)
+			q.removeOutput(old)
+		}
+	}
+}
+
+// cancel cancels a queued or running job, and returns a channel closed once
+// it has finished.
+func (q *queue) cancel(id string) (<-chan struct{}, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, ok := q.jobs[id]
+	if !ok {
+		return nil, errJobNotFound
+	}
+
+	switch j.info.Status {
+	case JobStatusQueued:
+		for i, queued := range q.queued {
+			if queued == j {
+				q.queued = append(q.queued[:i], q.queued[i+1:]...)
+				break
+			}
+		}
+		q.finishLocked(j, JobStatusCancelled, nil, nil)
+	case JobStatusRunning:
+		select {
+		case <-j.cancel:
+		default:
+			close(j.cancel)
+		}
+	default:
+		return nil, errJobFinished
+	}
+	return j.done, nil
+}
+
+// remove removes a finished job with its output.
+func (q *queue) remove(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, ok := q.jobs[id]
+	if !ok {
+		return errJobNotFound
+	}
+	select {
+	case <-j.done:
+	default:
+		return errors.New("job has not finished")
+	}
+
+	delete(q.jobs, id)
+	for i, finished := range q.finished {
+		if finished == id {
+			q.finished = append(q.finished[:i], q.finished[i+1:]...)
+			break
+		}
+	}
+	q.removeOutput(j)
+	return nil
+}
+
+func (q *queue) removeOutput(j *job) {
+	if err := os.RemoveAll(filepath.Dir(j.outputPath)); err != nil {
+		log.Warnf("[jobs] Failed to remove the output of job %s: %v", j.info.Id, err)
+	}
+}
+
+func (q *queue) get(id string) (*job, Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, ok := q.jobs[id]
+	if !ok {
+		return nil, Job{}, false
+	}
+	return j, j.info, true
+}
+
+// list returns the jobs with the given status, all of them if it is empty,
+// in the order they were submitted.
+func (q *queue) list(status JobStatus) []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jobs := make([]*job, 0, len(q.jobs))
+	for _, j := range q.jobs {
+		if status == "" || j.info.Status == status {
+			jobs = append(jobs, j)
+		}
+	}
+	sort.Slice(jobs, func(i, k int) bool {
+		return jobs[i].seq < jobs[k].seq
+	})
+
+	list := make([]Job, len(jobs))
+	for i, j := range jobs {
+		list[i] = j.info
+	}
+	return list
+}
@@ -0,0 +1,112 @@
+package job
+
+import (
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func newTestJob(t *testing.T, q *queue, id string, priority int, args ...string) *job {
+	t.Helper()
+	cmd := exec.Command(args[0], args[1:]...)
+	// like the commands of jobs, so their process group can be killed
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return &job{
+		info: Job{
+			Id:          id,
+			Priority:    priority,
+			Status:      JobStatusQueued,
+			SubmittedAt: time.Now(),
+		},
+		cmd:        cmd,
+		outputPath: filepath.Join(q.dir, id+".log"),
+		cancel:     make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+func waitJob(t *testing.T, j *job) {
+	t.Helper()
+	select {
+	case <-j.done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("job %s did not finish", j.info.Id)
+	}
+}
+
+func TestQueuePriority(t *testing.T) {
+	q := &queue{dir: t.TempDir(), maxConcurrency: 1, jobs: map[string]*job{}}
+
+	blocker := newTestJob(t, q, "blocker", 0, "sleep", "0.2")
+	low := newTestJob(t, q, "low", 0, "true")
+	high := newTestJob(t, q, "high", 5, "true")
+	cancelled := newTestJob(t, q, "cancelled", 9, "true")
+	q.submit(blocker)
+	q.submit(low)
+	q.submit(high)
+	q.submit(cancelled)
+
+	if _, err := q.cancel("cancelled"); err != nil {
+		t.Fatal(err)
+	}
+	for _, j := range []*job{blocker, low, high} {
+		waitJob(t, j)
+	}
+
+	_, info, _ := q.get("cancelled")
+	if info.Status != JobStatusCancelled || info.StartedAt != nil {
+		t.Errorf("expected the queued job to be cancelled without starting, got %+v", info)
+	}
+
+	_, lowInfo, _ := q.get("low")
+	_, highInfo, _ := q.get("high")
+	if lowInfo.Status != JobStatusExited || highInfo.Status != JobStatusExited {
+		t.Fatalf("expected both jobs to exit, got %s and %s", lowInfo.Status, highInfo.Status)
+	}
+	if !highInfo.StartedAt.Before(*lowInfo.StartedAt) {
+		t.Error("expected the job with the higher priority to start first")
+	}
+	_, blockerInfo, _ := q.get("blocker")
+	if highInfo.StartedAt.Before(*blockerInfo.EndedAt) {
+		t.Error("expected at most one job to run at a time")
+	}
+}
+
+func TestQueueCancelRunning(t *testing.T) {
+	q := &queue{dir: t.TempDir(), maxConcurrency: 2, jobs: map[string]*job{}}
+
+	j := newTestJob(t, q, "sleep", 0, "sleep", "30")
+	q.submit(j)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, info, _ := q.get("sleep")
+		if info.StartedAt != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("job did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	done, err := q.cancel("sleep")
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("cancelled job did not finish")
+	}
+
+	_, info, _ := q.get("sleep")
+	if info.Status != JobStatusCancelled || info.ExitCode == nil {
+		t.Errorf("expected the job to be cancelled with an exit code, got %+v", info)
+	}
+	if _, err := q.cancel("sleep"); err != errJobFinished {
+		t.Errorf("expected %v cancelling a finished job, got %v", errJobFinished, err)
+	}
+}
@@ -0,0 +1,58 @@
+package job
+
+import (
+	"time"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/cgroup"
+)
+
+type JobStatus string
+
+const (
+	// Waiting for a free slot
+	JobStatusQueued  JobStatus = "queued"
+	JobStatusRunning JobStatus = "running"
+	// Exited on its own or timed out, see exitCode and timedOut
+	JobStatusExited JobStatus = "exited"
+	// Cancelled while queued or running
+	JobStatusCancelled JobStatus = "cancelled"
+	// Could not be started
+	JobStatusFailed JobStatus = "failed"
+)
+
+type SubmitJobRequest struct {
+	// Command line run through the user's shell. Either command or argv is
+	// required
+	Command string `json:"command,omitempty" validate:"optional"`
+	// Program and arguments, passed on without any parsing
+	Argv []string `json:"argv,omitempty" validate:"optional"`
+	// Environment variables set in addition to the daemon's environment
+	Envs map[string]string `json:"envs,omitempty" validate:"optional"`
+	// Current working directory
+	Cwd *string `json:"cwd,omitempty" validate:"optional"`
+	// Timeout in seconds once the job is running, unlimited when unset
+	Timeout *uint32 `json:"timeout,omitempty" validate:"optional"`
+	// Jobs with a higher priority are started first, jobs of the same
+	// priority in the order they were submitted. Defaults to 0
+	Priority int `json:"priority,omitempty" validate:"optional"`
+	// Resource limits of the job and its children, needs cgroup v2
+	Limits *cgroup.Limits `json:"limits,omitempty" validate:"optional"`
+} //	@name	SubmitJobRequest
+
+type Job struct {
+	Id       string    `json:"id" validate:"required"`
+	Command  string    `json:"command,omitempty" validate:"optional"`
+	Argv     []string  `json:"argv,omitempty" validate:"optional"`
+	Priority int       `json:"priority" validate:"required"`
+	Status   JobStatus `json:"status" validate:"required"`
+	Pid      *int      `json:"pid,omitempty" validate:"optional"`
+	ExitCode *int      `json:"exitCode,omitempty" validate:"optional"`
+	TimedOut bool      `json:"timedOut,omitempty" validate:"optional"`
+	// Set when the OOM killer killed a process of the job
+	OomKilled bool `json:"oomKilled,omitempty" validate:"optional"`
+	// Why the job could not be started
+	Error       string     `json:"error,omitempty" validate:"optional"`
+	SubmittedAt time.Time  `json:"submittedAt" validate:"required"`
+	StartedAt   *time.Time `json:"startedAt,omitempty" validate:"optional"`
+	EndedAt     *time.Time `json:"endedAt,omitempty" validate:"optional"`
+} //	@name	Job
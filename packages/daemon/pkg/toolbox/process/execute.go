@@ -3,6 +3,7 @@ package process
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"os/exec"
@@ -11,6 +12,9 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/cofy-x/deck/apps/daemon/pkg/cgroup"
+	"github.com/cofy-x/deck/apps/daemon/pkg/common"
+	"github.com/cofy-x/deck/apps/daemon/pkg/workspace"
 	"github.com/cofy-x/deck/packages/core-go/pkg/log"
 
 	"github.com/gin-gonic/gin"
@@ -19,7 +23,7 @@ import (
 // ExecuteCommand godoc
 //
 //	@Summary		Execute a command
-//	@Description	Execute a shell command and return the output and exit code
+//	@Description	Execute a command and return the output and exit code. The command line is split into arguments on whitespace, unless shell is set to run it through the user's shell. Alternatively argv runs a program with the given arguments as they are. With limits the command runs in its own cgroup, processes it leaves behind are killed once it exits.
 //	@Tags			process
 //	@Accept			json
 //	@Produce		json
@@ -31,36 +35,32 @@ import (
 func ExecuteCommand(c *gin.Context) {
 	var request ExecuteRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.AbortWithError(http.StatusBadRequest, errors.New("command is required"))
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
 		return
 	}
 
-	cmdParts := parseCommand(request.Command)
-	if len(cmdParts) == 0 {
-		c.AbortWithError(http.StatusBadRequest, errors.New("empty command"))
+	cmd, err := NewCommand(request)
+	if err != nil {
+		if errors.Is(err, workspace.ErrOutsideRoots) {
+			c.AbortWithError(http.StatusForbidden, err)
+			return
+		}
+		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	cmd := exec.Command(cmdParts[0], cmdParts[1:]...)
-	if request.Cwd != nil {
-		cmd.Dir = *request.Cwd
-	}
-
-	// Create a new process group so we can kill all child processes on timeout
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setpgid: true,
-	}
-
 	var output []byte
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
 	// Start the command
-	if err := cmd.Start(); err != nil {
+	group, err := StartCommand(cmd, request.Limits)
+	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
+	defer group.Remove()
 
 	pid := cmd.Process.Pid
 	log.Debugf("[process/execute] Started command PID=%d: %s", pid, request.Command)
@@ -72,39 +72,143 @@ func ExecuteCommand(c *gin.Context) {
 		log.Debugf("[process/execute] Unregistered PID=%d", pid)
 	}()
 
-	// set maximum execution time
-	timeout := 360 * time.Second
-	if request.Timeout != nil && *request.Timeout > 0 {
-		timeout = time.Duration(*request.Timeout) * time.Second
-	}
-
 	timeoutReached := false
-	timer := time.AfterFunc(timeout, func() {
+	timer := time.AfterFunc(executeTimeout(request), func() {
 		timeoutReached = true
-		if cmd.Process != nil {
-			// Kill the entire process group (negative PID kills the whole group).
-			pgid := cmd.Process.Pid
-			err := syscall.Kill(-pgid, syscall.SIGKILL)
-			if err != nil {
-				log.Errorf("Failed to kill process group %d: %v", pgid, err)
-				return
-			}
-		}
+		KillProcessGroup(cmd)
 	})
 	defer timer.Stop()
 
 	// Wait for command to complete
-	err := cmd.Wait()
+	err = cmd.Wait()
 	output = append(stdout.Bytes(), stderr.Bytes()...)
 
-	// Determine exit code
-	var exitCode int
-	if err != nil {
-		if timeoutReached {
-			c.AbortWithError(http.StatusRequestTimeout, errors.New("command execution timeout"))
-			return
+	if err != nil && timeoutReached {
+		c.AbortWithError(http.StatusRequestTimeout, errors.New("command execution timeout"))
+		return
+	}
+
+	c.JSON(http.StatusOK, ExecuteResponse{
+		ExitCode:  CommandExitCode(cmd, err),
+		Result:    string(output),
+		Stdout:    stdout.String(),
+		Stderr:    stderr.String(),
+		OomKilled: group.OOMKills() > 0,
+	})
+}
+
+// NewCommand prepares the command of an execute request. The command runs in
+// its own process group, so it can be killed together with its children.
+func NewCommand(request ExecuteRequest) (*exec.Cmd, error) {
+	var cmdParts []string
+	switch {
+	case request.Command != "" && len(request.Argv) > 0:
+		return nil, errors.New("command and argv are mutually exclusive")
+	case len(request.Argv) > 0:
+		if request.Shell != nil && *request.Shell {
+			return nil, errors.New("shell requires command, not argv")
+		}
+		cmdParts = request.Argv
+	case request.Shell != nil && *request.Shell:
+		if strings.TrimSpace(request.Command) == "" {
+			return nil, errors.New("empty command")
+		}
+		cmdParts = []string{common.GetShell(), "-c", request.Command}
+	case request.Command != "":
+		cmdParts = parseCommand(request.Command)
+		if len(cmdParts) == 0 {
+			return nil, errors.New("empty command")
 		}
+	default:
+		return nil, errors.New("command or argv is required")
+	}
+
+	for key, value := range request.Envs {
+		if key == "" || strings.ContainsAny(key, "=\x00") || strings.ContainsRune(value, 0) {
+			return nil, fmt.Errorf("invalid environment variable %q", key)
+		}
+	}
 
+	if err := request.Limits.Validate(); err != nil {
+		return nil, err
+	}
+
+	cwd := workspace.DefaultDir()
+	if request.Cwd != nil {
+		cwd = *request.Cwd
+	}
+	if cwd != "" {
+		checked, err := workspace.Check(cwd)
+		if err != nil {
+			return nil, err
+		}
+		cwd = checked
+	}
+
+	cmd := exec.Command(cmdParts[0], cmdParts[1:]...)
+	cmd.Dir = cwd
+
+	if len(request.Envs) > 0 {
+		cmd.Env = os.Environ()
+		for key, value := range request.Envs {
+			cmd.Env = append(cmd.Env, key+"="+value)
+		}
+	}
+	if request.Stdin != nil {
+		cmd.Stdin = strings.NewReader(*request.Stdin)
+	}
+
+	// Create a new process group so we can kill all child processes on timeout
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid: true,
+	}
+	return cmd, nil
+}
+
+// StartCommand starts cmd in a cgroup with the given limits, if any. The
+// returned group must be removed once the command has exited.
+func StartCommand(cmd *exec.Cmd, limits *cgroup.Limits) (*cgroup.Group, error) {
+	group, err := cgroup.New(limits)
+	if err != nil {
+		return nil, err
+	}
+	if err = group.Attach(cmd); err == nil {
+		err = cmd.Start()
+	}
+	if err != nil {
+		group.Remove()
+		return nil, err
+	}
+	return group, nil
+}
+
+// executeTimeout returns the maximum execution time of a request.
+func executeTimeout(request ExecuteRequest) time.Duration {
+	if request.Timeout != nil && *request.Timeout > 0 {
+		return time.Duration(*request.Timeout) * time.Second
+	}
+	return 360 * time.Second
+}
+
+// KillProcessGroup kills the process group started by cmd.
+func KillProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	// Kill the entire process group (negative PID kills the whole group).
+	pgid := cmd.Process.Pid
+	if err := syscall.Kill(-pgid, syscall.SIGKILL); err != nil {
+		log.Errorf("Failed to kill process group %d: %v", pgid, err)
+	}
+}
+
+// CommandExitCode determines the exit code of cmd from the result of
+// cmd.Wait, falling back to the exit status cached by the zombie reaper.
+func CommandExitCode(cmd *exec.Cmd, err error) int {
+	pid := cmd.Process.Pid
+
+	var exitCode int
+	if err != nil {
 		// Check if this is a "child already reaped" error
 		if isNoChildProcessError(err) {
 			// Process was reaped by zombie reaper, check cache
@@ -135,11 +239,7 @@ func ExecuteCommand(c *gin.Context) {
 		log.Warnf("[process/execute] PID=%d ProcessState is nil", pid)
 		exitCode = -1
 	}
-
-	c.JSON(http.StatusOK, ExecuteResponse{
-		ExitCode: exitCode,
-		Result:   string(output),
-	})
+	return exitCode
 }
 
 func isNoChildProcessError(err error) bool {
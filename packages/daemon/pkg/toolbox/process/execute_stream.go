@@ -0,0 +1,266 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"time"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/cgroup"
+	"github.com/cofy-x/deck/apps/daemon/pkg/common"
+	"github.com/cofy-x/deck/apps/daemon/pkg/workspace"
+	"github.com/cofy-x/deck/packages/core-go/pkg/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// Execute stream event types
+const (
+	ExecuteEventStdout = "stdout"
+	ExecuteEventStderr = "stderr"
+	ExecuteEventExit   = "exit"
+	ExecuteEventError  = "error"
+)
+
+const streamWriteWait = 10 * time.Second
+
+var streamUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// ExecuteCommandStream godoc
+//
+//	@Summary		Execute a command and stream its output
+//	@Description	Execute a shell command and stream its stdout and stderr as server-sent events while it runs, in the order the output arrives. The event name is the event type, the data an ExecuteStreamEvent. The last event is an exit event. The command is killed when the client disconnects.
+//	@Tags			process
+//	@Accept			json
+//	@Produce		text/event-stream
+//	@Param			request	body		ExecuteRequest		true	"Command execution request"
+//	@Success		200		{object}	ExecuteStreamEvent	"Stream of events"
+//	@Router			/process/execute/stream [post]
+//
+//	@id				ExecuteCommandStream
+func ExecuteCommandStream(c *gin.Context) {
+	var request ExecuteRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	cmd, err := NewCommand(request)
+	if err != nil {
+		if errors.Is(err, workspace.ErrOutsideRoots) {
+			c.AbortWithError(http.StatusForbidden, err)
+			return
+		}
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	stream := newCommandStream(cmd)
+	if err := stream.start(request.Limits); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	log.Debugf("[process/execute] Started streamed command PID=%d: %s", cmd.Process.Pid, request.Command)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// keep reverse proxies from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	ctx := c.Request.Context()
+	stream.run(ctx, executeTimeout(request), func(event ExecuteStreamEvent) error {
+		c.SSEvent(event.Type, event)
+		c.Writer.Flush()
+		return ctx.Err()
+	})
+}
+
+// ExecuteCommandWebSocket godoc
+//
+//	@Summary		Execute a command over WebSocket
+//	@Description	Execute a shell command and stream its stdout and stderr over WebSocket while it runs, in the order the output arrives. The client sends an ExecuteRequest as the first message, every following message from the daemon is an ExecuteStreamEvent. The last event is an exit event, or an error event when the command could not be started. The command is killed when the client disconnects.
+//	@Tags			process
+//	@Success		101	{object}	ExecuteStreamEvent	"Switching Protocols - events are sent as JSON text messages"
+//	@Router			/process/execute/ws [get]
+//
+//	@id				ExecuteCommandWebSocket
+func ExecuteCommandWebSocket(c *gin.Context) {
+	ws, err := streamUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Errorf("Failed to upgrade websocket: %v", err)
+		return
+	}
+	defer ws.Close()
+
+	emit := func(event ExecuteStreamEvent) error {
+		_ = ws.SetWriteDeadline(time.Now().Add(streamWriteWait))
+		return ws.WriteJSON(event)
+	}
+
+	var request ExecuteRequest
+	if err := ws.ReadJSON(&request); err != nil {
+		_ = emit(ExecuteStreamEvent{Type: ExecuteEventError, Data: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+
+	cmd, err := NewCommand(request)
+	if err == nil {
+		stream := newCommandStream(cmd)
+		if err = stream.start(request.Limits); err == nil {
+			log.Debugf("[process/execute] Started streamed command PID=%d: %s", cmd.Process.Pid, request.Command)
+
+			ctx, cancel := context.WithCancel(c.Request.Context())
+			defer cancel()
+
+			// The client is not expected to send anything more, reading only
+			// detects disconnects.
+			go func() {
+				defer cancel()
+				for {
+					if _, _, err := ws.ReadMessage(); err != nil {
+						return
+					}
+				}
+			}()
+
+			stream.run(ctx, executeTimeout(request), emit)
+		}
+	}
+	if err != nil {
+		_ = emit(ExecuteStreamEvent{Type: ExecuteEventError, Data: err.Error()})
+		return
+	}
+
+	_ = ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+}
+
+// commandStream turns the output of a command into ExecuteStreamEvents.
+type commandStream struct {
+	cmd    *exec.Cmd
+	group  *cgroup.Group
+	events chan ExecuteStreamEvent
+	// closed once events are no longer read
+	done chan struct{}
+}
+
+// newCommandStream connects the output of cmd to a stream. It must be called
+// before the command is started.
+func newCommandStream(cmd *exec.Cmd) *commandStream {
+	s := &commandStream{
+		cmd:    cmd,
+		events: make(chan ExecuteStreamEvent),
+		done:   make(chan struct{}),
+	}
+	cmd.Stdout = &streamWriter{stream: s, eventType: ExecuteEventStdout}
+	cmd.Stderr = &streamWriter{stream: s, eventType: ExecuteEventStderr}
+	return s
+}
+
+// start starts the command in a cgroup with the given limits, if any.
+func (s *commandStream) start(limits *cgroup.Limits) error {
+	group, err := StartCommand(s.cmd, limits)
+	s.group = group
+	return err
+}
+
+// run hands the output of the started command to emit as it arrives, and an
+// exit event once the command has exited. The process group is killed when
+// ctx is done, the timeout expires or emit fails.
+func (s *commandStream) run(ctx context.Context, timeout time.Duration, emit func(ExecuteStreamEvent) error) {
+	pid := s.cmd.Process.Pid
+
+	// Register this PID to prevent zombie reaper from racing with cmd.Wait()
+	registry.Register(pid)
+	defer func() {
+		registry.Unregister(pid)
+		log.Debugf("[process/execute] Unregistered PID=%d", pid)
+	}()
+	defer close(s.done)
+	defer s.group.Remove()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	waitErr := make(chan error, 1)
+	go func() {
+		waitErr <- s.cmd.Wait()
+	}()
+
+	timedOut := false
+	emitting := true
+	for {
+		select {
+		case event := <-s.events:
+			if emitting && emit(event) != nil {
+				// the client is gone, the output is discarded from now on
+				emitting = false
+				cancel()
+			}
+		case <-timer.C:
+			timedOut = true
+			KillProcessGroup(s.cmd)
+		case <-ctx.Done():
+			KillProcessGroup(s.cmd)
+			// don't kill again
+			ctx = context.Background()
+		case err := <-waitErr:
+			// the output has been copied completely once Wait returns
+			exitCode := CommandExitCode(s.cmd, err)
+			if !emitting {
+				return
+			}
+			for _, w := range []io.Writer{s.cmd.Stdout, s.cmd.Stderr} {
+				if event, ok := w.(*streamWriter).rest(); ok {
+					_ = emit(event)
+				}
+			}
+			_ = emit(ExecuteStreamEvent{Type: ExecuteEventExit, ExitCode: &exitCode, TimedOut: timedOut, OomKilled: s.group.OOMKills() > 0})
+			return
+		}
+	}
+}
+
+type streamWriter struct {
+	stream    *commandStream
+	eventType string
+	// an incomplete UTF-8 sequence at the end of the last write, it is sent
+	// with the next chunk rather than mangled by JSON encoding
+	partial []byte
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	data := append(w.partial, p...)
+	w.partial = nil
+
+	data, partial := common.SplitIncompleteRune(data)
+	w.partial = append([]byte(nil), partial...)
+	if len(data) == 0 {
+		return len(p), nil
+	}
+
+	select {
+	case w.stream.events <- ExecuteStreamEvent{Type: w.eventType, Data: string(data)}:
+	case <-w.stream.done:
+	}
+	return len(p), nil
+}
+
+// rest returns what is left of an incomplete sequence once the output ended.
+func (w *streamWriter) rest() (ExecuteStreamEvent, bool) {
+	if len(w.partial) == 0 {
+		return ExecuteStreamEvent{}, false
+	}
+	return ExecuteStreamEvent{Type: w.eventType, Data: string(w.partial)}, true
+}
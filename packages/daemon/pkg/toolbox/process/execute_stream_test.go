@@ -0,0 +1,56 @@
+package process
+
+import (
+	"testing"
+)
+
+func TestStreamWriterSplitRune(t *testing.T) {
+	stream := &commandStream{
+		events: make(chan ExecuteStreamEvent, 4),
+		done:   make(chan struct{}),
+	}
+	w := &streamWriter{stream: stream, eventType: ExecuteEventStdout}
+
+	// "héllo ✓" with both multibyte runes split across writes
+	data := []byte("héllo ✓")
+	for _, chunk := range [][]byte{data[:2], data[2:8], data[8:]} {
+		if n, err := w.Write(chunk); err != nil || n != len(chunk) {
+			t.Fatalf("Write(%q) = %d, %v", chunk, n, err)
+		}
+	}
+	if _, ok := w.rest(); ok {
+		t.Fatal("expected nothing to be left over")
+	}
+	close(stream.events)
+
+	var got string
+	for event := range stream.events {
+		if event.Type != ExecuteEventStdout {
+			t.Fatalf("unexpected event type %s", event.Type)
+		}
+		got += event.Data
+	}
+	if got != string(data) {
+		t.Fatalf("got %q, want %q", got, data)
+	}
+}
+
+func TestStreamWriterTruncatedRune(t *testing.T) {
+	stream := &commandStream{
+		events: make(chan ExecuteStreamEvent, 1),
+		done:   make(chan struct{}),
+	}
+	w := &streamWriter{stream: stream, eventType: ExecuteEventStderr}
+
+	if _, err := w.Write([]byte("\xe2\x9c")); err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.events) != 0 {
+		t.Fatal("expected the incomplete rune to be held back")
+	}
+
+	event, ok := w.rest()
+	if !ok || event.Type != ExecuteEventStderr || event.Data != "\xe2\x9c" {
+		t.Fatalf("unexpected rest %+v, %v", event, ok)
+	}
+}
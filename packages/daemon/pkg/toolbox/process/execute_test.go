@@ -0,0 +1,69 @@
+package process
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/common"
+)
+
+func TestNewCommand(t *testing.T) {
+	shell := true
+
+	tests := []struct {
+		name    string
+		request ExecuteRequest
+		want    []string
+	}{
+		{
+			name:    "command",
+			request: ExecuteRequest{Command: `echo 'a b' "c" $HOME`},
+			want:    []string{"echo", "a b", "c", "$HOME"},
+		},
+		{
+			name:    "argv",
+			request: ExecuteRequest{Argv: []string{"echo", "'a b'", "$HOME | cat"}},
+			want:    []string{"echo", "'a b'", "$HOME | cat"},
+		},
+		{
+			name:    "shell",
+			request: ExecuteRequest{Command: "echo $HOME | cat", Shell: &shell},
+			want:    []string{common.GetShell(), "-c", "echo $HOME | cat"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := NewCommand(tt.request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(cmd.Args, tt.want) {
+				t.Fatalf("unexpected args %q, want %q", cmd.Args, tt.want)
+			}
+			if cmd.SysProcAttr == nil || !cmd.SysProcAttr.Setpgid {
+				t.Fatal("expected the command to get its own process group")
+			}
+		})
+	}
+}
+
+func TestNewCommandInvalid(t *testing.T) {
+	shell := true
+
+	tests := []struct {
+		name    string
+		request ExecuteRequest
+	}{
+		{"nothing", ExecuteRequest{}},
+		{"blank command", ExecuteRequest{Command: "   "}},
+		{"blank shell command", ExecuteRequest{Command: "   ", Shell: &shell}},
+		{"command and argv", ExecuteRequest{Command: "echo", Argv: []string{"echo"}}},
+		{"shell with argv", ExecuteRequest{Argv: []string{"echo"}, Shell: &shell}},
+		{"invalid env", ExecuteRequest{Command: "echo", Envs: map[string]string{"A=B": "c"}}},
+	}
+	for _, tt := range tests {
+		if _, err := NewCommand(tt.request); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
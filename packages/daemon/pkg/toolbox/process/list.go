@@ -0,0 +1,117 @@
+package process
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// ListProcesses godoc
+//
+//	@Summary		List processes
+//	@Description	List the processes of the sandbox ordered by PID, or as a tree of root processes with their children nested when tree is set
+//	@Tags			process
+//	@Produce		json
+//	@Param			tree	query	boolean	false	"Nest child processes under their parents"
+//	@Success		200		{array}	ProcessInfo
+//	@Router			/process/list [get]
+//
+//	@id				ListProcesses
+func ListProcesses(c *gin.Context) {
+	procs, err := process.ProcessesWithContext(c.Request.Context())
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	infos := make([]ProcessInfo, 0, len(procs))
+	for _, p := range procs {
+		// the process may exit while it is inspected
+		if info, ok := processInfo(p); ok {
+			infos = append(infos, info)
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Pid < infos[j].Pid
+	})
+
+	if c.Query("tree") == "true" {
+		infos = processTree(infos)
+	}
+
+	c.JSON(http.StatusOK, infos)
+}
+
+func processInfo(p *process.Process) (ProcessInfo, bool) {
+	ppid, err := p.Ppid()
+	if err != nil {
+		return ProcessInfo{}, false
+	}
+
+	info := ProcessInfo{
+		Pid:  int(p.Pid),
+		Ppid: int(ppid),
+	}
+
+	if pgid, err := syscall.Getpgid(info.Pid); err == nil {
+		info.Pgid = pgid
+	}
+	if user, err := p.Username(); err == nil {
+		info.User = user
+	}
+	if name, err := p.Name(); err == nil {
+		info.Name = name
+	}
+	if args, err := p.CmdlineSlice(); err == nil {
+		info.Cmdline = strings.Join(args, " ")
+	}
+	if cpu, err := p.CPUPercent(); err == nil {
+		info.CpuPercent = cpu
+	}
+	if mem, err := p.MemoryInfo(); err == nil {
+		info.Rss = mem.RSS
+	}
+	if created, err := p.CreateTime(); err == nil {
+		info.StartTime = time.UnixMilli(created)
+	}
+	return info, true
+}
+
+// processTree nests the processes under their parents. Processes whose parent
+// is not in the list are roots.
+func processTree(infos []ProcessInfo) []ProcessInfo {
+	byPid := make(map[int]int, len(infos))
+	children := make(map[int][]int, len(infos))
+	for i, info := range infos {
+		byPid[info.Pid] = i
+	}
+
+	var roots []int
+	for i, info := range infos {
+		if _, ok := byPid[info.Ppid]; ok && info.Ppid != info.Pid {
+			children[info.Ppid] = append(children[info.Ppid], i)
+		} else {
+			roots = append(roots, i)
+		}
+	}
+
+	var build func(i int) ProcessInfo
+	build = func(i int) ProcessInfo {
+		info := infos[i]
+		for _, child := range children[info.Pid] {
+			info.Children = append(info.Children, build(child))
+		}
+		return info
+	}
+
+	tree := make([]ProcessInfo, 0, len(roots))
+	for _, root := range roots {
+		tree = append(tree, build(root))
+	}
+	return tree
+}
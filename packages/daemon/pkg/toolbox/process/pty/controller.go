@@ -21,7 +21,7 @@ func NewPTYController(workDir string) *PTYController {
 // CreatePTYSession godoc
 //
 //	@Summary		Create a new PTY session
-//	@Description	Create a new pseudo-terminal session with specified configuration
+//	@Description	Create a new pseudo-terminal session with specified configuration. With limits the shell and its children run in a cgroup of their own.
 //	@Tags			process
 //	@Accept			json
 //	@Produce		json
@@ -74,6 +74,10 @@ func (p *PTYController) CreatePTYSession(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid value for rows - must be less than 1000"})
 		return
 	}
+	if err := req.Limits.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	session := &PTYSession{
 		info: PTYSessionInfo{
@@ -85,6 +89,7 @@ func (p *PTYController) CreatePTYSession(c *gin.Context) {
 			CreatedAt: time.Now(),
 			Active:    false,
 			LazyStart: req.LazyStart,
+			Limits:    req.Limits,
 		},
 		clients: cmap.New[*wsClient](),
 	}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 
+	"github.com/cofy-x/deck/apps/daemon/pkg/cgroup"
 	"github.com/cofy-x/deck/apps/daemon/pkg/common"
 	"github.com/cofy-x/deck/packages/core-go/pkg/log"
 	"github.com/creack/pty"
@@ -56,14 +57,27 @@ func (s *PTYSession) start() error {
 		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
 	}
 
+	group, err := cgroup.New(s.info.Limits)
+	if err != nil {
+		cancel()
+		return err
+	}
+	if err := group.Attach(cmd); err != nil {
+		group.Remove()
+		cancel()
+		return err
+	}
+
 	ptmx, err := pty.StartWithSize(cmd, &pty.Winsize{Rows: s.info.Rows, Cols: s.info.Cols})
 	if err != nil {
+		group.Remove()
 		cancel()
 		return fmt.Errorf("pty.StartWithSize: %w", err)
 	}
 
 	s.cmd = cmd
 	s.ptmx = ptmx
+	s.group = group
 	s.info.Active = true
 
 	log.Debugf("Started PTY session %s with PID %d", s.info.ID, s.cmd.Process.Pid)
@@ -105,8 +119,16 @@ func (s *PTYSession) start() error {
 			exitReason = " (clean exit)"
 		}
 
+		// kills what the shell left running
+		oomKilled := s.group.OOMKills() > 0
+		s.group.Remove()
+		if oomKilled {
+			exitReason = " (OOM killed)"
+		}
+
 		s.mu.Lock()
 		s.info.Active = false
+		s.oomKilled = oomKilled
 		sessionID := s.info.ID
 		s.mu.Unlock()
 
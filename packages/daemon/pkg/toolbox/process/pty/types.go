@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/cofy-x/deck/apps/daemon/pkg/cgroup"
 	"github.com/gorilla/websocket"
 	cmap "github.com/orcaman/concurrent-map/v2"
 )
@@ -43,6 +44,9 @@ type PTYSession struct {
 	ptmx   *os.File
 	ctx    context.Context
 	cancel context.CancelFunc
+	group  *cgroup.Group
+	// whether the OOM killer killed a process of the session
+	oomKilled bool
 
 	// multi-attach
 	clients   cmap.ConcurrentMap[string, *wsClient]
@@ -65,6 +69,7 @@ type PTYSessionInfo struct {
 	CreatedAt time.Time         `json:"createdAt"`
 	Active    bool              `json:"active"`
 	LazyStart bool              `json:"lazyStart"` // Whether this session uses lazy start
+	Limits    *cgroup.Limits    `json:"limits,omitempty" validate:"optional"`
 } //	@name	PtySessionInfo
 
 // API Request/Response types
@@ -76,7 +81,8 @@ type PTYCreateRequest struct {
 	Envs      map[string]string `json:"envs,omitempty"`
 	Cols      *uint16           `json:"cols" validate:"optional"`
 	Rows      *uint16           `json:"rows" validate:"optional"`
-	LazyStart bool              `json:"lazyStart,omitempty"` // Don't start PTY until first client connects
+	LazyStart bool              `json:"lazyStart,omitempty"`                  // Don't start PTY until first client connects
+	Limits    *cgroup.Limits    `json:"limits,omitempty" validate:"optional"` // Resource limits of the shell and its children, needs cgroup v2
 } //	@name	PtyCreateRequest
 
 // PTYCreateResponse represents the response when creating a PTY session
@@ -120,14 +120,21 @@ func (s *PTYSession) closeClientsWithExitCode(exitCode int, exitReason string) {
 	var wsCloseCode int
 	var exitReasonStr *string
 
+	s.mu.Lock()
+	oomKilled := s.oomKilled
+	s.mu.Unlock()
+
 	// Map PTY exit codes to WebSocket close codes
-	if exitCode == 0 {
+	if exitCode == 0 && !oomKilled {
 		wsCloseCode = websocket.CloseNormalClosure
 		exitReasonStr = nil // undefined for clean exit
 	} else {
 		wsCloseCode = websocket.CloseInternalServerErr
 		// Set human-readable reason for non-zero exits
 		switch {
+		case oomKilled:
+			reason := "OOM killed"
+			exitReasonStr = &reason
 		case exitCode == 130:
 			reason := "Ctrl+C"
 			exitReasonStr = &reason
@@ -151,11 +158,13 @@ func (s *PTYSession) closeClientsWithExitCode(exitCode int, exitReason string) {
 	type CloseData struct {
 		ExitCode   int     `json:"exitCode"`
 		ExitReason *string `json:"exitReason,omitempty"`
+		OomKilled  bool    `json:"oomKilled,omitempty"`
 	}
 
 	closeData := CloseData{
 		ExitCode:   exitCode,
 		ExitReason: exitReasonStr,
+		OomKilled:  oomKilled,
 	}
 
 	closeJSON, _ := json.Marshal(closeData)
@@ -0,0 +1,202 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	toolboxprocess "github.com/cofy-x/deck/apps/daemon/pkg/toolbox/process"
+	"github.com/gin-gonic/gin"
+	"github.com/shirou/gopsutil/v4/process"
+
+	"github.com/cofy-x/deck/packages/core-go/pkg/log"
+)
+
+// inputTimeout bounds how long writing input waits for a command that does
+// not read it.
+const inputTimeout = 10 * time.Second
+
+var (
+	errCommandExited     = errors.New("command has already exited")
+	errCommandNotStarted = errors.New("command has not started yet, earlier commands of the session are still running")
+)
+
+// SignalSessionCommand godoc
+//
+//	@Summary		Signal a session command
+//	@Description	Send SIGINT, SIGTERM or SIGKILL to the processes of a running command of a session. Background jobs of the session shell started by earlier commands are not signaled, and neither is the shell itself, so a command made of shell builtins only can't be interrupted.
+//	@Tags			process
+//	@Accept			json
+//	@Param			sessionId	path	string						true	"Session ID"
+//	@Param			commandId	path	string						true	"Command ID"
+//	@Param			request		body	SessionCommandSignalRequest	true	"Signal request"
+//	@Success		200
+//	@Router			/process/session/{sessionId}/command/{commandId}/signal [post]
+//
+//	@id				SignalSessionCommand
+func (s *SessionController) SignalSessionCommand(c *gin.Context) {
+	var request SessionCommandSignalRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	sig, err := toolboxprocess.ParseSignal(request.Signal)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	session, command, ok := s.runningCommand(c)
+	if !ok {
+		return
+	}
+	if command.StartedAt == nil {
+		c.AbortWithError(http.StatusConflict, errCommandNotStarted)
+		return
+	}
+
+	pids, err := s.commandProcesses(session, command)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if len(pids) == 0 {
+		c.AbortWithError(http.StatusConflict, errors.New("command has no running processes"))
+		return
+	}
+
+	// marked first, the command may exit as soon as it is signaled
+	session.markSignaled(command.Id)
+	for _, pid := range pids {
+		_ = s.signalProcessTree(pid, sig)
+		_ = syscall.Kill(pid, sig)
+	}
+	log.Debugf("Sent %v to command %s of session %s", sig, command.Id, session.id)
+
+	c.Status(http.StatusOK)
+}
+
+// SendSessionCommandInput godoc
+//
+//	@Summary		Write to the stdin of a session command
+//	@Description	Write data to the standard input of a running command of a session, optionally closing it afterwards. Only commands run with runAsync take input. Data can be written before the command has started, its stdin can only be closed once it has.
+//	@Tags			process
+//	@Accept			json
+//	@Param			sessionId	path	string						true	"Session ID"
+//	@Param			commandId	path	string						true	"Command ID"
+//	@Param			request		body	SessionCommandInputRequest	true	"Input request"
+//	@Success		200
+//	@Router			/process/session/{sessionId}/command/{commandId}/input [post]
+//
+//	@id				SendSessionCommandInput
+func (s *SessionController) SendSessionCommandInput(c *gin.Context) {
+	var request SessionCommandInputRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	session, command, ok := s.runningCommand(c)
+	if !ok {
+		return
+	}
+	// the shell would block forever opening a FIFO without writers
+	if request.Close && command.StartedAt == nil {
+		c.AbortWithError(http.StatusConflict, errCommandNotStarted)
+		return
+	}
+
+	stdin := session.commandInput(command.Id)
+	if stdin == nil {
+		c.AbortWithError(http.StatusConflict, errCommandExited)
+		return
+	}
+
+	if request.Data != "" {
+		// FIFOs support deadlines, a failure only means waiting longer
+		_ = stdin.SetWriteDeadline(time.Now().Add(inputTimeout))
+		if _, err := stdin.WriteString(request.Data); err != nil {
+			switch {
+			case errors.Is(err, os.ErrDeadlineExceeded):
+				c.AbortWithError(http.StatusConflict, errors.New("command is not reading its input"))
+			case errors.Is(err, os.ErrClosed):
+				c.AbortWithError(http.StatusConflict, errCommandExited)
+			default:
+				c.AbortWithError(http.StatusInternalServerError, err)
+			}
+			return
+		}
+	}
+
+	if request.Close {
+		session.closeInput(command.Id)
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// runningCommand looks up the running command of the request, aborting it
+// if there is none.
+func (s *SessionController) runningCommand(c *gin.Context) (*session, *Command, bool) {
+	session, ok := sessions.Get(c.Param("sessionId"))
+	if !ok {
+		c.AbortWithError(http.StatusNotFound, errors.New("session not found"))
+		return nil, nil, false
+	}
+	session.touch()
+
+	command, err := s.getSessionCommand(session, c.Param("commandId"))
+	if err != nil {
+		c.AbortWithError(http.StatusNotFound, err)
+		return nil, nil, false
+	}
+	if command.ExitCode != nil {
+		c.AbortWithError(http.StatusConflict, errCommandExited)
+		return nil, nil, false
+	}
+	if !session.active() {
+		c.AbortWithError(http.StatusGone, errors.New("session is no longer running"))
+		return nil, nil, false
+	}
+	return session, command, true
+}
+
+// commandProcesses returns the children of the session shell that run the
+// command, leaving out the background jobs the shell had when it started
+// the command.
+func (s *SessionController) commandProcesses(session *session, command *Command) ([]int, error) {
+	data, err := os.ReadFile(command.jobsPath(session.Dir(s.configDir)))
+	if err != nil {
+		return nil, err
+	}
+	// shells format the list differently, only the PIDs are numbers
+	jobs := map[int32]bool{}
+	for _, field := range strings.Fields(string(data)) {
+		if pid, err := strconv.ParseInt(field, 10, 32); err == nil {
+			jobs[int32(pid)] = true
+		}
+	}
+
+	shell, err := process.NewProcess(int32(session.cmd.Process.Pid))
+	if err != nil {
+		return nil, err
+	}
+	children, err := shell.Children()
+	if err != nil {
+		return nil, err
+	}
+
+	var pids []int
+	for _, child := range children {
+		if !jobs[child.Pid] {
+			pids = append(pids, int(child.Pid))
+		}
+	}
+	return pids, nil
+}
@@ -1,11 +1,24 @@
 package session
 
+import "time"
+
 type SessionController struct {
 	configDir string
+	// sessions idle for longer, or older than maxLifetime, are removed, zero
+	// disables the limit
+	idleTimeout time.Duration
+	maxLifetime time.Duration
 }
 
-func NewSessionController(configDir, workDir string) *SessionController {
-	return &SessionController{
-		configDir: configDir,
+func NewSessionController(configDir, workDir string, idleTimeout, maxLifetime time.Duration) *SessionController {
+	s := &SessionController{
+		configDir:   configDir,
+		idleTimeout: idleTimeout,
+		maxLifetime: maxLifetime,
+	}
+	s.loadSessions()
+	if idleTimeout > 0 || maxLifetime > 0 {
+		go s.reapSessions()
 	}
+	return s
 }
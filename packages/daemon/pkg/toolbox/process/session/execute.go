@@ -7,8 +7,8 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/cofy-x/deck/apps/daemon/internal/util"
@@ -61,19 +61,23 @@ func (s *SessionController) SessionExecuteCommand(c *gin.Context) {
 		return
 	}
 
-	session, ok := sessions[sessionId]
+	session, ok := sessions.Get(sessionId)
 	if !ok {
 		c.AbortWithError(http.StatusNotFound, errors.New("session not found"))
 		return
 	}
+	if !session.active() {
+		c.AbortWithError(http.StatusGone, errors.New("session is no longer running"))
+		return
+	}
 
 	cmdId := util.Pointer(uuid.NewString())
 
 	command := &Command{
 		Id:      *cmdId,
 		Command: request.Command,
+		Status:  CommandStatusRunning,
 	}
-	session.commands[*cmdId] = command
 
 	logFilePath, exitCodeFilePath := command.LogFilePath(session.Dir(s.configDir))
 	logDir := filepath.Dir(logFilePath)
@@ -91,25 +95,45 @@ func (s *SessionController) SessionExecuteCommand(c *gin.Context) {
 
 	defer logFile.Close()
 
+	// the daemon keeps the FIFO open for writing, so the shell can open it
+	// without blocking and the command reads EOF only once it is closed
+	stdinPath := command.stdinPath(session.Dir(s.configDir))
+	if err := syscall.Mkfifo(stdinPath, 0600); err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("failed to create stdin pipe: %w", err))
+		return
+	}
+	command.stdin, err = os.OpenFile(stdinPath, os.O_RDWR, 0)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("failed to open stdin pipe: %w", err))
+		return
+	}
+
 	cmdToExec := fmt.Sprintf(
 		`{
 	log=%q
 	dir=%q
+	sdir=%q
+	ip=%q
 
 	# per-command FIFOs
 	sp="$dir/stdout.pipe.%s.$$"; ep="$dir/stderr.pipe.%s.$$"
 	rm -f "$sp" "$ep" && mkfifo "$sp" "$ep" || exit 1
 
-	cleanup() { rm -f "$sp" "$ep"; }
+	cleanup() { rm -f "$sp" "$ep" "$ip"; }
 	trap 'cleanup' EXIT HUP INT TERM
 
 	# prefix each stream and append to shared log
 	( while IFS= read -r line || [ -n "$line" ]; do printf '%s%%s\n' "$line"; done < "$sp" ) >> "$log" & r1=$!
 	( while IFS= read -r line || [ -n "$line" ]; do printf '%s%%s\n' "$line"; done < "$ep" ) >> "$log" & r2=$!
 
-	# Run your command
-	{ %s; } > "$sp" 2> "$ep"
-	echo "$?" >> %s
+	# Run your command, the jobs listed once stdin is open mark it as started
+	{ jobs -p > "$dir/jobs"; %s; } < "$ip" > "$sp" 2> "$ep"
+	ec=$?
+
+	# record the state the command left the shell in before its exit code
+	pwd > "$sdir/.cwd.tmp" && mv -f "$sdir/.cwd.tmp" "$sdir/cwd"
+	env -0 > "$sdir/.env.tmp" && mv -f "$sdir/.env.tmp" "$sdir/env"
+	echo "$ec" >> %s
 
 	# drain labelers (cleanup via trap)
 	wait "$r1" "$r2"
@@ -118,17 +142,28 @@ func (s *SessionController) SessionExecuteCommand(c *gin.Context) {
 	cleanup
 }
 `+"\n",
-		logFilePath,    // %q  -> log
-		logDir,         // %q  -> dir
-		*cmdId, *cmdId, // %s  %s -> fifo names
+		logFilePath,              // %q  -> log
+		logDir,                   // %q  -> dir
+		session.Dir(s.configDir), // %q  -> sdir
+		stdinPath,                // %q  -> ip
+		*cmdId, *cmdId,           // %s  %s -> fifo names
 		toOctalEscapes(STDOUT_PREFIX), // %s  -> stdout prefix
 		toOctalEscapes(STDERR_PREFIX), // %s  -> stderr prefix
 		request.Command,               // %s  -> verbatim script body
 		exitCodeFilePath,              // %q
 	)
 
+	// Concurrent requests must not interleave their scripts, and commands
+	// are registered in the order the shell runs them
+	session.execMu.Lock()
+	command.oomKills = session.group.OOMKills()
+	session.addCommand(command)
 	_, err = session.stdinWriter.Write([]byte(cmdToExec))
+	session.execMu.Unlock()
+	s.saveSession(session)
+
 	if err != nil {
+		session.finishCommand(*cmdId, 1, time.Now(), true)
 		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("failed to write command: %w", err))
 		return
 	}
@@ -143,28 +178,27 @@ func (s *SessionController) SessionExecuteCommand(c *gin.Context) {
 	for {
 		select {
 		case <-session.ctx.Done():
-			session.commands[*cmdId].ExitCode = util.Pointer(1)
+			session.finishCommand(*cmdId, 1, time.Now(), true)
+			s.saveSession(session)
 
 			c.AbortWithError(http.StatusBadRequest, errors.New("session cancelled"))
 			return
 		default:
-			exitCode, err := os.ReadFile(exitCodeFilePath)
+			command, err = s.getSessionCommand(session, *cmdId)
 			if err != nil {
-				if os.IsNotExist(err) {
-					time.Sleep(50 * time.Millisecond)
-					continue
-				}
-				c.AbortWithError(http.StatusBadRequest, fmt.Errorf("failed to read exit code file: %w", err))
+				c.AbortWithError(http.StatusBadRequest, err)
 				return
 			}
-
-			exitCodeInt, err := strconv.Atoi(strings.TrimRight(string(exitCode), "\n"))
-			if err != nil {
-				c.AbortWithError(http.StatusBadRequest, fmt.Errorf("failed to convert exit code to int: %w", err))
-				return
+			// nobody can write to the stdin of a command that is waited for,
+			// so it reads EOF once the shell has opened the FIFO
+			if command.StartedAt != nil {
+				session.closeInput(*cmdId)
 			}
-
-			sessions[sessionId].commands[*cmdId].ExitCode = &exitCodeInt
+			if command.ExitCode == nil {
+				time.Sleep(50 * time.Millisecond)
+				continue
+			}
+			exitCodeInt := *command.ExitCode
 
 			logBytes, err := os.ReadFile(logFilePath)
 			if err != nil {
@@ -197,6 +231,7 @@ func (s *SessionController) SessionExecuteCommand(c *gin.Context) {
 				CommandId: cmdId,
 				Output:    &logContent,
 				ExitCode:  &exitCodeInt,
+				OomKilled: command.OomKilled,
 			})
 			return
 		}
@@ -0,0 +1,277 @@
+package session
+
+import (
+	"context"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/cofy-x/deck/packages/core-go/pkg/log"
+	cmap "github.com/orcaman/concurrent-map/v2"
+)
+
+// sessionManager holds the sessions. Handlers run concurrently, so sessions
+// are only looked up through the manager and their commands only accessed
+// through the methods of session, which guard them.
+type sessionManager struct {
+	sessions cmap.ConcurrentMap[string, *session]
+}
+
+var sessions = &sessionManager{
+	sessions: cmap.New[*session](),
+}
+
+// Get returns the session with the given ID.
+func (m *sessionManager) Get(id string) (*session, bool) {
+	return m.sessions.Get(id)
+}
+
+// Add adds a session, unless there already is one with its ID.
+func (m *sessionManager) Add(s *session) bool {
+	return m.sessions.SetIfAbsent(s.id, s)
+}
+
+// Delete removes the session with the given ID and returns it. Of concurrent
+// calls only one gets the session.
+func (m *sessionManager) Delete(id string) (*session, bool) {
+	return m.sessions.Pop(id)
+}
+
+// deleteSession removes s, unless it has been replaced by another session
+// with the same ID.
+func (m *sessionManager) deleteSession(s *session) bool {
+	return m.sessions.RemoveCb(s.id, func(_ string, v *session, exists bool) bool {
+		return exists && v == s
+	})
+}
+
+// List returns the sessions, oldest first.
+func (m *sessionManager) List() []*session {
+	list := make([]*session, 0, m.sessions.Count())
+	for _, s := range m.sessions.Items() {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].createdAt.Before(list[j].createdAt)
+	})
+	return list
+}
+
+// addCommand registers a command written to the shell of the session.
+func (s *session) addCommand(command *Command) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commands[command.Id] = command
+	s.lastActivity = time.Now()
+}
+
+// command returns a copy of a command of the session.
+func (s *session) command(id string) (*Command, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	command, ok := s.commands[id]
+	if !ok {
+		return nil, false
+	}
+	copied := *command
+	return &copied, true
+}
+
+// commandList returns copies of the commands of the session.
+func (s *session) commandList() []*Command {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]*Command, 0, len(s.commands))
+	for _, command := range s.commands {
+		copied := *command
+		list = append(list, &copied)
+	}
+	return list
+}
+
+// startCommand records when the shell started a command.
+func (s *session) startCommand(id string, startedAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if command, ok := s.commands[id]; ok && command.StartedAt == nil {
+		command.StartedAt = &startedAt
+	}
+}
+
+// finishCommand records the exit code of a command and returns a copy of it.
+// The exit code of a command is only recorded once. killed is set when the
+// command did not exit on its own, like when its shell died.
+func (s *session) finishCommand(id string, exitCode int, endedAt time.Time, killed bool) (*Command, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	command, ok := s.commands[id]
+	if !ok {
+		return nil, false
+	}
+	if command.ExitCode == nil {
+		command.ExitCode = &exitCode
+		command.EndedAt = &endedAt
+		command.OomKilled = s.group.OOMKills() > command.oomKills
+		command.Status = CommandStatusExited
+		if killed || command.OomKilled || (command.signaled && exitCode != 0) {
+			command.Status = CommandStatusKilled
+		}
+		if command.stdin != nil {
+			command.stdin.Close()
+			command.stdin = nil
+		}
+	}
+	copied := *command
+	return &copied, true
+}
+
+// markSignaled records that a running command has been signaled.
+func (s *session) markSignaled(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if command, ok := s.commands[id]; ok && command.ExitCode == nil {
+		command.signaled = true
+	}
+}
+
+// commandInput returns the stdin FIFO of a running command.
+func (s *session) commandInput(id string) *os.File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if command, ok := s.commands[id]; ok {
+		return command.stdin
+	}
+	return nil
+}
+
+// closeInput closes the stdin FIFO of a command, so it reads EOF.
+func (s *session) closeInput(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if command, ok := s.commands[id]; ok && command.stdin != nil {
+		command.stdin.Close()
+		command.stdin = nil
+	}
+}
+
+// closeInputs closes the stdin FIFOs of the commands, once the shell is gone.
+func (s *session) closeInputs() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, command := range s.commands {
+		if command.stdin != nil {
+			command.stdin.Close()
+			command.stdin = nil
+		}
+	}
+}
+
+// touch marks the session as used, which postpones reaping it for idleness.
+func (s *session) touch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastActivity = time.Now()
+}
+
+func (s *session) idleSince() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastActivity
+}
+
+// removeSession terminates the shell of a session removed from the manager
+// and deletes its logs.
+func (s *SessionController) removeSession(ctx context.Context, session *session) error {
+	// Terminate process group first with signals (SIGTERM -> SIGKILL)
+	err := s.terminateSession(ctx, session)
+	if err != nil {
+		log.Errorf("Failed to terminate session %s: %v", session.id, err)
+		// Continue with cleanup even if termination fails
+	}
+
+	// Cancel context after termination
+	session.cancel()
+	session.closeInputs()
+	session.group.Remove()
+
+	// Clean up session directory, once a save in progress is done
+	session.saveMu.Lock()
+	defer session.saveMu.Unlock()
+	return os.RemoveAll(session.Dir(s.configDir))
+}
+
+// abandonCommands finishes the commands left running when the shell of the
+// session died.
+func (s *SessionController) abandonCommands(session *session) {
+	session.closeInputs()
+	// a deleted session is gone with its logs
+	if current, ok := sessions.Get(session.id); !ok || current != session {
+		return
+	}
+	for _, command := range session.commandList() {
+		command, err := s.getSessionCommand(session, command.Id)
+		if err == nil && command.ExitCode == nil {
+			session.finishCommand(command.Id, 1, time.Now(), true)
+		}
+	}
+	s.saveSession(session)
+}
+
+// reapSessions periodically removes the sessions idle for longer than the
+// idle timeout or older than the max lifetime.
+func (s *SessionController) reapSessions() {
+	interval := time.Minute
+	for _, limit := range []time.Duration{s.idleTimeout, s.maxLifetime} {
+		if limit > 0 && limit/2 < interval {
+			interval = max(limit/2, time.Second)
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		s.reapExpired(now)
+	}
+}
+
+// reapExpired removes the sessions that expired at now. A session running a
+// command is not idle.
+func (s *SessionController) reapExpired(now time.Time) {
+	for _, session := range sessions.List() {
+		var reason string
+		switch {
+		case s.maxLifetime > 0 && now.Sub(session.createdAt) > s.maxLifetime:
+			reason = "reached its max lifetime"
+		case s.idleTimeout > 0 && now.Sub(session.idleSince()) > s.idleTimeout && !s.busy(session):
+			reason = "was idle"
+		default:
+			continue
+		}
+
+		if !sessions.deleteSession(session) {
+			continue
+		}
+		log.Infof("Removing session %s, it %s", session.id, reason)
+		if err := s.removeSession(context.Background(), session); err != nil {
+			log.Warnf("Failed to clean up session %s: %v", session.id, err)
+		}
+	}
+}
+
+// busy reports whether a command of the session is still running.
+func (s *SessionController) busy(session *session) bool {
+	if !session.active() {
+		return false
+	}
+	commands, err := s.getSessionCommands(session)
+	if err != nil {
+		// can't tell, rather keep the session
+		return true
+	}
+	for _, command := range commands {
+		if command.ExitCode == nil {
+			return true
+		}
+	}
+	return false
+}
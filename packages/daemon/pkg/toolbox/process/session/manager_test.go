@@ -0,0 +1,144 @@
+package session
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cmap "github.com/orcaman/concurrent-map/v2"
+)
+
+func setupController(t *testing.T, idleTimeout, maxLifetime time.Duration) *SessionController {
+	t.Helper()
+
+	previous := sessions
+	sessions = &sessionManager{sessions: cmap.New[*session]()}
+	t.Cleanup(func() { sessions = previous })
+
+	return &SessionController{
+		configDir:   t.TempDir(),
+		idleTimeout: idleTimeout,
+		maxLifetime: maxLifetime,
+	}
+}
+
+// addDeadSession adds a session without a shell, like the ones restored
+// after a restart.
+func addDeadSession(t *testing.T, s *SessionController, id string, createdAt, lastActivity time.Time) *session {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	session := &session{
+		id:           id,
+		commands:     map[string]*Command{},
+		ctx:          ctx,
+		cancel:       cancel,
+		createdAt:    createdAt,
+		lastActivity: lastActivity,
+	}
+	if err := os.MkdirAll(session.Dir(s.configDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if !sessions.Add(session) {
+		t.Fatalf("session %s already exists", id)
+	}
+	return session
+}
+
+func TestReapExpired(t *testing.T) {
+	now := time.Now()
+	s := setupController(t, time.Hour, 24*time.Hour)
+
+	idle := addDeadSession(t, s, "idle", now.Add(-3*time.Hour), now.Add(-2*time.Hour))
+	old := addDeadSession(t, s, "old", now.Add(-25*time.Hour), now)
+	used := addDeadSession(t, s, "used", now.Add(-3*time.Hour), now.Add(-time.Minute))
+
+	s.reapExpired(now)
+
+	for _, removed := range []*session{idle, old} {
+		if _, ok := sessions.Get(removed.id); ok {
+			t.Errorf("expected session %s to be removed", removed.id)
+		}
+		if _, err := os.Stat(removed.Dir(s.configDir)); !os.IsNotExist(err) {
+			t.Errorf("expected the directory of session %s to be removed, got %v", removed.id, err)
+		}
+	}
+	if _, ok := sessions.Get(used.id); !ok {
+		t.Error("expected the recently used session to be kept")
+	}
+}
+
+func TestSessionJournal(t *testing.T) {
+	now := time.Now()
+	s := setupController(t, 0, 0)
+
+	journaled := addDeadSession(t, s, "journaled", now, now)
+	journaled.addCommand(&Command{Id: "a", Command: "true", Status: CommandStatusRunning})
+	journaled.addCommand(&Command{Id: "b", Command: "sleep 1", Status: CommandStatusRunning})
+	journaled.addCommand(&Command{Id: "c", Command: "exit 3", Status: CommandStatusRunning})
+	journaled.finishCommand("a", 0, now, false)
+	s.saveSession(journaled)
+
+	// c exited after the journal was last written
+	_, exitCodePath := (&Command{Id: "c"}).LogFilePath(journaled.Dir(s.configDir))
+	if err := os.MkdirAll(filepath.Dir(exitCodePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(exitCodePath, []byte("3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions = &sessionManager{sessions: cmap.New[*session]()}
+	s.loadSessions()
+
+	restored, ok := sessions.Get("journaled")
+	if !ok {
+		t.Fatal("expected the session to be restored")
+	}
+	if restored.active() {
+		t.Error("expected the restored session to be dead")
+	}
+	if !restored.createdAt.Equal(journaled.createdAt) {
+		t.Errorf("expected createdAt %v, got %v", journaled.createdAt, restored.createdAt)
+	}
+
+	finished, ok := restored.command("a")
+	if !ok || finished.ExitCode == nil || *finished.ExitCode != 0 || finished.Status != CommandStatusExited {
+		t.Errorf("expected exited command a with exit code 0, got %+v", finished)
+	}
+	// the shell running b is gone
+	if killed, ok := restored.command("b"); !ok || killed.ExitCode == nil || *killed.ExitCode != 1 || killed.Status != CommandStatusKilled || killed.EndedAt == nil {
+		t.Errorf("expected killed command b with exit code 1, got %+v", killed)
+	}
+	if exited, ok := restored.command("c"); !ok || exited.ExitCode == nil || *exited.ExitCode != 3 || exited.Status != CommandStatusExited {
+		t.Errorf("expected exited command c with exit code 3, got %+v", exited)
+	}
+}
+
+func TestSaveReplacedSession(t *testing.T) {
+	now := time.Now()
+	s := setupController(t, 0, 0)
+
+	old := addDeadSession(t, s, "replaced", now, now)
+	if !sessions.deleteSession(old) {
+		t.Fatal("expected the session to be deleted")
+	}
+	replacement := addDeadSession(t, s, "replaced", now, now)
+	s.saveSession(replacement)
+
+	old.addCommand(&Command{Id: "stale", Command: "true", Status: CommandStatusRunning})
+	s.saveSession(old)
+
+	sessions = &sessionManager{sessions: cmap.New[*session]()}
+	s.loadSessions()
+	restored, ok := sessions.Get("replaced")
+	if !ok {
+		t.Fatal("expected the session to be restored")
+	}
+	if _, ok := restored.command("stale"); ok {
+		t.Error("expected the replaced session not to overwrite the journal")
+	}
+}
@@ -0,0 +1,159 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/cgroup"
+	"github.com/cofy-x/deck/packages/core-go/pkg/log"
+)
+
+// metadataFile is the journal of a session, kept in the session directory
+// next to the command logs so both outlive a restart of the daemon.
+const metadataFile = "session.json"
+
+type sessionMetadata struct {
+	SessionId string         `json:"sessionId"`
+	CreatedAt time.Time      `json:"createdAt"`
+	Shell     string         `json:"shell,omitempty"`
+	Limits    *cgroup.Limits `json:"limits,omitempty"`
+	Commands  []*Command     `json:"commands"`
+}
+
+// saveSession journals the metadata of a session. A session that can't be
+// journaled keeps working, it is only lost on restart, so failures are just
+// logged.
+func (s *SessionController) saveSession(session *session) {
+	// the latest state must be written last
+	session.saveMu.Lock()
+	defer session.saveMu.Unlock()
+
+	// a deleted or replaced session must not write into the directory of
+	// the session that took its ID
+	if current, ok := sessions.Get(session.id); !ok || current != session {
+		return
+	}
+
+	data, err := json.Marshal(sessionMetadata{
+		SessionId: session.id,
+		CreatedAt: session.createdAt,
+		Shell:     session.shell,
+		Limits:    session.limits,
+		Commands:  session.commandList(),
+	})
+	if err == nil {
+		err = writeFileAtomic(filepath.Join(session.Dir(s.configDir), metadataFile), data)
+	}
+	if err != nil {
+		log.Warnf("Failed to save session %s: %v", session.id, err)
+	}
+}
+
+// loadSessions restores the sessions journaled by an earlier run of the
+// daemon. Their shells are gone, so they are loaded as dead sessions whose
+// commands and logs can still be queried. Commands still running when the
+// daemon stopped are finished.
+func (s *SessionController) loadSessions() {
+	dir := filepath.Join(s.configDir, "sessions")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("Failed to load sessions: %v", err)
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		// directories of daemons predating the journal have no metadata
+		path := filepath.Join(dir, entry.Name(), metadataFile)
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var metadata sessionMetadata
+		if err := json.Unmarshal(data, &metadata); err != nil || metadata.SessionId != entry.Name() {
+			log.Warnf("Skipping invalid journal of session %s", entry.Name())
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		session := &session{
+			id:        metadata.SessionId,
+			commands:  map[string]*Command{},
+			ctx:       ctx,
+			cancel:    cancel,
+			shell:     metadata.Shell,
+			limits:    metadata.Limits,
+			createdAt: metadata.CreatedAt,
+			// the journal is written whenever the session is used
+			lastActivity: info.ModTime(),
+		}
+		for _, command := range metadata.Commands {
+			if command == nil || command.Id == "" {
+				continue
+			}
+			// journaled before commands had a status
+			if command.Status == "" {
+				command.Status = CommandStatusRunning
+				if command.ExitCode != nil {
+					command.Status = CommandStatusExited
+				}
+			}
+			session.commands[command.Id] = command
+		}
+		sessions.Add(session)
+		s.finishRestoredCommands(session)
+		log.Debugf("Restored session %s with %d commands", session.id, len(session.commands))
+	}
+}
+
+// finishRestoredCommands finishes the commands of a restored session that
+// were still running when the journal was last written. Commands that wrote
+// their exit code since get it, the others died with the shell and are
+// killed like in abandonCommands.
+func (s *SessionController) finishRestoredCommands(session *session) {
+	killed := false
+	for _, command := range session.commandList() {
+		command, err := s.getSessionCommand(session, command.Id)
+		if err == nil && command.ExitCode == nil {
+			session.finishCommand(command.Id, 1, time.Now(), true)
+			killed = true
+		}
+	}
+	if killed {
+		s.saveSession(session)
+	}
+}
+
+// writeFileAtomic replaces the file at path, so a crash never leaves a
+// truncated journal behind.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
@@ -6,14 +6,14 @@ import (
 	"fmt"
 	"net/http"
 	"os"
-	"os/exec"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/cofy-x/deck/apps/daemon/internal/util"
-	"github.com/cofy-x/deck/apps/daemon/pkg/common"
+	"github.com/cofy-x/deck/apps/daemon/pkg/cgroup"
+	"github.com/cofy-x/deck/apps/daemon/pkg/workspace"
 	"github.com/gin-gonic/gin"
 	"github.com/shirou/gopsutil/v4/process"
 
@@ -23,12 +23,12 @@ import (
 const TERMINATION_GRACE_PERIOD = 5 * time.Second
 const TERMINATION_CHECK_INTERVAL = 100 * time.Millisecond
 
-var sessions = map[string]*session{}
+var errSessionExists = errors.New("session already exists")
 
 // CreateSession godoc
 //
 //	@Summary		Create a new session
-//	@Description	Create a new shell session for command execution
+//	@Description	Create a new shell session for command execution. An existing session with the same ID whose shell is gone, e.g. one restored after a restart of the daemon, is replaced along with its commands and logs. With limits the shell and its commands run in a cgroup of their own. The shell must be POSIX compatible.
 //	@Tags			process
 //	@Accept			json
 //	@Produce		json
@@ -38,12 +38,34 @@ var sessions = map[string]*session{}
 //
 //	@id				CreateSession
 func (s *SessionController) CreateSession(c *gin.Context) {
-	ctx, cancel := context.WithCancel(context.Background())
+	var request CreateSessionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
 
-	cmd := exec.CommandContext(ctx, common.GetShell())
-	cmd.Env = os.Environ()
+	if err := request.Limits.Validate(); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	if existing, ok := sessions.Get(request.SessionId); ok {
+		if existing.active() {
+			c.AbortWithError(http.StatusConflict, errSessionExists)
+			return
+		}
+		// a session whose shell is gone, like the ones restored after a
+		// restart, is replaced together with its logs
+		if sessions.deleteSession(existing) {
+			if err := s.removeSession(c.Request.Context(), existing); err != nil {
+				c.AbortWithError(http.StatusInternalServerError, err)
+				return
+			}
+		}
+	}
 
 	// for backward compatibility (only sdk clients before 0.103.X), we use the home directory as the default directory
+	var dir string
 	sdkVersion := util.ExtractSdkVersionFromHeader(c.Request.Header)
 	versionComparison, err := util.CompareVersions(sdkVersion, "0.103.0-0")
 	if err != nil {
@@ -55,22 +77,21 @@ func (s *SessionController) CreateSession(c *gin.Context) {
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
 			c.AbortWithError(http.StatusInternalServerError, err)
-			cancel()
 			return
 		}
-		cmd.Dir = homeDir
+		dir = homeDir
 	}
 
-	var request CreateSessionRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
-		cancel()
-		return
-	}
+	ctx, cancel := context.WithCancel(context.Background())
 
-	if _, ok := sessions[request.SessionId]; ok {
-		c.AbortWithError(http.StatusConflict, errors.New("session already exists"))
+	cmd, err := shellCommand(ctx, request, dir)
+	if err != nil {
 		cancel()
+		if errors.Is(err, workspace.ErrOutsideRoots) {
+			c.AbortWithError(http.StatusForbidden, err)
+			return
+		}
+		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
@@ -81,28 +102,61 @@ func (s *SessionController) CreateSession(c *gin.Context) {
 		return
 	}
 
-	err = cmd.Start()
+	// the shell and every command of the session share the limits
+	group, err := cgroup.New(request.Limits)
+	if err == nil {
+		if err = group.Attach(cmd); err == nil {
+			err = cmd.Start()
+		}
+	}
 	if err != nil {
+		group.Remove()
 		c.AbortWithError(http.StatusInternalServerError, err)
 		cancel()
 		return
 	}
 
+	now := time.Now()
 	session := &session{
-		id:          request.SessionId,
-		cmd:         cmd,
-		stdinWriter: stdinWriter,
-		commands:    map[string]*Command{},
-		ctx:         ctx,
-		cancel:      cancel,
+		id:           request.SessionId,
+		cmd:          cmd,
+		stdinWriter:  stdinWriter,
+		commands:     map[string]*Command{},
+		ctx:          ctx,
+		cancel:       cancel,
+		shell:        cmd.Path,
+		limits:       request.Limits,
+		group:        group,
+		createdAt:    now,
+		lastActivity: now,
 	}
-	sessions[request.SessionId] = session
+
+	// the session dies with its shell
+	go func() {
+		_ = cmd.Wait()
+		cancel()
+		s.abandonCommands(session)
+	}()
 
 	err = os.MkdirAll(session.Dir(s.configDir), 0755)
+	if err == nil {
+		err = writeShellState(session.Dir(s.configDir), cmd)
+	}
+	if err == nil && !sessions.Add(session) {
+		// created concurrently under the same ID
+		err = errSessionExists
+	}
 	if err != nil {
+		cancel()
+		group.Remove()
+		if errors.Is(err, errSessionExists) {
+			c.AbortWithError(http.StatusConflict, err)
+			return
+		}
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
+	s.saveSession(session)
 
 	c.Status(http.StatusCreated)
 }
@@ -120,37 +174,25 @@ func (s *SessionController) CreateSession(c *gin.Context) {
 func (s *SessionController) DeleteSession(c *gin.Context) {
 	sessionId := c.Param("sessionId")
 
-	session, ok := sessions[sessionId]
+	session, ok := sessions.Delete(sessionId)
 	if !ok {
 		_ = c.AbortWithError(http.StatusNotFound, errors.New("session not found"))
 		return
 	}
 
-	// Terminate process group first with signals (SIGTERM -> SIGKILL)
-	err := s.terminateSession(c.Request.Context(), session)
-	if err != nil {
-		log.Errorf("Failed to terminate session %s: %v", session.id, err)
-		// Continue with cleanup even if termination fails
-	}
-
-	// Cancel context after termination
-	session.cancel()
-
-	// Clean up session directory
-	err = os.RemoveAll(session.Dir(s.configDir))
+	err := s.removeSession(c.Request.Context(), session)
 	if err != nil {
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	delete(sessions, session.id)
 	c.Status(http.StatusNoContent)
 }
 
 // ListSessions godoc
 //
 //	@Summary		List all sessions
-//	@Description	Get a list of all active shell sessions
+//	@Description	Get a list of all shell sessions, including the sessions restored after a restart of the daemon whose shells are gone
 //	@Tags			process
 //	@Produce		json
 //	@Success		200	{array}	Session
@@ -160,16 +202,23 @@ func (s *SessionController) DeleteSession(c *gin.Context) {
 func (s *SessionController) ListSessions(c *gin.Context) {
 	sessionDTOs := []Session{}
 
-	for sessionId := range sessions {
-		commands, err := s.getSessionCommands(sessionId)
+	for _, session := range sessions.List() {
+		commands, err := s.getSessionCommands(session)
 		if err != nil {
 			c.AbortWithError(http.StatusInternalServerError, err)
 			return
 		}
 
+		cwd, envs := shellState(session.Dir(s.configDir))
 		sessionDTOs = append(sessionDTOs, Session{
-			SessionId: sessionId,
+			SessionId: session.id,
 			Commands:  commands,
+			Active:    session.active(),
+			CreatedAt: session.createdAt,
+			Limits:    session.limits,
+			Shell:     session.shell,
+			Cwd:       cwd,
+			Envs:      envs,
 		})
 	}
 
@@ -179,7 +228,7 @@ func (s *SessionController) ListSessions(c *gin.Context) {
 // GetSession godoc
 //
 //	@Summary		Get session details
-//	@Description	Get details of a specific session including its commands
+//	@Description	Get details of a specific session including its commands, and the working directory and exported environment variables its last command left the shell in
 //	@Tags			process
 //	@Produce		json
 //	@Param			sessionId	path		string	true	"Session ID"
@@ -190,21 +239,29 @@ func (s *SessionController) ListSessions(c *gin.Context) {
 func (s *SessionController) GetSession(c *gin.Context) {
 	sessionId := c.Param("sessionId")
 
-	_, ok := sessions[sessionId]
+	session, ok := sessions.Get(sessionId)
 	if !ok {
 		c.AbortWithError(http.StatusNotFound, errors.New("session not found"))
 		return
 	}
+	session.touch()
 
-	commands, err := s.getSessionCommands(sessionId)
+	commands, err := s.getSessionCommands(session)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
 
+	cwd, envs := shellState(session.Dir(s.configDir))
 	c.JSON(http.StatusOK, Session{
 		SessionId: sessionId,
 		Commands:  commands,
+		Active:    session.active(),
+		CreatedAt: session.createdAt,
+		Limits:    session.limits,
+		Shell:     session.shell,
+		Cwd:       cwd,
+		Envs:      envs,
 	})
 }
 
@@ -224,7 +281,14 @@ func (s *SessionController) GetSessionCommand(c *gin.Context) {
 	sessionId := c.Param("sessionId")
 	cmdId := c.Param("commandId")
 
-	command, err := s.getSessionCommand(sessionId, cmdId)
+	session, ok := sessions.Get(sessionId)
+	if !ok {
+		c.AbortWithError(http.StatusNotFound, errors.New("session not found"))
+		return
+	}
+	session.touch()
+
+	command, err := s.getSessionCommand(session, cmdId)
 	if err != nil {
 		c.AbortWithError(http.StatusNotFound, err)
 		return
@@ -233,15 +297,10 @@ func (s *SessionController) GetSessionCommand(c *gin.Context) {
 	c.JSON(http.StatusOK, command)
 }
 
-func (s *SessionController) getSessionCommands(sessionId string) ([]*Command, error) {
-	session, ok := sessions[sessionId]
-	if !ok {
-		return nil, errors.New("session not found")
-	}
-
+func (s *SessionController) getSessionCommands(session *session) ([]*Command, error) {
 	commands := []*Command{}
-	for _, command := range session.commands {
-		cmd, err := s.getSessionCommand(sessionId, command.Id)
+	for _, command := range session.commandList() {
+		cmd, err := s.getSessionCommand(session, command.Id)
 		if err != nil {
 			return nil, err
 		}
@@ -251,13 +310,10 @@ func (s *SessionController) getSessionCommands(sessionId string) ([]*Command, er
 	return commands, nil
 }
 
-func (s *SessionController) getSessionCommand(sessionId, cmdId string) (*Command, error) {
-	session, ok := sessions[sessionId]
-	if !ok {
-		return nil, errors.New("session not found")
-	}
-
-	command, ok := session.commands[cmdId]
+// getSessionCommand returns a copy of a command of the session, with the
+// exit code once the command has exited.
+func (s *SessionController) getSessionCommand(session *session, cmdId string) (*Command, error) {
+	command, ok := session.command(cmdId)
 	if !ok {
 		return nil, errors.New("command not found")
 	}
@@ -266,7 +322,16 @@ func (s *SessionController) getSessionCommand(sessionId, cmdId string) (*Command
 		return command, nil
 	}
 
-	_, exitCodeFilePath := command.LogFilePath(session.Dir(s.configDir))
+	sessionDir := session.Dir(s.configDir)
+	if command.StartedAt == nil {
+		if info, err := os.Stat(command.jobsPath(sessionDir)); err == nil {
+			startedAt := info.ModTime()
+			session.startCommand(cmdId, startedAt)
+			command.StartedAt = &startedAt
+		}
+	}
+
+	_, exitCodeFilePath := command.LogFilePath(sessionDir)
 	exitCode, err := os.ReadFile(exitCodeFilePath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -280,7 +345,12 @@ func (s *SessionController) getSessionCommand(sessionId, cmdId string) (*Command
 		return nil, errors.New("failed to convert exit code to int")
 	}
 
-	command.ExitCode = &exitCodeInt
+	endedAt := time.Now()
+	if info, err := os.Stat(exitCodeFilePath); err == nil {
+		endedAt = info.ModTime()
+	}
+	command, _ = session.finishCommand(cmdId, exitCodeInt, endedAt, false)
+	s.saveSession(session)
 
 	return command, nil
 }
@@ -0,0 +1,112 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/common"
+	"github.com/cofy-x/deck/apps/daemon/pkg/workspace"
+)
+
+// The shell state after the last command, written next to the journal by
+// the wrapper script of every command.
+const (
+	cwdFile = "cwd"
+	envFile = "env"
+)
+
+// shellCommand returns the shell of a new session, configured with the
+// working directory and environment variables of the request. Without a
+// working directory the shell starts in dir.
+func shellCommand(ctx context.Context, request CreateSessionRequest, dir string) (*exec.Cmd, error) {
+	shell := common.GetShell()
+	if request.Shell != nil {
+		path, err := exec.LookPath(*request.Shell)
+		if err != nil {
+			return nil, fmt.Errorf("invalid shell: %w", err)
+		}
+		shell = path
+	}
+
+	env := os.Environ()
+	for key, value := range request.Envs {
+		if key == "" || strings.ContainsAny(key, "=\x00") || strings.ContainsRune(value, 0) {
+			return nil, fmt.Errorf("invalid environment variable %q", key)
+		}
+		env = append(env, key+"="+value)
+	}
+
+	if request.Cwd != nil {
+		cwd, err := workspace.Check(*request.Cwd)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(cwd)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cwd: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("invalid cwd: %s is not a directory", cwd)
+		}
+		dir = cwd
+	}
+
+	cmd := exec.CommandContext(ctx, shell)
+	cmd.Env = env
+	cmd.Dir = dir
+	return cmd, nil
+}
+
+// writeShellState records the state a new shell starts with, so sessions
+// report it before their first command has run.
+func writeShellState(dir string, cmd *exec.Cmd) error {
+	cwd := cmd.Dir
+	if cwd == "" {
+		var err error
+		if cwd, err = os.Getwd(); err != nil {
+			return err
+		}
+	}
+	if err := writeFileAtomic(filepath.Join(dir, cwdFile), []byte(cwd+"\n")); err != nil {
+		return err
+	}
+
+	var env strings.Builder
+	for _, entry := range cmd.Env {
+		env.WriteString(entry)
+		env.WriteByte(0)
+	}
+	return writeFileAtomic(filepath.Join(dir, envFile), []byte(env.String()))
+}
+
+// shellState returns the working directory and exported environment the
+// last command left the shell of a session in. Sessions journaled before
+// the state was recorded have none.
+func shellState(dir string) (string, map[string]string) {
+	cwd, err := os.ReadFile(filepath.Join(dir, cwdFile))
+	if err != nil {
+		return "", nil
+	}
+	env, err := os.ReadFile(filepath.Join(dir, envFile))
+	if err != nil {
+		return "", nil
+	}
+	return strings.TrimSuffix(string(cwd), "\n"), parseEnv(string(env))
+}
+
+// parseEnv parses the output of env -0, NUL terminated entries whose values
+// may span lines.
+func parseEnv(data string) map[string]string {
+	envs := map[string]string{}
+	for _, entry := range strings.Split(data, "\x00") {
+		key, value, ok := strings.Cut(entry, "=")
+		if ok && key != "" {
+			envs[key] = value
+		}
+	}
+	return envs
+}
@@ -0,0 +1,21 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEnv(t *testing.T) {
+	envs := parseEnv("HOME=/root\x00MULTI=first\nFAKE=value\n=odd\x00EMPTY=\x00PATH=/usr/bin:/bin\x00")
+
+	// a continuation line looking like a variable stays part of the value
+	expected := map[string]string{
+		"HOME":  "/root",
+		"MULTI": "first\nFAKE=value\n=odd",
+		"EMPTY": "",
+		"PATH":  "/usr/bin:/bin",
+	}
+	if !reflect.DeepEqual(envs, expected) {
+		t.Errorf("expected %v, got %v", expected, envs)
+	}
+}
@@ -3,18 +3,35 @@ package session
 import (
 	"context"
 	"io"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/cgroup"
 )
 
 type CreateSessionRequest struct {
 	SessionId string `json:"sessionId" validate:"required"`
+	// Resource limits shared by the shell of the session and all its
+	// commands, needs cgroup v2
+	Limits *cgroup.Limits `json:"limits,omitempty" validate:"optional"`
+	// Initial working directory of the shell
+	Cwd *string `json:"cwd,omitempty" validate:"optional"`
+	// Environment variables set in addition to the daemon's environment
+	Envs map[string]string `json:"envs,omitempty" validate:"optional"`
+	// POSIX compatible shell to run, defaults to the user's shell
+	Shell *string `json:"shell,omitempty" validate:"optional"`
 } //	@name	CreateSessionRequest
 
 type SessionExecuteRequest struct {
-	Command  string `json:"command" validate:"required"`
-	RunAsync bool   `json:"runAsync" validate:"optional"`
-	Async    bool   `json:"async" validate:"optional"`
+	Command string `json:"command" validate:"required"`
+	// Return the command ID right away instead of waiting for the command to
+	// exit. Only async commands can be sent input, the stdin of a synchronous
+	// command is closed as soon as it starts.
+	RunAsync bool `json:"runAsync" validate:"optional"`
+	Async    bool `json:"async" validate:"optional"`
 } //	@name	SessionExecuteRequest
 
 type SessionExecuteResponse struct {
@@ -23,36 +40,115 @@ type SessionExecuteResponse struct {
 	Stdout    *string `json:"stdout" validate:"optional"`
 	Stderr    *string `json:"stderr" validate:"optional"`
 	ExitCode  *int    `json:"exitCode" validate:"optional"`
+	// Set when the OOM killer killed a process of the session while the
+	// command ran
+	OomKilled bool `json:"oomKilled,omitempty" validate:"optional"`
 } //	@name	SessionExecuteResponse
 
 type Session struct {
 	SessionId string     `json:"sessionId" validate:"required"`
 	Commands  []*Command `json:"commands" validate:"required"`
+	// Whether the shell of the session is running. Sessions restored after
+	// a restart of the daemon are not, only their commands can be queried
+	Active    bool           `json:"active" validate:"required"`
+	CreatedAt time.Time      `json:"createdAt" validate:"required"`
+	Limits    *cgroup.Limits `json:"limits,omitempty" validate:"optional"`
+	Shell     string         `json:"shell,omitempty" validate:"optional"`
+	// Working directory of the shell after the last command
+	Cwd string `json:"cwd,omitempty" validate:"optional"`
+	// Environment variables exported by the shell after the last command
+	Envs map[string]string `json:"envs,omitempty" validate:"optional"`
 } //	@name	Session
 
 type session struct {
 	id          string
 	cmd         *exec.Cmd
 	stdinWriter io.Writer
-	commands    map[string]*Command
 	ctx         context.Context
 	cancel      context.CancelFunc
+	shell       string
+	limits      *cgroup.Limits
+	group       *cgroup.Group
+	createdAt   time.Time
+
+	// guards commands and lastActivity
+	mu           sync.Mutex
+	commands     map[string]*Command
+	lastActivity time.Time
+
+	// serializes writing commands to the shell
+	execMu sync.Mutex
+	// serializes writing the journal
+	saveMu sync.Mutex
 }
 
 func (s *session) Dir(configDir string) string {
 	return filepath.Join(configDir, "sessions", s.id)
 }
 
+// active reports whether the shell of the session is running.
+func (s *session) active() bool {
+	return s.ctx.Err() == nil
+}
+
+type CommandStatus string
+
+const (
+	CommandStatusRunning CommandStatus = "running"
+	CommandStatusExited  CommandStatus = "exited"
+	// Exited after being signaled, OOM killed, or with its session
+	CommandStatusKilled CommandStatus = "killed"
+)
+
 type Command struct {
 	Id       string `json:"id" validate:"required"`
 	Command  string `json:"command" validate:"required"`
 	ExitCode *int   `json:"exitCode,omitempty" validate:"optional"`
+	// Set when the OOM killer killed a process of the session while the
+	// command ran
+	OomKilled bool          `json:"oomKilled,omitempty" validate:"optional"`
+	Status    CommandStatus `json:"status" validate:"required"`
+	// When the shell started the command, unset while earlier commands of
+	// the session are still running
+	StartedAt *time.Time `json:"startedAt,omitempty" validate:"optional"`
+	EndedAt   *time.Time `json:"endedAt,omitempty" validate:"optional"`
+
+	// OOM kills of the session when the command was started
+	oomKills int
+	// set once the command has been signaled through the API
+	signaled bool
+	// write end of the stdin FIFO of the command, closed once it exits
+	stdin *os.File
 } //	@name	Command
 
 func (c *Command) LogFilePath(sessionDir string) (string, string) {
 	return filepath.Join(sessionDir, c.Id, "output.log"), filepath.Join(sessionDir, c.Id, "exit_code")
 }
 
+// stdinPath is the FIFO the command reads its standard input from.
+func (c *Command) stdinPath(sessionDir string) string {
+	return filepath.Join(sessionDir, c.Id, "stdin.pipe")
+}
+
+// jobsPath lists the background jobs of the shell when it started the
+// command, which are not signaled with it. The shell writes the list once
+// it has opened the stdin FIFO, right before running the command.
+func (c *Command) jobsPath(sessionDir string) string {
+	return filepath.Join(sessionDir, c.Id, "jobs")
+}
+
+type SessionCommandSignalRequest struct {
+	// SIGINT, SIGTERM or SIGKILL, the SIG prefix is optional
+	Signal string `json:"signal" validate:"required"`
+} //	@name	SessionCommandSignalRequest
+
+type SessionCommandInputRequest struct {
+	// Written to the standard input of the command as is
+	Data string `json:"data,omitempty" validate:"optional"`
+	// Close the standard input after writing data, so the command reads EOF
+	Close bool `json:"close,omitempty" validate:"optional"`
+} //	@name	SessionCommandInputRequest
+
 type SessionCommandLogsResponse struct {
 	Stdout string `json:"stdout" validate:"required"`
 	Stderr string `json:"stderr" validate:"required"`
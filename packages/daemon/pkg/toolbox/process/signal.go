@@ -0,0 +1,120 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/cofy-x/deck/packages/core-go/pkg/log"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errSignalDaemon      = errors.New("refusing to signal the daemon")
+	errSignalDaemonGroup = errors.New("refusing to signal the process group of the daemon")
+)
+
+var signals = map[string]syscall.Signal{
+	"SIGINT":  syscall.SIGINT,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGKILL": syscall.SIGKILL,
+}
+
+// SignalProcess godoc
+//
+//	@Summary		Signal a process
+//	@Description	Send SIGINT, SIGTERM or SIGKILL to a process, or to its whole process group. The daemon and its own process group can't be signaled.
+//	@Tags			process
+//	@Accept			json
+//	@Param			pid		path	integer			true	"Process ID"
+//	@Param			request	body	SignalRequest	true	"Signal request"
+//	@Success		200
+//	@Router			/process/{pid}/signal [post]
+//
+//	@id				SignalProcess
+func SignalProcess(c *gin.Context) {
+	pid, err := strconv.Atoi(c.Param("pid"))
+	if err != nil || pid <= 0 {
+		c.AbortWithError(http.StatusBadRequest, errors.New("invalid pid"))
+		return
+	}
+
+	var request SignalRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	sig, err := ParseSignal(request.Signal)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	target, err := signalTarget(pid, request.Group)
+	if err != nil {
+		abortWithSignalError(c, err)
+		return
+	}
+
+	if err := syscall.Kill(target, sig); err != nil {
+		abortWithSignalError(c, err)
+		return
+	}
+	log.Debugf("[process/signal] Sent %v to %d", sig, target)
+
+	c.Status(http.StatusOK)
+}
+
+// signalTarget returns the pid to pass to kill for signaling pid, or its whole
+// process group when group is set. The daemon and its own process group are
+// refused.
+func signalTarget(pid int, group bool) (int, error) {
+	if !group {
+		if pid == os.Getpid() {
+			return 0, errSignalDaemon
+		}
+		return pid, nil
+	}
+
+	pgid, err := syscall.Getpgid(pid)
+	if err != nil {
+		return 0, err
+	}
+	if pgid <= 1 || pgid == syscall.Getpgrp() {
+		return 0, errSignalDaemonGroup
+	}
+	// a negative pid signals the whole process group
+	return -pgid, nil
+}
+
+// ParseSignal accepts the supported signal names with or without the SIG
+// prefix, in any case.
+func ParseSignal(name string) (syscall.Signal, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	sig, ok := signals[name]
+	if !ok {
+		return 0, fmt.Errorf("unsupported signal %q, expected SIGINT, SIGTERM or SIGKILL", name)
+	}
+	return sig, nil
+}
+
+func abortWithSignalError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errSignalDaemon), errors.Is(err, errSignalDaemonGroup):
+		c.AbortWithError(http.StatusForbidden, err)
+	case errors.Is(err, syscall.ESRCH):
+		c.AbortWithError(http.StatusNotFound, errors.New("no such process"))
+	case errors.Is(err, syscall.EPERM):
+		c.AbortWithError(http.StatusForbidden, err)
+	default:
+		c.AbortWithError(http.StatusInternalServerError, err)
+	}
+}
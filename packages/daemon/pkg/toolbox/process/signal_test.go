@@ -0,0 +1,55 @@
+package process
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+)
+
+func TestSignalTargetRefusesDaemon(t *testing.T) {
+	if _, err := signalTarget(os.Getpid(), false); !errors.Is(err, errSignalDaemon) {
+		t.Fatalf("expected errSignalDaemon, got %v", err)
+	}
+	if _, err := signalTarget(os.Getpid(), true); !errors.Is(err, errSignalDaemonGroup) {
+		t.Fatalf("expected errSignalDaemonGroup, got %v", err)
+	}
+
+	// a child that did not get its own group shares the daemon's
+	child := exec.Command("sleep", "10")
+	if err := child.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = child.Process.Kill()
+		_ = child.Wait()
+	})
+
+	if target, err := signalTarget(child.Process.Pid, false); err != nil || target != child.Process.Pid {
+		t.Fatalf("signalTarget(child) = %d, %v", target, err)
+	}
+	if _, err := signalTarget(child.Process.Pid, true); !errors.Is(err, errSignalDaemonGroup) {
+		t.Fatalf("expected errSignalDaemonGroup, got %v", err)
+	}
+}
+
+func TestSignalTargetGroup(t *testing.T) {
+	child := exec.Command("sleep", "10")
+	child.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := child.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = child.Process.Kill()
+		_ = child.Wait()
+	})
+
+	target, err := signalTarget(child.Process.Pid, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target != -child.Process.Pid {
+		t.Fatalf("expected the negated process group %d, got %d", -child.Process.Pid, target)
+	}
+}
@@ -1,15 +1,77 @@
 package process
 
+import (
+	"time"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/cgroup"
+)
+
 type ExecuteRequest struct {
-	Command string `json:"command" validate:"required"`
+	// Command line, split into arguments on whitespace unless shell is set.
+	// Either command or argv is required.
+	Command string `json:"command,omitempty" validate:"optional"`
+	// Run command through the user's shell, so pipes, redirects, globs and
+	// variable expansion work
+	Shell *bool `json:"shell,omitempty" validate:"optional"`
+	// Program and arguments, passed on without any parsing
+	Argv []string `json:"argv,omitempty" validate:"optional"`
+	// Environment variables set in addition to the daemon's environment
+	Envs map[string]string `json:"envs,omitempty" validate:"optional"`
+	// Content written to the standard input of the command
+	Stdin *string `json:"stdin,omitempty" validate:"optional"`
 	// Timeout in seconds, defaults to 10 seconds
 	Timeout *uint32 `json:"timeout,omitempty" validate:"optional"`
 	// Current working directory
 	Cwd *string `json:"cwd,omitempty" validate:"optional"`
+	// Resource limits of the command and its children, needs cgroup v2
+	Limits *cgroup.Limits `json:"limits,omitempty" validate:"optional"`
 } //	@name	ExecuteRequest
 
 // TODO: Set ExitCode as required once all sandboxes migrated to the new daemon
 type ExecuteResponse struct {
-	ExitCode int    `json:"exitCode"`
-	Result   string `json:"result" validate:"required"`
+	ExitCode int `json:"exitCode"`
+	// Standard output followed by standard error
+	Result string `json:"result" validate:"required"`
+	Stdout string `json:"stdout" validate:"optional"`
+	Stderr string `json:"stderr" validate:"optional"`
+	// Set when a process of the command was killed for exceeding the memory limit
+	OomKilled bool `json:"oomKilled,omitempty" validate:"optional"`
 } //	@name	ExecuteResponse
+
+type ExecuteStreamEvent struct {
+	// stdout, stderr, exit or error
+	Type string `json:"type" validate:"required"`
+	// Output chunk of stdout and stderr events, message of error events
+	Data string `json:"data,omitempty" validate:"optional"`
+	// Exit code of the exit event
+	ExitCode *int `json:"exitCode,omitempty" validate:"optional"`
+	// Set on the exit event when the command was killed after the timeout
+	TimedOut bool `json:"timedOut,omitempty" validate:"optional"`
+	// Set on the exit event when a process of the command was killed for
+	// exceeding the memory limit
+	OomKilled bool `json:"oomKilled,omitempty" validate:"optional"`
+} //	@name	ExecuteStreamEvent
+
+type ProcessInfo struct {
+	Pid  int `json:"pid" validate:"required"`
+	Ppid int `json:"ppid" validate:"required"`
+	// Process group ID
+	Pgid    int    `json:"pgid" validate:"required"`
+	User    string `json:"user" validate:"required"`
+	Name    string `json:"name" validate:"required"`
+	Cmdline string `json:"cmdline" validate:"required"`
+	// CPU usage in percent of one core, averaged over the lifetime of the process
+	CpuPercent float64 `json:"cpuPercent" validate:"required"`
+	// Resident set size in bytes
+	Rss       uint64    `json:"rss" validate:"required"`
+	StartTime time.Time `json:"startTime" validate:"required"`
+	// Child processes, only set when the tree is requested
+	Children []ProcessInfo `json:"children,omitempty" validate:"optional"`
+} //	@name	ProcessInfo
+
+type SignalRequest struct {
+	// SIGINT, SIGTERM or SIGKILL, the SIG prefix is optional
+	Signal string `json:"signal" validate:"required"`
+	// Signal the whole process group of the process
+	Group bool `json:"group,omitempty" validate:"optional"`
+} //	@name	SignalRequest
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/workspace"
+	"github.com/gin-gonic/gin"
+	cmap "github.com/orcaman/concurrent-map/v2"
+
+	"github.com/cofy-x/deck/packages/core-go/pkg/log"
+)
+
+var errServiceNotFound = errors.New("service not found")
+
+type ServiceController struct {
+	dir      string
+	services cmap.ConcurrentMap[string, *service]
+}
+
+// NewServiceController creates a controller that keeps the logs of the
+// services in configDir/services.
+func NewServiceController(configDir string) *ServiceController {
+	return &ServiceController{
+		dir:      filepath.Join(configDir, "services"),
+		services: cmap.New[*service](),
+	}
+}
+
+// CreateService godoc
+//
+//	@Summary		Create a service
+//	@Description	Start a long-running process, like a dev server or database, supervised by the daemon. Depending on its restart policy it is restarted when it exits, with a delay doubling from one second up to a minute while it keeps exiting. Its stdout and stderr go to a log that can be tailed, rotated at 10 MiB keeping one previous log.
+//	@Tags			service
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		CreateServiceRequest	true	"Service"
+//	@Success		201		{object}	Service
+//	@Router			/services [post]
+//
+//	@id				CreateService
+func (sc *ServiceController) CreateService(c *gin.Context) {
+	var request CreateServiceRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	if err := request.validate(); err != nil {
+		if errors.Is(err, workspace.ErrOutsideRoots) {
+			c.AbortWithError(http.StatusForbidden, err)
+			return
+		}
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	svc := &service{
+		config:  request,
+		logPath: filepath.Join(sc.dir, request.Name, "output.log"),
+		status:  ServiceStatusStopped,
+	}
+	if !sc.services.SetIfAbsent(request.Name, svc) {
+		c.AbortWithError(http.StatusConflict, errors.New("service already exists"))
+		return
+	}
+
+	// a new service starts with an empty log
+	dir := filepath.Dir(svc.logPath)
+	err := os.RemoveAll(dir)
+	if err == nil {
+		err = os.MkdirAll(dir, 0755)
+	}
+	if err != nil {
+		sc.services.Remove(request.Name)
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	svc.start()
+	log.Infof("Created service %s", request.Name)
+
+	c.JSON(http.StatusCreated, svc.info())
+}
+
+// ListServices godoc
+//
+//	@Summary		List services
+//	@Description	Get all services, sorted by name
+//	@Tags			service
+//	@Produce		json
+//	@Success		200	{array}	Service
+//	@Router			/services [get]
+//
+//	@id				ListServices
+func (sc *ServiceController) ListServices(c *gin.Context) {
+	services := []Service{}
+	for _, svc := range sc.services.Items() {
+		services = append(services, svc.info())
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+
+	c.JSON(http.StatusOK, services)
+}
+
+// GetService godoc
+//
+//	@Summary		Get a service
+//	@Description	Get the configuration and status of a service
+//	@Tags			service
+//	@Produce		json
+//	@Param			name	path		string	true	"Service name"
+//	@Success		200		{object}	Service
+//	@Router			/services/{name} [get]
+//
+//	@id				GetService
+func (sc *ServiceController) GetService(c *gin.Context) {
+	svc, ok := sc.services.Get(c.Param("name"))
+	if !ok {
+		c.AbortWithError(http.StatusNotFound, errServiceNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, svc.info())
+}
+
+// DeleteService godoc
+//
+//	@Summary		Delete a service
+//	@Description	Stop a service and delete it with its logs
+//	@Tags			service
+//	@Param			name	path	string	true	"Service name"
+//	@Success		204
+//	@Router			/services/{name} [delete]
+//
+//	@id				DeleteService
+func (sc *ServiceController) DeleteService(c *gin.Context) {
+	svc, ok := sc.services.Pop(c.Param("name"))
+	if !ok {
+		c.AbortWithError(http.StatusNotFound, errServiceNotFound)
+		return
+	}
+
+	_ = svc.halt()
+	if err := os.RemoveAll(filepath.Dir(svc.logPath)); err != nil {
+		log.Warnf("Failed to remove the logs of service %s: %v", svc.config.Name, err)
+	}
+	log.Infof("Deleted service %s", svc.config.Name)
+
+	c.Status(http.StatusNoContent)
+}
+
+// StartService godoc
+//
+//	@Summary		Start a service
+//	@Description	Start a service that was stopped or is no longer restarted
+//	@Tags			service
+//	@Produce		json
+//	@Param			name	path		string	true	"Service name"
+//	@Success		200		{object}	Service
+//	@Router			/services/{name}/start [post]
+//
+//	@id				StartService
+func (sc *ServiceController) StartService(c *gin.Context) {
+	svc, ok := sc.services.Get(c.Param("name"))
+	if !ok {
+		c.AbortWithError(http.StatusNotFound, errServiceNotFound)
+		return
+	}
+
+	if !svc.start() {
+		c.AbortWithError(http.StatusConflict, errors.New("service is already running"))
+		return
+	}
+
+	c.JSON(http.StatusOK, svc.info())
+}
+
+// StopService godoc
+//
+//	@Summary		Stop a service
+//	@Description	Stop a service with SIGTERM, and SIGKILL when it is still running after 10 seconds. It is not restarted until started again.
+//	@Tags			service
+//	@Produce		json
+//	@Param			name	path		string	true	"Service name"
+//	@Success		200		{object}	Service
+//	@Router			/services/{name}/stop [post]
+//
+//	@id				StopService
+func (sc *ServiceController) StopService(c *gin.Context) {
+	svc, ok := sc.services.Get(c.Param("name"))
+	if !ok {
+		c.AbortWithError(http.StatusNotFound, errServiceNotFound)
+		return
+	}
+
+	if err := svc.halt(); err != nil {
+		c.AbortWithError(http.StatusConflict, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, svc.info())
+}
+
+// RestartService godoc
+//
+//	@Summary		Restart a service
+//	@Description	Stop a service if it is running and start it again
+//	@Tags			service
+//	@Produce		json
+//	@Param			name	path		string	true	"Service name"
+//	@Success		200		{object}	Service
+//	@Router			/services/{name}/restart [post]
+//
+//	@id				RestartService
+func (sc *ServiceController) RestartService(c *gin.Context) {
+	svc, ok := sc.services.Get(c.Param("name"))
+	if !ok {
+		c.AbortWithError(http.StatusNotFound, errServiceNotFound)
+		return
+	}
+
+	_ = svc.halt()
+	if !svc.start() {
+		// restarted concurrently
+		c.AbortWithError(http.StatusConflict, errors.New("service is already running"))
+		return
+	}
+
+	c.JSON(http.StatusOK, svc.info())
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultTailLines = 100
+	followInterval   = 200 * time.Millisecond
+)
+
+// GetServiceLogs godoc
+//
+//	@Summary		Tail the logs of a service
+//	@Description	Get the last lines of the combined stdout and stderr of a service. With follow the response stays open and streams new output until the client disconnects or the service is deleted.
+//	@Tags			service
+//	@Produce		text/plain
+//	@Param			name	path		string	true	"Service name"
+//	@Param			lines	query		integer	false	"Number of lines from the end, 0 for the whole log, defaults to 100"
+//	@Param			follow	query		boolean	false	"Stream new output as it is written"
+//	@Success		200		{string}	string	"Log content"
+//	@Router			/services/{name}/logs [get]
+//
+//	@id				GetServiceLogs
+func (sc *ServiceController) GetServiceLogs(c *gin.Context) {
+	svc, ok := sc.services.Get(c.Param("name"))
+	if !ok {
+		c.AbortWithError(http.StatusNotFound, errServiceNotFound)
+		return
+	}
+
+	lines := defaultTailLines
+	if value := c.Query("lines"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			c.AbortWithError(http.StatusBadRequest, errors.New("invalid lines"))
+			return
+		}
+		lines = n
+	}
+	follow := c.Query("follow") == "true"
+
+	logFile, err := os.Open(svc.logPath)
+	if err != nil && !os.IsNotExist(err) {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if logFile != nil {
+		defer func() { logFile.Close() }()
+		offset, err := tailOffset(logFile, lines)
+		if err == nil {
+			_, err = logFile.Seek(offset, io.SeekStart)
+		}
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	if follow {
+		c.Header("Cache-Control", "no-cache")
+		// keep reverse proxies from buffering the stream
+		c.Header("X-Accel-Buffering", "no")
+	}
+	c.Status(http.StatusOK)
+
+	if logFile != nil {
+		if _, err := io.Copy(c.Writer, logFile); err != nil {
+			return
+		}
+	}
+	if !follow {
+		return
+	}
+	c.Writer.Flush()
+
+	ctx := c.Request.Context()
+	ticker := time.NewTicker(followInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if current, ok := sc.services.Get(svc.config.Name); !ok || current != svc {
+			return
+		}
+
+		// the log is replaced when it is rotated, and only created by the
+		// first run
+		if logFile == nil || rotated(logFile, svc.logPath) {
+			next, err := os.Open(svc.logPath)
+			if err != nil {
+				continue
+			}
+			if logFile != nil {
+				// the rest of the rotated log
+				_, _ = io.Copy(c.Writer, logFile)
+				logFile.Close()
+			}
+			logFile = next
+		}
+
+		n, err := io.Copy(c.Writer, logFile)
+		if err != nil {
+			return
+		}
+		if n > 0 {
+			c.Writer.Flush()
+		}
+	}
+}
+
+// tailOffset returns the offset of the last n lines of f, all of it when n
+// is 0. A final line without newline counts as a line.
+func tailOffset(f *os.File, n int) (int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+	if n == 0 || size == 0 {
+		return 0, nil
+	}
+
+	buf := make([]byte, 64<<10)
+	// the newline ending the last line does not start another one
+	newlines := 0
+	if _, err := f.ReadAt(buf[:1], size-1); err != nil {
+		return 0, err
+	}
+	if buf[0] == '\n' {
+		newlines = -1
+	}
+
+	end := size
+	for end > 0 {
+		start := max(end-int64(len(buf)), 0)
+		chunk := buf[:end-start]
+		if _, err := f.ReadAt(chunk, start); err != nil {
+			return 0, err
+		}
+		for i := len(chunk) - 1; i >= 0; i-- {
+			if chunk[i] != '\n' {
+				continue
+			}
+			newlines++
+			if newlines == n {
+				return start + int64(i) + 1, nil
+			}
+		}
+		end = start
+	}
+	return 0, nil
+}
+
+// rotated reports whether path no longer is the file f was opened from.
+func rotated(f *os.File, path string) bool {
+	opened, err := f.Stat()
+	if err != nil {
+		return true
+	}
+	current, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return !os.SameFile(opened, current)
+}
@@ -0,0 +1,438 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/toolbox/process"
+	"github.com/cofy-x/deck/packages/core-go/pkg/log"
+)
+
+const (
+	// restarts are delayed by minBackoff, doubled after every restart in a
+	// row up to maxBackoff
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+	// a run lasting stableRun resets the backoff and the restart count
+	stableRun = time.Minute
+
+	// stopping sends SIGTERM, and SIGKILL after stopTimeout
+	stopTimeout = 10 * time.Second
+
+	probeInterval = time.Second
+	probeTimeout  = 2 * time.Second
+
+	// the log is rotated once it has grown larger, keeping one previous log
+	maxLogSize = 10 << 20
+	// output of processes left behind by a run is logged until outputDelay
+	// after it exited
+	outputDelay = time.Second
+)
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+var errNotRunning = errors.New("service is not running")
+
+type service struct {
+	config  CreateServiceRequest
+	logPath string
+
+	mu        sync.Mutex
+	status    ServiceStatus
+	ready     bool
+	pid       *int
+	restarts  int
+	exitCode  *int
+	startedAt *time.Time
+	exitedAt  *time.Time
+	// closed to stop the supervisor, nil while it is not running
+	stop chan struct{}
+	// closed once the supervisor has returned
+	done chan struct{}
+}
+
+// validate checks the request and defaults its restart policy. The command
+// is prepared once, so services that can't run are rejected right away.
+func (r *CreateServiceRequest) validate() error {
+	if !namePattern.MatchString(r.Name) {
+		return fmt.Errorf("invalid service name %q", r.Name)
+	}
+	switch r.Restart {
+	case "":
+		r.Restart = RestartOnFailure
+	case RestartAlways, RestartOnFailure, RestartNever:
+	default:
+		return fmt.Errorf("invalid restart policy %q, expected always, on-failure or never", r.Restart)
+	}
+	if r.MaxRestarts != nil && *r.MaxRestarts < 0 {
+		return errors.New("maxRestarts must not be negative")
+	}
+	if r.Readiness != nil {
+		if r.Readiness.Port < 1 || r.Readiness.Port > 65535 {
+			return fmt.Errorf("invalid readiness port %d", r.Readiness.Port)
+		}
+		if r.Readiness.Path != nil && (len(*r.Readiness.Path) == 0 || (*r.Readiness.Path)[0] != '/') {
+			return errors.New("readiness path must start with /")
+		}
+	}
+	_, err := r.command()
+	return err
+}
+
+// command prepares the command of a run of the service.
+func (r *CreateServiceRequest) command() (*exec.Cmd, error) {
+	request := process.ExecuteRequest{
+		Command: r.Command,
+		Argv:    r.Argv,
+		Envs:    r.Envs,
+		Cwd:     r.Cwd,
+		Limits:  r.Limits,
+	}
+	if r.Command != "" {
+		shell := true
+		request.Shell = &shell
+	}
+	return process.NewCommand(request)
+}
+
+func (s *service) info() Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Service{
+		CreateServiceRequest: s.config,
+		Status:               s.status,
+		Ready:                s.ready,
+		Pid:                  s.pid,
+		Restarts:             s.restarts,
+		ExitCode:             s.exitCode,
+		StartedAt:            s.startedAt,
+		ExitedAt:             s.exitedAt,
+	}
+}
+
+// start starts the supervisor of the service, unless it is running. A
+// supervisor still being halted is waited for, so only one ever runs.
+func (s *service) start() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		if s.stop != nil {
+			return false
+		}
+		if s.done == nil || isClosed(s.done) {
+			break
+		}
+		done := s.done
+		s.mu.Unlock()
+		<-done
+		s.mu.Lock()
+	}
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	s.status = ServiceStatusStarting
+	s.restarts = 0
+	go s.supervise(s.stop, s.done)
+	return true
+}
+
+// halt stops the supervisor and waits until the service has exited.
+func (s *service) halt() error {
+	s.mu.Lock()
+	stop, done := s.stop, s.done
+	s.stop = nil
+	s.mu.Unlock()
+	if stop == nil {
+		return errNotRunning
+	}
+	close(stop)
+	<-done
+	return nil
+}
+
+// supervise runs the service and restarts it according to its policy until
+// stop is closed.
+func (s *service) supervise(stop, done chan struct{}) {
+	defer close(done)
+
+	delay := minBackoff
+	failures := 0
+	for {
+		startedAt := time.Now()
+		exitCode, err := s.run(stop)
+		if err != nil {
+			log.Warnf("Failed to run service %s: %v", s.config.Name, err)
+		}
+
+		select {
+		case <-stop:
+			s.setStatus(ServiceStatusStopped)
+			return
+		default:
+		}
+
+		if time.Since(startedAt) >= stableRun {
+			delay = minBackoff
+			failures = 0
+		}
+
+		failed := err != nil || exitCode != 0
+		restart := s.config.Restart == RestartAlways || (s.config.Restart == RestartOnFailure && failed)
+		if restart && s.config.MaxRestarts != nil && failures >= *s.config.MaxRestarts {
+			log.Warnf("Service %s exited %d times in a row, giving up", s.config.Name, failures+1)
+			restart = false
+		}
+		if !restart {
+			s.mu.Lock()
+			s.status = ServiceStatusExited
+			if failed {
+				s.status = ServiceStatusFailed
+			}
+			// the service can be started again right away
+			if s.stop == stop {
+				s.stop = nil
+			}
+			s.mu.Unlock()
+			return
+		}
+
+		failures++
+		s.mu.Lock()
+		s.status = ServiceStatusBackoff
+		s.restarts++
+		s.mu.Unlock()
+		log.Infof("Restarting service %s in %v", s.config.Name, delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-stop:
+			timer.Stop()
+			s.setStatus(ServiceStatusStopped)
+			return
+		case <-timer.C:
+		}
+		delay = min(delay*2, maxBackoff)
+	}
+}
+
+// run runs the service once, until it exits or stop is closed.
+func (s *service) run(stop chan struct{}) (int, error) {
+	cmd, err := s.config.command()
+	if err != nil {
+		return -1, err
+	}
+
+	logFile, err := openLog(s.logPath)
+	if err != nil {
+		return -1, err
+	}
+	defer logFile.Close()
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.WaitDelay = outputDelay
+
+	group, err := process.StartCommand(cmd, s.config.Limits)
+	if err != nil {
+		fmt.Fprintf(logFile, "failed to start service: %v\n", err)
+		return -1, err
+	}
+	defer group.Remove()
+
+	pid := cmd.Process.Pid
+	// Register this PID to prevent zombie reaper from racing with cmd.Wait()
+	registry := process.GetRegistry()
+	registry.Register(pid)
+	defer registry.Unregister(pid)
+
+	now := time.Now()
+	s.mu.Lock()
+	s.pid = &pid
+	s.startedAt = &now
+	s.ready = s.config.Readiness == nil
+	s.status = ServiceStatusStarting
+	if s.ready {
+		s.status = ServiceStatusRunning
+	}
+	s.mu.Unlock()
+	log.Debugf("Started service %s PID=%d", s.config.Name, pid)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if s.config.Readiness != nil {
+		go s.probe(ctx, *s.config.Readiness)
+	}
+
+	waitErr := make(chan error, 1)
+	go func() {
+		waitErr <- cmd.Wait()
+	}()
+
+	select {
+	case err = <-waitErr:
+	case <-stop:
+		err = terminate(cmd, waitErr)
+	}
+	cancel()
+	if errors.Is(err, exec.ErrWaitDelay) {
+		err = nil
+	}
+	exitCode := process.CommandExitCode(cmd, err)
+
+	exitedAt := time.Now()
+	s.mu.Lock()
+	s.pid = nil
+	s.ready = false
+	s.exitCode = &exitCode
+	s.exitedAt = &exitedAt
+	s.mu.Unlock()
+	log.Debugf("Service %s PID=%d exited with code %d", s.config.Name, pid, exitCode)
+
+	return exitCode, nil
+}
+
+// terminate stops the process group of cmd and returns the result of its
+// Wait.
+func terminate(cmd *exec.Cmd, waitErr chan error) error {
+	pgid := cmd.Process.Pid
+	_ = syscall.Kill(-pgid, syscall.SIGTERM)
+
+	timer := time.NewTimer(stopTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-waitErr:
+		return err
+	case <-timer.C:
+		_ = syscall.Kill(-pgid, syscall.SIGKILL)
+		return <-waitErr
+	}
+}
+
+// probe checks whether the service is ready until ctx is done.
+func (s *service) probe(ctx context.Context, probe ReadinessProbe) {
+	address := net.JoinHostPort("localhost", strconv.Itoa(probe.Port))
+	client := &http.Client{Timeout: probeTimeout}
+
+	ticker := time.NewTicker(probeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		var ready bool
+		if probe.Path == nil {
+			conn, err := net.DialTimeout("tcp", address, probeTimeout)
+			if err == nil {
+				conn.Close()
+				ready = true
+			}
+		} else {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+address+*probe.Path, nil)
+			if err == nil {
+				resp, err := client.Do(req)
+				if err == nil {
+					resp.Body.Close()
+					ready = resp.StatusCode < http.StatusBadRequest
+				}
+			}
+		}
+
+		s.mu.Lock()
+		// the run may have ended while probing
+		if ctx.Err() == nil {
+			s.ready = ready
+			s.status = ServiceStatusStarting
+			if ready {
+				s.status = ServiceStatusRunning
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *service) setStatus(status ServiceStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+// logWriter appends to the log of a service and rotates it while the
+// service runs. The output of a run is copied by a single goroutine, since
+// stdout and stderr share the writer.
+type logWriter struct {
+	path string
+	file *os.File
+	size int64
+}
+
+func openLog(path string) (*logWriter, error) {
+	rotateLog(path)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &logWriter{path: path, file: file, size: info.Size()}, nil
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	if w.size >= maxLogSize {
+		w.rotate()
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// rotate moves the log aside and continues in a new one. If the new log
+// can't be created, writing continues in the old one.
+func (w *logWriter) rotate() {
+	w.size = 0
+	rotateLog(w.path)
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		log.Warnf("Failed to rotate %s: %v", filepath.Base(w.path), err)
+		return
+	}
+	w.file.Close()
+	w.file = file
+}
+
+func (w *logWriter) Close() error {
+	return w.file.Close()
+}
+
+// rotateLog keeps one previous log once the log has grown too large.
+func rotateLog(path string) {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() < maxLogSize {
+		return
+	}
+	if err := os.Rename(path, path+".1"); err != nil {
+		log.Warnf("Failed to rotate %s: %v", filepath.Base(path), err)
+	}
+}
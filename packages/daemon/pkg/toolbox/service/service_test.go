@@ -0,0 +1,98 @@
+package service
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLogWriterRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output.log")
+	w, err := openLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	chunk := bytes.Repeat([]byte("x"), 1<<20)
+	for range maxLogSize/len(chunk) + 1 {
+		if _, err := w.Write(chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	previous, err := os.Stat(path + ".1")
+	if err != nil || previous.Size() != maxLogSize {
+		t.Fatalf("expected the previous log to hold %d bytes, got %v (%v)", maxLogSize, previous, err)
+	}
+	if current, err := os.Stat(path); err != nil || current.Size() != int64(len(chunk)) {
+		t.Fatalf("expected the log to hold the last write, got %v (%v)", current, err)
+	}
+}
+
+func TestTailOffset(t *testing.T) {
+	tests := []struct {
+		content string
+		lines   int
+		tail    string
+	}{
+		{"a\nb\nc\n", 2, "b\nc\n"},
+		{"a\nb\nc", 2, "b\nc"},
+		{"a\nb\nc\n", 5, "a\nb\nc\n"},
+		{"a\nb\nc\n", 0, "a\nb\nc\n"},
+		{"a\n\n\n", 1, "\n"},
+		{"", 3, ""},
+	}
+
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), "output.log")
+		if err := os.WriteFile(path, []byte(test.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		offset, err := tailOffset(f, test.lines)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tail := test.content[offset:]; tail != test.tail {
+			t.Errorf("last %d lines of %q: expected %q, got %q", test.lines, test.content, test.tail, tail)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		request CreateServiceRequest
+		valid   bool
+	}{
+		{"command", CreateServiceRequest{Name: "web", Command: "npm run dev"}, true},
+		{"argv", CreateServiceRequest{Name: "db.1", Argv: []string{"sleep", "1"}, Restart: RestartAlways}, true},
+		{"no command", CreateServiceRequest{Name: "web"}, false},
+		{"bad name", CreateServiceRequest{Name: "../web", Command: "true"}, false},
+		{"bad policy", CreateServiceRequest{Name: "web", Command: "true", Restart: "sometimes"}, false},
+		{"negative restarts", CreateServiceRequest{Name: "web", Command: "true", MaxRestarts: &[]int{-1}[0]}, false},
+		{"bad port", CreateServiceRequest{Name: "web", Command: "true", Readiness: &ReadinessProbe{Port: 0}}, false},
+		{"bad path", CreateServiceRequest{Name: "web", Command: "true", Readiness: &ReadinessProbe{Port: 80, Path: &[]string{"health"}[0]}}, false},
+	}
+
+	for _, test := range tests {
+		err := test.request.validate()
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+
+	request := CreateServiceRequest{Name: "web", Command: "true"}
+	if err := request.validate(); err != nil || request.Restart != RestartOnFailure {
+		t.Errorf("expected the on-failure policy by default, got %q (%v)", request.Restart, err)
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"time"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/cgroup"
+)
+
+type RestartPolicy string
+
+const (
+	RestartAlways    RestartPolicy = "always"
+	RestartOnFailure RestartPolicy = "on-failure"
+	RestartNever     RestartPolicy = "never"
+)
+
+type ServiceStatus string
+
+const (
+	// Running, but the readiness probe has not succeeded yet
+	ServiceStatusStarting ServiceStatus = "starting"
+	ServiceStatusRunning  ServiceStatus = "running"
+	// Waiting to be restarted after it exited
+	ServiceStatusBackoff ServiceStatus = "backoff"
+	ServiceStatusStopped ServiceStatus = "stopped"
+	// Exited with code 0 and not restarted
+	ServiceStatusExited ServiceStatus = "exited"
+	// Failed to start, or exited with an error and not restarted
+	ServiceStatusFailed ServiceStatus = "failed"
+)
+
+type ReadinessProbe struct {
+	// Port on localhost the service listens on once it is ready
+	Port int `json:"port" validate:"required"`
+	// HTTP path requested on the port, any status below 400 means ready.
+	// Without a path accepting TCP connections is enough
+	Path *string `json:"path,omitempty" validate:"optional"`
+} //	@name	ReadinessProbe
+
+type CreateServiceRequest struct {
+	// Letters, digits, dots, dashes and underscores
+	Name string `json:"name" validate:"required"`
+	// Command line run through the user's shell. Either command or argv is
+	// required
+	Command string `json:"command,omitempty" validate:"optional"`
+	// Program and arguments, passed on without any parsing
+	Argv []string `json:"argv,omitempty" validate:"optional"`
+	// Environment variables set in addition to the daemon's environment
+	Envs map[string]string `json:"envs,omitempty" validate:"optional"`
+	// Current working directory
+	Cwd *string `json:"cwd,omitempty" validate:"optional"`
+	// always, on-failure or never, defaults to on-failure
+	Restart RestartPolicy `json:"restart,omitempty" validate:"optional"`
+	// Restarts in a row before giving up, unlimited when unset. The count
+	// resets once the service stays up for a minute
+	MaxRestarts *int            `json:"maxRestarts,omitempty" validate:"optional"`
+	Readiness   *ReadinessProbe `json:"readiness,omitempty" validate:"optional"`
+	// Resource limits of each run of the service, needs cgroup v2
+	Limits *cgroup.Limits `json:"limits,omitempty" validate:"optional"`
+} //	@name	CreateServiceRequest
+
+type Service struct {
+	CreateServiceRequest
+	Status ServiceStatus `json:"status" validate:"required"`
+	// Whether the readiness probe succeeded, always set for a running
+	// service without probe
+	Ready bool `json:"ready" validate:"required"`
+	Pid   *int `json:"pid,omitempty" validate:"optional"`
+	// Restarts since the service was created or last started
+	Restarts int `json:"restarts" validate:"required"`
+	// Exit code of the last run
+	ExitCode *int `json:"exitCode,omitempty" validate:"optional"`
+	// When the current or last run started
+	StartedAt *time.Time `json:"startedAt,omitempty" validate:"optional"`
+	// When the last run exited
+	ExitedAt *time.Time `json:"exitedAt,omitempty" validate:"optional"`
+} //	@name	Service
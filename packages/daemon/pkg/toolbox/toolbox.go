@@ -11,13 +11,16 @@ import (
 	"net/http"
 	"os"
 	"path"
+	"time"
 
 	"github.com/cofy-x/deck/apps/daemon/internal"
+	"github.com/cofy-x/deck/apps/daemon/pkg/toolbox/checkpoint"
 	"github.com/cofy-x/deck/apps/daemon/pkg/toolbox/computeruse"
 	"github.com/cofy-x/deck/apps/daemon/pkg/toolbox/computeruse/manager"
 	"github.com/cofy-x/deck/apps/daemon/pkg/toolbox/config"
 	"github.com/cofy-x/deck/apps/daemon/pkg/toolbox/fs"
 	"github.com/cofy-x/deck/apps/daemon/pkg/toolbox/git"
+	"github.com/cofy-x/deck/apps/daemon/pkg/toolbox/job"
 	"github.com/cofy-x/deck/apps/daemon/pkg/toolbox/lsp"
 	"github.com/cofy-x/deck/apps/daemon/pkg/toolbox/middlewares"
 	"github.com/cofy-x/deck/apps/daemon/pkg/toolbox/port"
@@ -26,6 +29,7 @@ import (
 	"github.com/cofy-x/deck/apps/daemon/pkg/toolbox/process/pty"
 	"github.com/cofy-x/deck/apps/daemon/pkg/toolbox/process/session"
 	"github.com/cofy-x/deck/apps/daemon/pkg/toolbox/proxy"
+	"github.com/cofy-x/deck/apps/daemon/pkg/toolbox/service"
 	"github.com/cofy-x/deck/packages/computer-use/api"
 	common_errors "github.com/cofy-x/deck/packages/core-go/pkg/errors"
 	common_proxy "github.com/cofy-x/deck/packages/core-go/pkg/proxy"
@@ -42,6 +46,15 @@ import (
 type Server struct {
 	WorkDir     string
 	ComputerUse api.IComputerUse
+	// Retention limits of the trash used by soft deletes
+	TrashRetention time.Duration
+	TrashMaxItems  int
+	// Process sessions idle for longer or older than these are removed, zero
+	// keeps them
+	SessionIdleTimeout time.Duration
+	SessionMaxLifetime time.Duration
+	// Jobs running at the same time, zero means one per CPU
+	JobsMaxConcurrency int
 }
 
 type WorkDirResponse struct {
@@ -151,23 +164,54 @@ func (s *Server) Start() error {
 
 	log.Println("configDir", configDir)
 
+	fs.SetTrash(path.Join(configDir, "trash"), s.TrashRetention, s.TrashMaxItems)
+	fs.SetUploadSessions(path.Join(configDir, "uploads"))
+
 	fsController := r.Group("/files")
 	{
 		// read operations
 		fsController.GET("/", fs.ListFiles)
+		fsController.GET("/archive", fs.ArchiveFiles)
 		fsController.GET("/download", fs.DownloadFile)
 		fsController.POST("/bulk-download", fs.DownloadFiles)
 		fsController.GET("/find", fs.FindInFiles)
 		fsController.GET("/info", fs.GetFileInfo)
+		fsController.GET("/lines", fs.GetFileLines)
+		fsController.GET("/manifest", fs.GetManifest)
 		fsController.GET("/search", fs.SearchFiles)
+		fsController.GET("/search/fuzzy", fs.FuzzySearchFiles)
+		fsController.GET("/tree", fs.GetFileTree)
+		fsController.GET("/watch", fs.WatchFiles)
 
 		// create/modify operations
+		fsController.POST("/copy", fs.CopyFile)
 		fsController.POST("/folder", fs.CreateFolder)
+		fsController.POST("/link", fs.CreateLink)
+		fsController.POST("/lines", fs.EditLines)
 		fsController.POST("/move", fs.MoveFile)
+		fsController.POST("/patch", fs.ApplyPatch)
 		fsController.POST("/permissions", fs.SetFilePermissions)
 		fsController.POST("/replace", fs.ReplaceInFiles)
 		fsController.POST("/upload", fs.UploadFile)
 		fsController.POST("/bulk-upload", fs.UploadFiles)
+		fsController.POST("/extract", fs.ExtractArchive)
+
+		// manifest based sync
+		fsController.POST("/sync/missing", fs.GetMissingBlobs)
+		fsController.POST("/sync", fs.SyncFiles)
+
+		// resumable uploads
+		fsController.POST("/uploads", fs.CreateUploadSession)
+		fsController.GET("/uploads/:uploadId", fs.GetUploadSession)
+		fsController.PUT("/uploads/:uploadId", fs.UploadChunk)
+		fsController.POST("/uploads/:uploadId/complete", fs.CompleteUploadSession)
+		fsController.DELETE("/uploads/:uploadId", fs.DeleteUploadSession)
+
+		// trash
+		fsController.GET("/trash", fs.ListTrash)
+		fsController.POST("/trash/:itemId/restore", fs.RestoreTrashItem)
+		fsController.DELETE("/trash/:itemId", fs.PurgeTrashItem)
+		fsController.DELETE("/trash", fs.PurgeTrash)
 
 		// delete operations
 		fsController.DELETE("/", fs.DeleteFile)
@@ -176,8 +220,12 @@ func (s *Server) Start() error {
 	processController := r.Group("/process")
 	{
 		processController.POST("/execute", process.ExecuteCommand)
+		processController.POST("/execute/stream", process.ExecuteCommandStream)
+		processController.GET("/execute/ws", process.ExecuteCommandWebSocket)
+		processController.GET("/list", process.ListProcesses)
+		processController.POST("/:pid/signal", process.SignalProcess)
 
-		sessionController := session.NewSessionController(configDir, s.WorkDir)
+		sessionController := session.NewSessionController(configDir, s.WorkDir, s.SessionIdleTimeout, s.SessionMaxLifetime)
 		sessionGroup := processController.Group("/session")
 		{
 			sessionGroup.GET("", sessionController.ListSessions)
@@ -187,6 +235,8 @@ func (s *Server) Start() error {
 			sessionGroup.DELETE("/:sessionId", sessionController.DeleteSession)
 			sessionGroup.GET("/:sessionId/command/:commandId", sessionController.GetSessionCommand)
 			sessionGroup.GET("/:sessionId/command/:commandId/logs", sessionController.GetSessionCommandLogs)
+			sessionGroup.POST("/:sessionId/command/:commandId/signal", sessionController.SignalSessionCommand)
+			sessionGroup.POST("/:sessionId/command/:commandId/input", sessionController.SendSessionCommandInput)
 		}
 
 		// PTY endpoints
@@ -212,6 +262,41 @@ func (s *Server) Start() error {
 		}
 	}
 
+	serviceController := service.NewServiceController(configDir)
+	serviceGroup := r.Group("/services")
+	{
+		serviceGroup.GET("", serviceController.ListServices)
+		serviceGroup.POST("", serviceController.CreateService)
+		serviceGroup.GET("/:name", serviceController.GetService)
+		serviceGroup.DELETE("/:name", serviceController.DeleteService)
+		serviceGroup.GET("/:name/logs", serviceController.GetServiceLogs)
+		serviceGroup.POST("/:name/start", serviceController.StartService)
+		serviceGroup.POST("/:name/stop", serviceController.StopService)
+		serviceGroup.POST("/:name/restart", serviceController.RestartService)
+	}
+
+	jobController := job.NewJobController(configDir, s.JobsMaxConcurrency)
+	jobGroup := r.Group("/jobs")
+	{
+		jobGroup.GET("", jobController.ListJobs)
+		jobGroup.POST("", jobController.SubmitJob)
+		jobGroup.GET("/:jobId", jobController.GetJob)
+		jobGroup.DELETE("/:jobId", jobController.DeleteJob)
+		jobGroup.GET("/:jobId/output", jobController.GetJobOutput)
+		jobGroup.POST("/:jobId/cancel", jobController.CancelJob)
+	}
+
+	checkpointController := checkpoint.NewCheckpointController(configDir, s.WorkDir)
+	checkpointGroup := r.Group("/checkpoints")
+	{
+		checkpointGroup.GET("", checkpointController.ListCheckpoints)
+		checkpointGroup.POST("", checkpointController.CreateCheckpoint)
+		checkpointGroup.GET("/:checkpointId", checkpointController.GetCheckpoint)
+		checkpointGroup.DELETE("/:checkpointId", checkpointController.DeleteCheckpoint)
+		checkpointGroup.GET("/:checkpointId/diff", checkpointController.DiffCheckpoint)
+		checkpointGroup.POST("/:checkpointId/restore", checkpointController.RestoreCheckpoint)
+	}
+
 	gitController := r.Group("/git")
 	{
 		gitController.GET("/branches", git.ListBranches)
@@ -0,0 +1,167 @@
+// Package workspace confines the paths accepted by the daemon APIs to a set
+// of allowed root directories. Confinement is disabled until roots are set.
+//
+// Paths are checked after resolving symlinks, so a link inside a root that
+// points elsewhere is treated as being outside. The check only applies to
+// paths handed to the APIs; it does not restrict what spawned processes can
+// access.
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/cofy-x/deck/apps/daemon/pkg/fsutil"
+)
+
+// ErrOutsideRoots is returned for paths that resolve outside of all
+// workspace roots.
+var ErrOutsideRoots = errors.New("path is outside the workspace roots")
+
+var (
+	mu    sync.RWMutex
+	roots []string
+)
+
+// SetRoots sets the allowed roots. Each root must be an existing directory.
+// An empty list disables confinement.
+func SetRoots(paths []string) error {
+	resolved := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if strings.TrimSpace(path) == "" {
+			continue
+		}
+
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		root, err := filepath.EvalSymlinks(abs)
+		if err != nil {
+			return fmt.Errorf("invalid workspace root %s: %w", path, err)
+		}
+		info, err := os.Stat(root)
+		if err != nil {
+			return fmt.Errorf("invalid workspace root %s: %w", path, err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("invalid workspace root %s: not a directory", path)
+		}
+		resolved = append(resolved, root)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	roots = resolved
+	return nil
+}
+
+// Roots returns the allowed roots, or nil when confinement is disabled.
+func Roots() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return roots
+}
+
+// Enabled reports whether paths are confined to the workspace roots.
+func Enabled() bool {
+	return len(Roots()) > 0
+}
+
+// DefaultDir returns the first workspace root, or an empty string when
+// confinement is disabled.
+func DefaultDir() string {
+	if r := Roots(); len(r) > 0 {
+		return r[0]
+	}
+	return ""
+}
+
+// Check verifies that path, with all symlinks followed, lies inside one of
+// the workspace roots. Path components that do not exist yet are allowed,
+// so paths that are about to be created can be checked as well.
+//
+// When confinement is enabled it returns the clean absolute form of path,
+// which callers must use instead of the original so that ".." components
+// cannot be resolved differently through a symlink. A relative path is
+// taken relative to DefaultDir rather than the working directory of the
+// daemon. When disabled it returns path unchanged.
+func Check(path string) (string, error) {
+	return check(path, true)
+}
+
+// CheckNoFollow is like Check but does not follow a symlink in the final
+// path component. It is meant for operations on the link itself, such as
+// removing or renaming it.
+func CheckNoFollow(path string) (string, error) {
+	return check(path, false)
+}
+
+func check(path string, follow bool) (string, error) {
+	allowed := Roots()
+	if len(allowed) == 0 {
+		return path, nil
+	}
+
+	// relative paths start in the default directory, like commands do
+	abs := filepath.Clean(path)
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(allowed[0], abs)
+	}
+
+	var resolved string
+	var err error
+	if follow {
+		resolved, err = resolve(abs)
+	} else {
+		// the root of the filesystem has no parent to resolve
+		if parent := filepath.Dir(abs); parent != abs {
+			resolved, err = resolve(parent)
+			resolved = filepath.Join(resolved, filepath.Base(abs))
+		} else {
+			resolved = abs
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	for _, root := range allowed {
+		if fsutil.Within(root, resolved) {
+			return abs, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrOutsideRoots, path)
+}
+
+// resolve evaluates the symlinks of an absolute, clean path. Trailing
+// components that do not exist are appended unresolved. Dangling symlinks
+// are rejected, since anything created through them would end up at a
+// location that cannot be checked reliably.
+func resolve(path string) (string, error) {
+	var missing []string
+	for {
+		resolved, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+
+		if info, lstatErr := os.Lstat(path); lstatErr == nil && info.Mode()&os.ModeSymlink != 0 {
+			return "", fmt.Errorf("%w: %s is a dangling symlink", ErrOutsideRoots, path)
+		}
+
+		parent := filepath.Dir(path)
+		if parent == path {
+			return "", err
+		}
+		missing = append([]string{filepath.Base(path)}, missing...)
+		path = parent
+	}
+}
@@ -0,0 +1,142 @@
+package workspace
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func setupRoots(t *testing.T) (string, string) {
+	t.Helper()
+
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := filepath.Join(base, "root")
+	outside := filepath.Join(base, "outside")
+	for _, dir := range []string{filepath.Join(root, "dir"), outside} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := SetRoots([]string{root}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = SetRoots(nil) })
+
+	return root, outside
+}
+
+func TestCheckDisabled(t *testing.T) {
+	_ = SetRoots(nil)
+	if path, err := Check("/etc/../etc/passwd"); err != nil || path != "/etc/../etc/passwd" {
+		t.Fatalf("expected path to be returned unchanged, got %q, %v", path, err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	root, outside := setupRoots(t)
+
+	mustLink := func(target, link string) {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustLink(outside, "escape")
+	mustLink("dir", "inside")
+	mustLink(filepath.Join(outside, "missing"), "dangling")
+
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{root, true},
+		{filepath.Join(root, "dir"), true},
+		{filepath.Join(root, "dir", "new", "file.txt"), true},
+		{filepath.Join(root, "inside", "file.txt"), true},
+		{filepath.Join(root, "dir", "..", "..", "outside"), false},
+		{filepath.Join(root, "escape"), false},
+		{filepath.Join(root, "escape", "file.txt"), false},
+		{filepath.Join(root, "dangling"), false},
+		{filepath.Join(root, "dangling", "file.txt"), false},
+		{outside, false},
+		{"/etc/passwd", false},
+	}
+
+	for _, tt := range tests {
+		_, err := Check(tt.path)
+		if tt.allowed && err != nil {
+			t.Errorf("%s: expected to be allowed, got %v", tt.path, err)
+		}
+		if !tt.allowed && !errors.Is(err, ErrOutsideRoots) {
+			t.Errorf("%s: expected ErrOutsideRoots, got %v", tt.path, err)
+		}
+	}
+}
+
+func TestCheckNoFollow(t *testing.T) {
+	root, outside := setupRoots(t)
+
+	link := filepath.Join(root, "escape")
+	if err := os.Symlink(outside, link); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := CheckNoFollow(link); err != nil {
+		t.Fatalf("expected the link itself to be allowed, got %v", err)
+	}
+	if _, err := CheckNoFollow(filepath.Join(link, "file.txt")); !errors.Is(err, ErrOutsideRoots) {
+		t.Fatalf("expected ErrOutsideRoots through the link, got %v", err)
+	}
+}
+
+func TestCheckParentAfterSymlink(t *testing.T) {
+	root, outside := setupRoots(t)
+
+	if err := os.Mkdir(filepath.Join(outside, "a"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "a"), filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+	for dir, content := range map[string]string{root: "inside", outside: "outside"} {
+		if err := os.WriteFile(filepath.Join(dir, "secret"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the OS resolves ".." after following link, and would read outside/secret
+	path := filepath.Join(root, "link") + "/../secret"
+	checked, err := Check(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checked != filepath.Join(root, "secret") {
+		t.Fatalf("expected the clean path inside the root, got %s", checked)
+	}
+	if data, err := os.ReadFile(checked); err != nil || string(data) != "inside" {
+		t.Fatalf("expected to read the file inside the root, got %q, %v", data, err)
+	}
+}
+
+func TestCheckRelativeToDefaultDir(t *testing.T) {
+	root, outside := setupRoots(t)
+	t.Chdir(outside)
+
+	for path, expected := range map[string]string{
+		".":       root,
+		"dir/a":   filepath.Join(root, "dir", "a"),
+		"dir/../": root,
+	} {
+		checked, err := Check(path)
+		if err != nil || checked != expected {
+			t.Errorf("%s: expected %s, got %q, %v", path, expected, checked, err)
+		}
+	}
+	if _, err := Check("../outside"); !errors.Is(err, ErrOutsideRoots) {
+		t.Fatalf("expected ErrOutsideRoots, got %v", err)
+	}
+}